@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"pw-comp/dsp"
+)
+
+// envPrefix is prepended to the upper-case parameter names for environment variables.
+const envPrefix = "PWCOMP_"
+
+// configFileName is the config file's path below the user config directory.
+const configFileName = "pw-comp/config.json"
+
+// errInvalidEnv is returned when a parameter environment variable can't be parsed.
+var errInvalidEnv = errors.New("invalid environment variable")
+
+// errInvalidConfig is returned when the config file can't be parsed.
+var errInvalidConfig = errors.New("invalid config file")
+
+// errInvalidLinkMode is returned for an unknown -link-mode name.
+var errInvalidLinkMode = errors.New("invalid link mode")
+
+// linkModes maps the -link-mode names to the dsp link modes.
+var linkModes = map[string]dsp.LinkMode{
+	"detector":      dsp.LinkDetector,
+	"max-reduction": dsp.LinkMaxReduction,
+}
+
+// compressorParams holds the compressor settings configurable from the command
+// line, the environment and the config file.
+type compressorParams struct {
+	Threshold    float64 // Compression threshold in dB
+	Ratio        float64 // Compression ratio
+	Knee         float64 // Soft knee width in dB
+	Attack       float64 // Attack time in milliseconds
+	Release      float64 // Release time in milliseconds
+	Speed        float64 // Factor scaling attack and release together
+	Range        float64 // Largest gain reduction in dB, 0 = unlimited
+	Makeup       float64 // Manual makeup gain in dB, 0 = auto
+	AutoMakeup   bool    // Automatic makeup gain
+	MakeupBypass bool    // Makeup gain bypassed while compression stays active
+	OutputGain   float64 // Output gain trim in dB
+
+	GainStagingLow float64 // Averaged input level in dBFS reported as under-driven
+}
+
+// defaultParams returns the built-in parameter defaults.
+func defaultParams() compressorParams {
+	return compressorParams{
+		Threshold:    -20.0,
+		Ratio:        4.0,
+		Knee:         6.0,
+		Attack:       10.0,
+		Release:      100.0,
+		Speed:        1.0,
+		Range:        0.0,
+		Makeup:       0.0,
+		AutoMakeup:   true,
+		MakeupBypass: false,
+		OutputGain:   0.0,
+
+		GainStagingLow: -40.0,
+	}
+}
+
+// floatFields maps the environment variable suffixes to the float parameters.
+func (params *compressorParams) floatFields() map[string]*float64 {
+	return map[string]*float64{
+		"THRESHOLD":   &params.Threshold,
+		"RATIO":       &params.Ratio,
+		"KNEE":        &params.Knee,
+		"ATTACK":      &params.Attack,
+		"RELEASE":     &params.Release,
+		"SPEED":       &params.Speed,
+		"RANGE":       &params.Range,
+		"MAKEUP":      &params.Makeup,
+		"OUTPUT_GAIN": &params.OutputGain,
+
+		"GAIN_STAGING_LOW": &params.GainStagingLow,
+	}
+}
+
+// loadEnv overrides parameters from PWCOMP_* environment variables read through
+// lookup (e.g. os.LookupEnv). Unset variables keep their current value; all
+// malformed values are reported together and leave their parameter untouched.
+func (params *compressorParams) loadEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+
+	for name, field := range params.floatFields() {
+		raw, ok := lookup(envPrefix + name)
+		if !ok {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s%s=%q", errInvalidEnv, envPrefix, name, raw))
+			continue
+		}
+
+		*field = value
+	}
+
+	if raw, ok := lookup(envPrefix + "AUTO_MAKEUP"); ok {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %sAUTO_MAKEUP=%q", errInvalidEnv, envPrefix, raw))
+		} else {
+			params.AutoMakeup = value
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// configPath returns the config file path: PWCOMP_CONFIG if set through lookup,
+// otherwise config.json in the pw-comp directory of the user config directory
+// (e.g. ~/.config/pw-comp/config.json), or "" if there is none.
+func configPath(lookup func(string) (string, bool)) string {
+	if path, ok := lookup(envPrefix + "CONFIG"); ok {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, configFileName)
+}
+
+// loadConfigFile overrides parameters from the JSON object in the file at
+// path, keyed by the parameter field names as in the control socket's params,
+// e.g. {"Threshold": -30, "AutoMakeup": false}. Missing keys keep their current
+// value, and a missing file (or an empty path) isn't an error. A malformed file
+// or an unknown key leaves all parameters untouched.
+func (params *compressorParams) loadConfigFile(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	loaded := *params
+	if err := decoder.Decode(&loaded); err != nil {
+		return fmt.Errorf("%w %s: %w", errInvalidConfig, path, err)
+	}
+
+	*params = loaded
+
+	return nil
+}
+
+// registerFlags defines the parameter flags on fs, using the current values as
+// defaults so that flags take precedence over the environment.
+func (params *compressorParams) registerFlags(fs *flag.FlagSet) {
+	fs.Float64Var(&params.Threshold, "threshold", params.Threshold, "Compression threshold in dB")
+	fs.Float64Var(&params.Ratio, "ratio", params.Ratio, "Compression ratio (e.g., 4.0 for 4:1)")
+	fs.Float64Var(&params.Knee, "knee", params.Knee, "Soft knee width in dB")
+	fs.Float64Var(&params.Attack, "attack", params.Attack, "Attack time in milliseconds")
+	fs.Float64Var(&params.Release, "release", params.Release, "Release time in milliseconds")
+	fs.Float64Var(&params.Speed, "speed", params.Speed,
+		"Factor scaling attack and release together, 0.1-10 (e.g., 0.5 for twice as fast)")
+	fs.Float64Var(&params.Range, "range", params.Range, "Largest gain reduction in dB (0 = unlimited)")
+	fs.Float64Var(&params.Makeup, "makeup", params.Makeup, "Manual makeup gain in dB (0 = auto)")
+	fs.BoolVar(&params.AutoMakeup, "auto-makeup", params.AutoMakeup, "Enable automatic makeup gain")
+	fs.Float64Var(&params.OutputGain, "output-gain", params.OutputGain,
+		"Output gain trim in dB (applied on top of makeup)")
+	fs.Float64Var(&params.GainStagingLow, "gain-staging-low", params.GainStagingLow,
+		"Averaged input level in dBFS below which an under-driven input is reported")
+}
+
+// apply configures the compressor with the parameters.
+func (params *compressorParams) apply(comp *dsp.SoftKneeCompressor) {
+	comp.SetThreshold(params.Threshold)
+	comp.SetRatio(params.Ratio)
+	comp.SetKnee(params.Knee)
+	comp.SetAttack(params.Attack)
+	comp.SetRelease(params.Release)
+	comp.SetSpeed(params.Speed)
+	comp.SetRange(params.Range)
+
+	switch {
+	case params.Makeup != 0.0:
+		comp.SetMakeupGain(params.Makeup)
+	case params.AutoMakeup:
+		comp.SetAutoMakeup(true)
+	default:
+		// Without auto makeup a zero makeup means 0 dB, not the last auto value
+		comp.SetMakeupGain(0.0)
+	}
+
+	comp.SetMakeupBypass(params.MakeupBypass)
+	comp.SetOutputGain(params.OutputGain)
+	comp.SetGainStagingLowThreshold(params.GainStagingLow)
+}
+
+// captureParams reads the compressor's current settings, so that applying
+// them later restores the same state.
+func captureParams(comp *dsp.SoftKneeCompressor) compressorParams {
+	params := compressorParams{
+		Threshold:    comp.GetThreshold(),
+		Ratio:        comp.GetRatio(),
+		Knee:         comp.GetKnee(),
+		Attack:       comp.GetAttack(),
+		Release:      comp.GetRelease(),
+		Speed:        comp.GetSpeed(),
+		Range:        comp.GetRange(),
+		AutoMakeup:   comp.GetAutoMakeup(),
+		MakeupBypass: comp.GetMakeupBypass(),
+		OutputGain:   comp.GetOutputGain(),
+
+		GainStagingLow: comp.GetGainStagingLowThreshold(),
+	}
+
+	if !params.AutoMakeup {
+		params.Makeup = comp.GetMakeupGain()
+	}
+
+	return params
+}
+
+// parseChannelList parses a comma-separated list of zero-based channel
+// indices; an empty list yields nil.
+func parseChannelList(list string) ([]int, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(list, ",")
+	channels := make([]int, 0, len(fields))
+
+	for _, field := range fields {
+		ch, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("channel %q: %w", field, err)
+		}
+
+		channels = append(channels, ch)
+	}
+
+	return channels, nil
+}
+
+// applySidechainSource makes the channels in the comma-separated list drive
+// the detector of every channel of comp.
+func applySidechainSource(comp *dsp.SoftKneeCompressor, list string) error {
+	channels, err := parseChannelList(list)
+	if err != nil {
+		return err
+	}
+
+	return comp.SetSidechainSource(channels)
+}
+
+// applyLinkWeights sets the per-channel link weights of comp from a
+// comma-separated list; an empty list keeps every weight at 1.
+func applyLinkWeights(comp *dsp.SoftKneeCompressor, list string) error {
+	if strings.TrimSpace(list) == "" {
+		return comp.SetLinkWeights(nil)
+	}
+
+	fields := strings.Split(list, ",")
+	weights := make([]float64, 0, len(fields))
+
+	for _, field := range fields {
+		weight, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return fmt.Errorf("link weight %q: %w", field, err)
+		}
+
+		weights = append(weights, weight)
+	}
+
+	return comp.SetLinkWeights(weights)
+}
+
+// applyLinkMode selects how the channels of comp share gain reduction by
+// its -link-mode name.
+func applyLinkMode(comp *dsp.SoftKneeCompressor, name string) error {
+	mode, ok := linkModes[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", errInvalidLinkMode, name)
+	}
+
+	comp.SetLinkMode(mode)
+
+	return nil
+}
+
+// loadTransferCurve replaces the parametric gain curve of comp with the
+// transfer curve in the file at path.
+func loadTransferCurve(comp *dsp.SoftKneeCompressor, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open transfer curve: %w", err)
+	}
+	defer file.Close()
+
+	return comp.LoadTransferCurve(file)
+}
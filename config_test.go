@@ -0,0 +1,315 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"pw-comp/dsp"
+)
+
+// mapLookup returns an environment lookup backed by a map.
+func mapLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+// TestLoadEnv verifies valid variables override the defaults and missing ones keep them.
+func TestLoadEnv(t *testing.T) {
+	t.Parallel()
+
+	params := defaultParams()
+
+	err := params.loadEnv(mapLookup(map[string]string{
+		"PWCOMP_THRESHOLD":   "-30",
+		"PWCOMP_RATIO":       "8.5",
+		"PWCOMP_SPEED":       "2",
+		"PWCOMP_AUTO_MAKEUP": "false",
+		"PWCOMP_OUTPUT_GAIN": "-1.5",
+		"PWCOMP_RANGE":       "12",
+		"OTHER_THRESHOLD":    "-10",
+
+		"PWCOMP_GAIN_STAGING_LOW": "-50",
+	}))
+	if err != nil {
+		t.Fatalf("loadEnv failed: %v", err)
+	}
+
+	want := defaultParams()
+	want.Threshold = -30.0
+	want.Ratio = 8.5
+	want.Speed = 2.0
+	want.AutoMakeup = false
+	want.OutputGain = -1.5
+	want.Range = 12.0
+	want.GainStagingLow = -50.0
+
+	if params != want {
+		t.Errorf("loadEnv = %+v, want %+v", params, want)
+	}
+}
+
+// TestLoadEnvMalformed verifies malformed values are reported and leave their
+// parameter at the default while valid ones still apply.
+func TestLoadEnvMalformed(t *testing.T) {
+	t.Parallel()
+
+	params := defaultParams()
+
+	err := params.loadEnv(mapLookup(map[string]string{
+		"PWCOMP_THRESHOLD":   "loud",
+		"PWCOMP_AUTO_MAKEUP": "maybe",
+		"PWCOMP_KNEE":        "3",
+	}))
+	if !errors.Is(err, errInvalidEnv) {
+		t.Fatalf("Expected errInvalidEnv, got %v", err)
+	}
+
+	defaults := defaultParams()
+	if params.Threshold != defaults.Threshold || params.AutoMakeup != defaults.AutoMakeup {
+		t.Errorf("Malformed values should keep the defaults, got threshold %f, auto makeup %t",
+			params.Threshold, params.AutoMakeup)
+	}
+
+	if params.Knee != 3.0 {
+		t.Errorf("Valid variables should still apply, got knee %f", params.Knee)
+	}
+}
+
+// TestFlagsOverrideEnv verifies a flag given on the command line wins over the environment.
+func TestFlagsOverrideEnv(t *testing.T) {
+	t.Parallel()
+
+	params := defaultParams()
+	if err := params.loadEnv(mapLookup(map[string]string{
+		"PWCOMP_THRESHOLD": "-30",
+		"PWCOMP_RATIO":     "8",
+	})); err != nil {
+		t.Fatalf("loadEnv failed: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	params.registerFlags(fs)
+
+	if err := fs.Parse([]string{"-threshold", "-12"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if params.Threshold != -12.0 || params.Ratio != 8.0 {
+		t.Errorf("Expected flag threshold -12 and env ratio 8, got %f and %f", params.Threshold, params.Ratio)
+	}
+}
+
+// TestConfigFileMerge verifies the config file overrides the built-in
+// defaults, flags override the config file, and a missing file changes nothing.
+func TestConfigFileMerge(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"threshold": -30, "Ratio": 8, "AutoMakeup": false}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	params := defaultParams()
+	if err := params.loadConfigFile(path); err != nil {
+		t.Fatalf("loadConfigFile failed: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	params.registerFlags(fs)
+
+	if err := fs.Parse([]string{"-threshold", "-12"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want := defaultParams()
+	want.Threshold = -12.0
+	want.Ratio = 8.0
+	want.AutoMakeup = false
+
+	if params != want {
+		t.Errorf("Merged parameters %+v, want %+v", params, want)
+	}
+
+	missing := defaultParams()
+	if err := missing.loadConfigFile(filepath.Join(t.TempDir(), "missing.json")); err != nil || missing != defaultParams() {
+		t.Errorf("Missing config file gave %+v, %v; want the defaults and no error", missing, err)
+	}
+}
+
+// TestConfigFileMalformed verifies a config file with an unknown key or bad
+// JSON is rejected without changing any parameter.
+func TestConfigFileMalformed(t *testing.T) {
+	t.Parallel()
+
+	for _, content := range []string{`{"Ratio": 8, "Treshold": -30}`, `{"Ratio": "eight"}`, `{"Ratio": 8`} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		params := defaultParams()
+
+		err := params.loadConfigFile(path)
+		if !errors.Is(err, errInvalidConfig) {
+			t.Errorf("Config %s gave error %v, want errInvalidConfig", content, err)
+		}
+
+		if params != defaultParams() {
+			t.Errorf("Config %s changed the parameters to %+v", content, params)
+		}
+	}
+}
+
+// TestConfigPath verifies PWCOMP_CONFIG overrides the default config path.
+func TestConfigPath(t *testing.T) {
+	t.Parallel()
+
+	if got := configPath(mapLookup(map[string]string{"PWCOMP_CONFIG": "/etc/pw-comp.json"})); got != "/etc/pw-comp.json" {
+		t.Errorf("Config path %q, want the PWCOMP_CONFIG value", got)
+	}
+
+	if got := configPath(mapLookup(nil)); got != "" && filepath.Base(got) != "config.json" {
+		t.Errorf("Default config path %q, want a config.json", got)
+	}
+}
+
+// TestApplyRoundTrip verifies captured parameters apply back unchanged, and
+// that disabling auto makeup without a makeup value gives 0 dB makeup.
+func TestApplyRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	params := defaultParams()
+	params.Threshold = -26.0
+	params.Range = 9.0
+	params.AutoMakeup = false
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+	params.apply(comp)
+
+	if got := comp.GetMakeupGain(); got != 0.0 {
+		t.Errorf("Makeup %f dB with auto makeup disabled, want 0 dB", got)
+	}
+
+	if got := captureParams(comp); got != params {
+		t.Errorf("captureParams = %+v, want %+v", got, params)
+	}
+}
+
+// TestLoadTransferCurveFile verifies a curve file is loaded and a missing file
+// is reported.
+func TestLoadTransferCurveFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "curve.txt")
+	if err := os.WriteFile(path, []byte("-20 -20\n0 -10\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+
+	if err := loadTransferCurve(comp, path); err != nil {
+		t.Fatalf("loadTransferCurve failed: %v", err)
+	}
+
+	if !comp.HasTransferCurve() {
+		t.Error("Expected the transfer curve to be active")
+	}
+
+	if err := loadTransferCurve(comp, filepath.Join(t.TempDir(), "missing.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not-exist error for a missing file, got %v", err)
+	}
+}
+
+// TestApplySidechainSource verifies the channel list flag is parsed and
+// applied, and that bad entries are rejected.
+func TestApplySidechainSource(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+
+	if err := applySidechainSource(comp, "1"); err != nil {
+		t.Fatalf("applySidechainSource failed: %v", err)
+	}
+
+	if got := comp.GetSidechainSource(); !slices.Equal(got, []int{1}) {
+		t.Errorf("Expected sidechain source [1], got %v", got)
+	}
+
+	if err := applySidechainSource(comp, " 0, 1 "); err != nil {
+		t.Fatalf("applySidechainSource failed: %v", err)
+	}
+
+	if got := comp.GetSidechainSource(); !slices.Equal(got, []int{0, 1}) {
+		t.Errorf("Expected sidechain source [0 1], got %v", got)
+	}
+
+	if err := applySidechainSource(comp, "left"); err == nil {
+		t.Error("Expected an error for a non-numeric channel")
+	}
+
+	if err := applySidechainSource(comp, "2"); !errors.Is(err, dsp.ErrInvalidChannel) {
+		t.Errorf("Expected ErrInvalidChannel for an out-of-range channel, got %v", err)
+	}
+}
+
+// TestApplyLinkMode verifies the -link-mode names and that unknown names are
+// rejected.
+func TestApplyLinkMode(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+
+	if err := applyLinkMode(comp, "max-reduction"); err != nil {
+		t.Fatalf("applyLinkMode failed: %v", err)
+	}
+
+	if comp.GetLinkMode() != dsp.LinkMaxReduction {
+		t.Error("Expected the max-reduction link mode")
+	}
+
+	if err := applyLinkMode(comp, "stereo"); !errors.Is(err, errInvalidLinkMode) {
+		t.Errorf("Expected errInvalidLinkMode for an unknown mode, got %v", err)
+	}
+
+	if comp.GetLinkMode() != dsp.LinkMaxReduction {
+		t.Error("An unknown mode must keep the current link mode")
+	}
+}
+
+// TestApplyLinkWeights verifies the weight list is parsed and applied, and that
+// malformed or mismatched lists are rejected.
+func TestApplyLinkWeights(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+
+	if err := applyLinkWeights(comp, "1, 0.5"); err != nil {
+		t.Fatalf("applyLinkWeights failed: %v", err)
+	}
+
+	if got := comp.GetLinkWeights(); !slices.Equal(got, []float64{1, 0.5}) {
+		t.Errorf("Expected link weights [1 0.5], got %v", got)
+	}
+
+	if err := applyLinkWeights(comp, "1,x"); err == nil {
+		t.Error("Expected an error for a non-numeric weight")
+	}
+
+	if err := applyLinkWeights(comp, "1"); !errors.Is(err, dsp.ErrInvalidLinkWeights) {
+		t.Errorf("Expected ErrInvalidLinkWeights for a missing weight, got %v", err)
+	}
+
+	if err := applyLinkWeights(comp, ""); err != nil {
+		t.Fatalf("applyLinkWeights failed: %v", err)
+	}
+
+	if got := comp.GetLinkWeights(); !slices.Equal(got, []float64{1, 1}) {
+		t.Errorf("Expected an empty list to reset the weights, got %v", got)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"pw-comp/dsp"
+)
+
+// controlInterval is the rate at which meter messages are streamed to clients.
+const controlInterval = 50 * time.Millisecond
+
+// errInvalidCommand is returned when a control command can't be decoded or applied.
+var errInvalidCommand = errors.New("invalid control command")
+
+// controlMessage is a newline-delimited JSON message sent to control clients:
+// "meters" with the meters and parameters, or "error" for a rejected command.
+type controlMessage struct {
+	Type   string            `json:"type"`
+	Meters *dsp.MeterStats   `json:"meters,omitempty"`
+	Params *compressorParams `json:"params,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// controlCommand is a newline-delimited JSON command received from a control
+// client, e.g. {"type":"set","param":"threshold","value":-30}. Switches such as
+// auto_makeup and bypass are on for any non-zero value.
+type controlCommand struct {
+	Type  string  `json:"type"`
+	Param string  `json:"param"`
+	Value float64 `json:"value"`
+}
+
+// controlSetters maps the parameter names of set commands to their setters.
+var controlSetters = map[string]func(*dsp.SoftKneeCompressor, float64){
+	"threshold":   (*dsp.SoftKneeCompressor).SetThreshold,
+	"ratio":       (*dsp.SoftKneeCompressor).SetRatio,
+	"knee":        (*dsp.SoftKneeCompressor).SetKnee,
+	"attack":      (*dsp.SoftKneeCompressor).SetAttack,
+	"release":     (*dsp.SoftKneeCompressor).SetRelease,
+	"speed":       (*dsp.SoftKneeCompressor).SetSpeed,
+	"range":       (*dsp.SoftKneeCompressor).SetRange,
+	"makeup":      (*dsp.SoftKneeCompressor).SetMakeupGain,
+	"output_gain": (*dsp.SoftKneeCompressor).SetOutputGain,
+	"auto_makeup": func(comp *dsp.SoftKneeCompressor, value float64) { comp.SetAutoMakeup(value != 0.0) },
+	"bypass":      func(comp *dsp.SoftKneeCompressor, value float64) { comp.SetBypass(value != 0.0) },
+	"link_max_reduction": func(comp *dsp.SoftKneeCompressor, value float64) {
+		if value != 0.0 {
+			comp.SetLinkMode(dsp.LinkMaxReduction)
+		} else {
+			comp.SetLinkMode(dsp.LinkDetector)
+		}
+	},
+}
+
+// encodeMeterMessage encodes a meter message as one line of JSON.
+func encodeMeterMessage(stats dsp.MeterStats, params compressorParams) ([]byte, error) {
+	return encodeControlMessage(controlMessage{Type: "meters", Meters: &stats, Params: &params})
+}
+
+// encodeControlMessage encodes a message as one line of JSON.
+func encodeControlMessage(msg controlMessage) ([]byte, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s message: %w", msg.Type, err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// decodeControlCommand decodes and validates one line of JSON from a client.
+func decodeControlCommand(line []byte) (controlCommand, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.DisallowUnknownFields()
+
+	var cmd controlCommand
+
+	if err := decoder.Decode(&cmd); err != nil {
+		return controlCommand{}, fmt.Errorf("%w: %w", errInvalidCommand, err)
+	}
+
+	if cmd.Type != "set" {
+		return controlCommand{}, fmt.Errorf("%w: unknown type %q", errInvalidCommand, cmd.Type)
+	}
+
+	if _, ok := controlSetters[cmd.Param]; !ok {
+		return controlCommand{}, fmt.Errorf("%w: unknown parameter %q", errInvalidCommand, cmd.Param)
+	}
+
+	return cmd, nil
+}
+
+// apply runs the command against the compressor.
+func (cmd controlCommand) apply(comp *dsp.SoftKneeCompressor) {
+	controlSetters[cmd.Param](comp, cmd.Value)
+}
+
+// controlServer streams meters to and accepts commands from clients connected
+// to a Unix domain socket.
+type controlServer struct {
+	listener net.Listener
+	comp     *dsp.SoftKneeCompressor
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// startControlServer listens on the Unix socket at path and returns a function
+// that stops the server. A stale socket left behind by a crashed instance is
+// replaced.
+func startControlServer(path string, comp *dsp.SoftKneeCompressor) (func(), error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("control socket %s is in use", path)
+		}
+
+		_ = os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen on control socket %s: %w", path, err)
+	}
+
+	server := &controlServer{
+		listener: listener,
+		comp:     comp,
+		done:     make(chan struct{}),
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	server.wg.Add(1)
+
+	go server.acceptLoop()
+
+	slog.Info("Control socket started", "path", path)
+
+	return server.stop, nil
+}
+
+// acceptLoop serves each client in its own goroutines until the listener closes.
+func (s *controlServer) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+			default:
+				slog.Error("Control socket accept failed", "err", err)
+			}
+
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+
+		go s.serve(conn)
+	}
+}
+
+// serve streams meter messages to a client and applies its commands until
+// either side closes the connection.
+func (s *controlServer) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	var writeMu sync.Mutex
+
+	write := func(data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+
+		_, err := conn.Write(data)
+
+		return err
+	}
+
+	closed := make(chan struct{})
+
+	go func() {
+		defer close(closed)
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			cmd, err := decodeControlCommand(scanner.Bytes())
+			if err != nil {
+				if data, encErr := encodeControlMessage(controlMessage{Type: "error", Error: err.Error()}); encErr == nil {
+					_ = write(data)
+				}
+
+				continue
+			}
+
+			cmd.apply(s.comp)
+			slog.Info("Control command applied", "param", cmd.Param, "value", cmd.Value)
+		}
+	}()
+
+	ticker := time.NewTicker(controlInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-closed:
+			return
+		case <-ticker.C:
+			data, err := encodeMeterMessage(s.comp.GetMeters(), captureParams(s.comp))
+			if err != nil {
+				slog.Error("Control message encoding failed", "err", err)
+				continue
+			}
+
+			if write(data) != nil {
+				return
+			}
+		}
+	}
+}
+
+// stop closes the listener and all client connections and waits for them.
+func (s *controlServer) stop() {
+	close(s.done)
+	s.listener.Close()
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"pw-comp/dsp"
+)
+
+// TestControlMeterMessageRoundTrip verifies a meter message survives encoding
+// as a single JSON line.
+func TestControlMeterMessageRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	stats := dsp.MeterStats{
+		InputL:                0.5,
+		OutputR:               0.25,
+		GainReductionMeterL:   -6.5,
+		AverageGainReductionR: -3.0,
+		InputClipL:            true,
+		GainStagingHint:       dsp.GainStagingLow,
+		Blocks:                1234,
+		SampleRate:            48000.0,
+	}
+	params := defaultParams()
+	params.Threshold = -30.0
+
+	data, err := encodeMeterMessage(stats, params)
+	if err != nil {
+		t.Fatalf("encodeMeterMessage failed: %v", err)
+	}
+
+	if !bytes.HasSuffix(data, []byte("\n")) || bytes.Count(data, []byte("\n")) != 1 {
+		t.Fatalf("Expected a single newline-terminated line, got %q", data)
+	}
+
+	var msg controlMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("Decoding the message failed: %v", err)
+	}
+
+	if msg.Type != "meters" || msg.Meters == nil || msg.Params == nil {
+		t.Fatalf("Unexpected message %+v", msg)
+	}
+
+	if *msg.Meters != stats {
+		t.Errorf("Meters %+v, want %+v", *msg.Meters, stats)
+	}
+
+	if *msg.Params != params {
+		t.Errorf("Params %+v, want %+v", *msg.Params, params)
+	}
+}
+
+// TestControlSetCommand verifies set commands are decoded and applied, and
+// malformed ones are rejected.
+func TestControlSetCommand(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+
+	for _, line := range []string{
+		`{"type":"set","param":"threshold","value":-32.5}`,
+		`{"type":"set","param":"speed","value":0.5}`,
+		`{"type":"set","param":"auto_makeup","value":0}`,
+		`{"type":"set","param":"bypass","value":1}`,
+		`{"type":"set","param":"link_max_reduction","value":1}`,
+	} {
+		cmd, err := decodeControlCommand([]byte(line))
+		if err != nil {
+			t.Fatalf("decodeControlCommand(%s) failed: %v", line, err)
+		}
+
+		cmd.apply(comp)
+	}
+
+	if got := comp.GetThreshold(); got != -32.5 {
+		t.Errorf("Threshold %.1f dB, want -32.5 dB", got)
+	}
+
+	if got := comp.GetSpeed(); got != 0.5 {
+		t.Errorf("Speed %.1f, want 0.5", got)
+	}
+
+	if comp.GetAutoMakeup() {
+		t.Error("Expected auto makeup to be switched off")
+	}
+
+	if !comp.GetBypass() {
+		t.Error("Expected bypass to be switched on")
+	}
+
+	if comp.GetLinkMode() != dsp.LinkMaxReduction {
+		t.Error("Expected the max-reduction link mode")
+	}
+
+	for _, line := range []string{
+		`not json`,
+		`{"type":"get","param":"threshold"}`,
+		`{"type":"set","param":"volume","value":1}`,
+		`{"type":"set","param":"ratio","value":"4"}`,
+		`{"type":"set","param":"ratio","value":4,"extra":true}`,
+	} {
+		if _, err := decodeControlCommand([]byte(line)); !errors.Is(err, errInvalidCommand) {
+			t.Errorf("decodeControlCommand(%s): expected errInvalidCommand, got %v", line, err)
+		}
+	}
+}
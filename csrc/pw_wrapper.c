@@ -7,14 +7,54 @@
 #include <string.h>
 
 // Go function
-extern void process_channel_go(float *in, float *out, int samples,
-                               int sample_rate, int channel_index);
+extern void process_quantum_go(float **in, float **out, int channels,
+                              int samples, int sample_rate);
 extern void log_from_c(char *msg);
+extern void on_stream_restart_go(int sample_rate);
+extern void on_node_ready_go(uint32_t node_id, uint64_t serial);
+extern void on_loop_started_go(void);
+extern void fill_gr_cv_go(float *out, int samples);
 int pw_debug = 0;
+int pw_gr_cv = 0; // Set to add the gain reduction CV output port
+int pw_latency_samples = 0; // Processing delay reported to the graph
+
+// Report the node id and object serial to Go once PipeWire registered the
+// node, so that scripts can address this instance with pw-cli or wpctl.
+static void report_node(struct pw_filter_data *data) {
+  if (data->node_id != SPA_ID_INVALID)
+    return;
+
+  uint32_t node_id = pw_filter_get_node_id(data->filter);
+  if (node_id == SPA_ID_INVALID)
+    return;
+
+  data->node_id = node_id;
+
+  uint64_t serial = 0;
+  const struct pw_properties *props =
+      pw_filter_get_properties(data->filter, NULL);
+  const char *serial_str =
+      props ? pw_properties_get(props, PW_KEY_OBJECT_SERIAL) : NULL;
+  if (serial_str)
+    serial = strtoull(serial_str, NULL, 10);
+
+  on_node_ready_go(node_id, serial);
+}
+
+// Idle callback run on the first main loop iteration: tells Go the loop is
+// running, then disables itself.
+static void on_loop_started(void *userdata) {
+  struct pw_filter_data *data = userdata;
+
+  pw_loop_enable_idle(pw_main_loop_get_loop(data->loop), data->start_source,
+                      false);
+  on_loop_started_go();
+}
 
 // State listener callback
-static void on_state_changed(void *data, enum pw_filter_state old,
+static void on_state_changed(void *userdata, enum pw_filter_state old,
                              enum pw_filter_state state, const char *error) {
+  struct pw_filter_data *data = userdata;
   char msg[256];
   snprintf(msg, sizeof(msg), "State change: %s -> %s",
            pw_filter_state_as_string(old), pw_filter_state_as_string(state));
@@ -24,6 +64,21 @@ static void on_state_changed(void *data, enum pw_filter_state old,
     snprintf(msg, sizeof(msg), "Error: %s", error);
     log_from_c(msg);
   }
+
+  if (data && (state == PW_FILTER_STATE_PAUSED ||
+               state == PW_FILTER_STATE_STREAMING)) {
+    report_node(data);
+  }
+
+  // Re-entering STREAMING after the first run means PipeWire restarted the
+  // node (e.g. after an xrun), so let Go drop stale envelope state.
+  if (data && state == PW_FILTER_STATE_STREAMING &&
+      old != PW_FILTER_STATE_STREAMING) {
+    if (data->has_streamed) {
+      on_stream_restart_go((int)data->sample_rate);
+    }
+    data->has_streamed = 1;
+  }
 }
 
 static void on_add_buffer(void *data, void *port_data,
@@ -46,6 +101,44 @@ static void on_add_buffer(void *data, void *port_data,
   pw_filter_queue_buffer(port_data, buffer);
 }
 
+// Fill the gain reduction CV port with the envelope of the block just
+// processed.
+static void write_gr_cv(struct pw_filter_data *data, uint32_t n_samples) {
+  struct pw_buffer *buf = pw_filter_dequeue_buffer(data->cv_port);
+  if (buf == NULL)
+    return;
+
+  uint32_t samples = n_samples;
+  if (buf->buffer && buf->buffer->n_datas > 0) {
+    uint32_t max_samples = buf->buffer->datas[0].maxsize / sizeof(float);
+    if (max_samples > 0 && samples > max_samples)
+      samples = max_samples;
+  }
+
+  float *out = pw_filter_get_dsp_buffer(data->cv_port, samples);
+  if (out == NULL && buf->buffer && buf->buffer->n_datas > 0) {
+    struct spa_data *d = &buf->buffer->datas[0];
+    if (d->data && (d->flags & SPA_DATA_FLAG_WRITABLE)) {
+      uint32_t offset = d->chunk ? d->chunk->offset : 0;
+      out = (float *)((uint8_t *)d->data + offset);
+    }
+  }
+
+  if (out) {
+    fill_gr_cv_go(out, (int)samples);
+
+    buf->size = samples;
+    if (buf->buffer && buf->buffer->datas[0].chunk) {
+      buf->buffer->datas[0].chunk->offset = 0;
+      buf->buffer->datas[0].chunk->size = samples * sizeof(float);
+      buf->buffer->datas[0].chunk->stride = sizeof(float);
+      buf->buffer->datas[0].chunk->flags = 0;
+    }
+  }
+
+  pw_filter_queue_buffer(data->cv_port, buf);
+}
+
 // Callback function for processing audio
 static void on_process(void *userdata, struct spa_io_position *position) {
   struct pw_filter_data *data = userdata;
@@ -61,6 +154,7 @@ static void on_process(void *userdata, struct spa_io_position *position) {
   } else {
     return;
   }
+  data->sample_rate = sample_rate;
 
   if (pw_debug && (process_cnt < 20 || process_cnt % 100 == 0)) {
     char msg[128];
@@ -69,10 +163,18 @@ static void on_process(void *userdata, struct spa_io_position *position) {
     log_from_c(msg);
   }
 
-  // Process each channel
+  // Resolve every channel's buffers first, so the whole quantum can be
+  // handed to Go in one call and cross-channel detection sees all channels
+  uint32_t samples = n_samples;
+  int use_scratch = 0;
   for (int i = 0; i < data->channels; i++) {
     struct pw_buffer *in_buf = pw_filter_dequeue_buffer(data->in_ports[i]);
     struct pw_buffer *out_buf = pw_filter_dequeue_buffer(data->out_ports[i]);
+    data->in_bufs[i] = in_buf;
+    data->out_bufs[i] = out_buf;
+    data->in_ptrs[i] = NULL;
+    data->out_ptrs[i] = NULL;
+    data->out_lens[i] = 0;
 
     if (pw_debug && process_cnt < 20) {
       char msg[128];
@@ -87,8 +189,7 @@ static void on_process(void *userdata, struct spa_io_position *position) {
                  "WARNING: CH%d Output buffer is NULL (Unconnected?)", i);
         log_from_c(msg);
       }
-      if (in_buf)
-        pw_filter_queue_buffer(data->in_ports[i], in_buf);
+      use_scratch = 1;
       continue;
     }
 
@@ -113,11 +214,13 @@ static void on_process(void *userdata, struct spa_io_position *position) {
       }
     }
     if (out == NULL) {
-      pw_filter_queue_buffer(data->out_ports[i], out_buf);
-      if (in_buf)
-        pw_filter_queue_buffer(data->in_ports[i], in_buf);
+      use_scratch = 1;
       continue;
     }
+    data->out_ptrs[i] = out;
+    data->out_lens[i] = out_samples;
+    if (out_samples < samples)
+      samples = out_samples;
 
     float *in = NULL;
     uint32_t in_samples = out_samples;
@@ -151,25 +254,64 @@ static void on_process(void *userdata, struct spa_io_position *position) {
     }
 
     if (in) {
-      process_channel_go(in, out, (int)in_samples, (int)sample_rate, i);
+      data->in_ptrs[i] = in;
+      if (in_samples < samples)
+        samples = in_samples;
     } else {
+      // No input: process silence in place
       memset(out, 0, out_samples * sizeof(float));
-      process_channel_go(out, out, (int)out_samples, (int)sample_rate, i);
+      data->in_ptrs[i] = out;
     }
+  }
 
-    // Output buffers need a valid size for downstream to consume them.
-    out_buf->size = out_samples;
-    if (out_buf->buffer && out_buf->buffer->datas[0].chunk) {
-      out_buf->buffer->datas[0].chunk->offset = 0;
-      out_buf->buffer->datas[0].chunk->size = out_samples * sizeof(float);
-      out_buf->buffer->datas[0].chunk->stride = sizeof(float);
-      out_buf->buffer->datas[0].chunk->flags = 0;
+  // Channels without a usable output still need a buffer so the channel
+  // order Go sees stays intact; they process silence into scratch
+  if (use_scratch) {
+    if (samples > PW_SCRATCH_SAMPLES)
+      samples = PW_SCRATCH_SAMPLES;
+    for (int i = 0; i < data->channels; i++) {
+      if (data->out_ptrs[i] == NULL) {
+        float *scratch = data->scratch + (size_t)i * PW_SCRATCH_SAMPLES;
+        memset(scratch, 0, samples * sizeof(float));
+        data->in_ptrs[i] = scratch;
+        data->out_ptrs[i] = scratch;
+      }
+    }
+  }
+
+  if (samples > 0)
+    process_quantum_go(data->in_ptrs, data->out_ptrs, data->channels,
+                       (int)samples, (int)sample_rate);
+
+  for (int i = 0; i < data->channels; i++) {
+    struct pw_buffer *in_buf = data->in_bufs[i];
+    struct pw_buffer *out_buf = data->out_bufs[i];
+    uint32_t out_samples = data->out_lens[i];
+
+    if (out_buf && out_samples > 0) {
+      // Silence whatever the shortest channel left unprocessed
+      if (out_samples > samples)
+        memset(data->out_ptrs[i] + samples, 0,
+               (out_samples - samples) * sizeof(float));
+
+      // Output buffers need a valid size for downstream to consume them.
+      out_buf->size = out_samples;
+      if (out_buf->buffer && out_buf->buffer->datas[0].chunk) {
+        out_buf->buffer->datas[0].chunk->offset = 0;
+        out_buf->buffer->datas[0].chunk->size = out_samples * sizeof(float);
+        out_buf->buffer->datas[0].chunk->stride = sizeof(float);
+        out_buf->buffer->datas[0].chunk->flags = 0;
+      }
     }
 
     if (in_buf)
       pw_filter_queue_buffer(data->in_ports[i], in_buf);
-    pw_filter_queue_buffer(data->out_ports[i], out_buf);
+    if (out_buf)
+      pw_filter_queue_buffer(data->out_ports[i], out_buf);
   }
+
+  if (data->cv_port)
+    write_gr_cv(data, n_samples);
 }
 
 static const struct pw_filter_events filter_events = {
@@ -179,9 +321,29 @@ static const struct pw_filter_events filter_events = {
     .add_buffer = on_add_buffer,
 };
 
-// Helper to get channel name/position
-static void get_channel_config(int i, int total, char *name, size_t max_len,
-                               uint32_t *pos) {
+// Surround channel orders matching the Go surround layouts: quad, 5.1, 7.1
+static const char *const quad_names[] = {"FL", "FR", "RL", "RR"};
+static const uint32_t quad_pos[] = {SPA_AUDIO_CHANNEL_FL, SPA_AUDIO_CHANNEL_FR,
+                                    SPA_AUDIO_CHANNEL_RL, SPA_AUDIO_CHANNEL_RR};
+static const char *const surround51_names[] = {"FL",  "FR", "FC",
+                                               "LFE", "SL", "SR"};
+static const uint32_t surround51_pos[] = {
+    SPA_AUDIO_CHANNEL_FL,  SPA_AUDIO_CHANNEL_FR, SPA_AUDIO_CHANNEL_FC,
+    SPA_AUDIO_CHANNEL_LFE, SPA_AUDIO_CHANNEL_SL, SPA_AUDIO_CHANNEL_SR};
+static const char *const surround71_names[] = {"FL", "FR", "FC", "LFE",
+                                               "RL", "RR", "SL", "SR"};
+static const uint32_t surround71_pos[] = {
+    SPA_AUDIO_CHANNEL_FL, SPA_AUDIO_CHANNEL_FR,  SPA_AUDIO_CHANNEL_FC,
+    SPA_AUDIO_CHANNEL_LFE, SPA_AUDIO_CHANNEL_RL, SPA_AUDIO_CHANNEL_RR,
+    SPA_AUDIO_CHANNEL_SL, SPA_AUDIO_CHANNEL_SR};
+
+// Helper to get channel name/position; returns 1 if the channel has a
+// standard position, 0 for generic CHn ports
+static int get_channel_config(int i, int total, char *name, size_t max_len,
+                              uint32_t *pos) {
+  const char *const *names = NULL;
+  const uint32_t *positions = NULL;
+
   if (total == 2) {
     if (i == 0) {
       snprintf(name, max_len, "FL");
@@ -190,13 +352,31 @@ static void get_channel_config(int i, int total, char *name, size_t max_len,
       snprintf(name, max_len, "FR");
       *pos = SPA_AUDIO_CHANNEL_FR;
     }
+    return 1;
   } else if (total == 1) {
     snprintf(name, max_len, "MONO");
     *pos = SPA_AUDIO_CHANNEL_MONO;
-  } else {
-    snprintf(name, max_len, "CH%d", i + 1);
-    *pos = SPA_AUDIO_CHANNEL_MONO;
+    return 1;
+  } else if (total == 4) {
+    names = quad_names;
+    positions = quad_pos;
+  } else if (total == 6) {
+    names = surround51_names;
+    positions = surround51_pos;
+  } else if (total == 8) {
+    names = surround71_names;
+    positions = surround71_pos;
+  }
+
+  if (names) {
+    snprintf(name, max_len, "%s", names[i]);
+    *pos = positions[i];
+    return 1;
   }
+
+  snprintf(name, max_len, "CH%d", i + 1);
+  *pos = SPA_AUDIO_CHANNEL_MONO;
+  return 0;
 }
 
 struct pw_filter_data *create_pipewire_filter(struct pw_main_loop *loop,
@@ -207,6 +387,7 @@ struct pw_filter_data *create_pipewire_filter(struct pw_main_loop *loop,
   struct pw_filter_data *data = calloc(1, sizeof(struct pw_filter_data));
   data->loop = loop;
   data->channels = channels;
+  data->node_id = SPA_ID_INVALID;
 
   data->context = pw_context_new(pw_main_loop_get_loop(loop), NULL, 0);
   if (!data->context) {
@@ -240,21 +421,26 @@ struct pw_filter_data *create_pipewire_filter(struct pw_main_loop *loop,
   pw_filter_add_listener(data->filter, &data->filter_listener, &filter_events,
                          data);
 
+  data->start_source = pw_loop_add_idle(pw_main_loop_get_loop(loop), true,
+                                        on_loop_started, data);
+
   data->in_ports = calloc(channels, sizeof(struct port_data *));
   data->out_ports = calloc(channels, sizeof(struct port_data *));
+  data->in_bufs = calloc(channels, sizeof(struct pw_buffer *));
+  data->out_bufs = calloc(channels, sizeof(struct pw_buffer *));
+  data->in_ptrs = calloc(channels, sizeof(float *));
+  data->out_ptrs = calloc(channels, sizeof(float *));
+  data->out_lens = calloc(channels, sizeof(uint32_t));
+  data->scratch = calloc((size_t)channels * PW_SCRATCH_SAMPLES, sizeof(float));
 
   uint8_t buffer[1024];
 
   for (int i = 0; i < channels; i++) {
     char ch_name[32];
     uint32_t ch_pos;
-    get_channel_config(i, channels, ch_name, sizeof(ch_name), &ch_pos);
     const char *channel_prop = NULL;
-    if (channels == 2) {
-      channel_prop = (i == 0) ? "FL" : "FR";
-    } else if (channels == 1) {
-      channel_prop = "MONO";
-    }
+    if (get_channel_config(i, channels, ch_name, sizeof(ch_name), &ch_pos))
+      channel_prop = ch_name;
 
     struct spa_pod_builder b = SPA_POD_BUILDER_INIT(buffer, sizeof(buffer));
     const struct spa_pod *params[1];
@@ -315,12 +501,44 @@ struct pw_filter_data *create_pipewire_filter(struct pw_main_loop *loop,
     data->out_ports[i]->channel = i;
   }
 
+  if (pw_gr_cv) {
+    // Unipolar gain reduction envelope (0 = none, 1 = full scale) as a mono
+    // audio-rate signal, for modulating other effects
+    struct spa_pod_builder b = SPA_POD_BUILDER_INIT(buffer, sizeof(buffer));
+    const struct spa_pod *params[1];
+    uint32_t positions[1] = {SPA_AUDIO_CHANNEL_MONO};
+
+    params[0] = spa_pod_builder_add_object(
+        &b, SPA_TYPE_OBJECT_Format, SPA_PARAM_EnumFormat, SPA_FORMAT_mediaType,
+        SPA_POD_Id(SPA_MEDIA_TYPE_audio), SPA_FORMAT_mediaSubtype,
+        SPA_POD_Id(SPA_MEDIA_SUBTYPE_raw), SPA_FORMAT_AUDIO_format,
+        SPA_POD_Id(SPA_AUDIO_FORMAT_F32), SPA_FORMAT_AUDIO_rate,
+        SPA_POD_CHOICE_RANGE_Int(48000, 1, 384000), SPA_FORMAT_AUDIO_channels,
+        SPA_POD_Int(1), SPA_FORMAT_AUDIO_position,
+        SPA_POD_Array(sizeof(uint32_t), SPA_TYPE_Id, 1, positions), 0);
+
+    struct pw_properties *cv_props = pw_properties_new(
+        PW_KEY_PORT_NAME, "output_GR_CV", PW_KEY_FORMAT_DSP,
+        "32 bit float mono audio", PW_KEY_MEDIA_TYPE, "Audio", NULL);
+
+    data->cv_port = pw_filter_add_port(
+        data->filter, PW_DIRECTION_OUTPUT, PW_FILTER_PORT_FLAG_MAP_BUFFERS,
+        sizeof(struct port_data), cv_props, params, 1);
+
+    if (!data->cv_port) {
+      destroy_pipewire_filter(data);
+      return NULL;
+    }
+
+    data->cv_port->direction = PW_DIRECTION_OUTPUT;
+    data->cv_port->channel = -1;
+  }
+
   struct spa_pod_builder b_lat = SPA_POD_BUILDER_INIT(buffer, sizeof(buffer));
   const struct spa_pod *connect_params[1];
   connect_params[0] = spa_process_latency_build(
       &b_lat, SPA_PARAM_ProcessLatency,
-      &SPA_PROCESS_LATENCY_INFO_INIT(.ns = 1024 * SPA_NSEC_PER_SEC /
-                                           48000)); // ~21ms
+      &SPA_PROCESS_LATENCY_INFO_INIT(.rate = (uint32_t)pw_latency_samples));
 
   if (pw_filter_connect(data->filter, PW_FILTER_FLAG_RT_PROCESS, connect_params,
                         1) < 0) {
@@ -337,6 +555,9 @@ struct pw_filter_data *create_pipewire_filter(struct pw_main_loop *loop,
 void destroy_pipewire_filter(struct pw_filter_data *data) {
   if (!data)
     return;
+  if (data->start_source)
+    pw_loop_destroy_source(pw_main_loop_get_loop(data->loop),
+                           data->start_source);
   if (data->filter)
     pw_filter_destroy(data->filter);
   if (data->core)
@@ -348,5 +569,11 @@ void destroy_pipewire_filter(struct pw_filter_data *data) {
     free(data->in_ports);
   if (data->out_ports)
     free(data->out_ports);
+  free(data->in_bufs);
+  free(data->out_bufs);
+  free(data->in_ptrs);
+  free(data->out_ptrs);
+  free(data->out_lens);
+  free(data->scratch);
   free(data);
 }
@@ -11,10 +11,19 @@
 #include <spa/pod/pod.h>
 #include <spa/utils/type.h>
 
-extern void process_channel_go(float *in, float *out, int samples,
-                               int sample_rate, int channel_index);
+// Largest quantum processed for channels whose output buffer is missing
+#define PW_SCRATCH_SAMPLES 8192
+
+extern void process_quantum_go(float **in, float **out, int channels,
+                              int samples, int sample_rate);
 extern void log_from_c(char *msg);
+extern void on_stream_restart_go(int sample_rate);
+extern void on_node_ready_go(uint32_t node_id, uint64_t serial);
+extern void on_loop_started_go(void);
+extern void fill_gr_cv_go(float *out, int samples);
 extern int pw_debug;
+extern int pw_gr_cv;
+extern int pw_latency_samples;
 
 // Structure to hold port-specific data
 struct port_data {
@@ -31,7 +40,20 @@ struct pw_filter_data {
   struct spa_hook filter_listener;
   struct port_data **in_ports;  // Array of pointers to port_data
   struct port_data **out_ports; // Array of pointers to port_data
+  struct port_data *cv_port;    // Gain reduction CV output, NULL if disabled
   int channels;
+  uint32_t sample_rate; // Last negotiated rate seen in on_process
+  int has_streamed;     // Set once the filter reached STREAMING
+  uint32_t node_id;     // Registered node id, SPA_ID_INVALID until known
+  struct spa_source *start_source; // Idle source signalling the loop started
+
+  // Per-quantum scratch for on_process, sized to channels at creation
+  struct pw_buffer **in_bufs;
+  struct pw_buffer **out_bufs;
+  float **in_ptrs;
+  float **out_ptrs;
+  uint32_t *out_lens;
+  float *scratch; // Silent stand-in for channels without an output buffer
 };
 
 struct pw_filter_data *create_pipewire_filter(struct pw_main_loop *loop,
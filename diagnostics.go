@@ -0,0 +1,91 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"pw-comp/dsp"
+)
+
+// diagnosticsInterval is how often the input diagnostics are polled for changes.
+const diagnosticsInterval = 100 * time.Millisecond
+
+// diagnosticsReport tracks the last logged state of the compressor's input
+// diagnostics, so only changes are logged.
+type diagnosticsReport struct {
+	nanMuted    bool                // Last logged NaN/Inf safety mute state
+	gainStaging dsp.GainStagingHint // Last logged gain-staging hint
+}
+
+// poll logs changes of the compressor's input diagnostics.
+func (r *diagnosticsReport) poll(comp *dsp.SoftKneeCompressor) {
+	r.pollNaNMute(comp)
+	r.pollGainStaging(comp)
+}
+
+// pollNaNMute logs when the NaN/Inf safety mute engages or lifts.
+func (r *diagnosticsReport) pollNaNMute(comp *dsp.SoftKneeCompressor) {
+	muted := comp.NaNMuted()
+	if muted == r.nanMuted {
+		return
+	}
+
+	r.nanMuted = muted
+
+	if muted {
+		slog.Error("CRITICAL: sustained NaN/Inf input, output muted until the input recovers")
+	} else {
+		slog.Info("Input recovered from NaN/Inf, output unmuted")
+	}
+}
+
+// pollGainStaging logs a suggestion when the most severe gain-staging hint
+// over all channels changes.
+func (r *diagnosticsReport) pollGainStaging(comp *dsp.SoftKneeCompressor) {
+	hint := dsp.GainStagingOK
+	for ch := range comp.Channels() {
+		hint = max(hint, comp.GainStaging(ch))
+	}
+
+	if hint == r.gainStaging {
+		return
+	}
+
+	r.gainStaging = hint
+
+	if hint != dsp.GainStagingOK {
+		slog.Warn("Gain staging", "hint", hint.String())
+	}
+}
+
+// startDiagnosticsMonitor polls the input diagnostics of comp every interval
+// and logs their changes. The diagnostics are read lock-free, so neither the
+// polling nor the logging runs on the audio thread. It returns a function
+// that stops the monitor.
+func startDiagnosticsMonitor(comp *dsp.SoftKneeCompressor, interval time.Duration) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var report diagnosticsReport
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				report.poll(comp)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
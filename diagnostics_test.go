@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"pw-comp/dsp"
+)
+
+// TestDiagnosticsReportPoll verifies the report follows the safety mute and the
+// most severe gain-staging hint of the compressor.
+func TestDiagnosticsReportPoll(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+	comp.SetNaNSafetyMute(true)
+
+	var report diagnosticsReport
+
+	// Only the right channel clips
+	clipping := make([]float32, 960)
+	for i := 1; i < len(clipping); i += 2 {
+		clipping[i] = 1.0
+	}
+
+	comp.ProcessFrames(clipping, make([]float32, len(clipping)))
+	report.poll(comp)
+
+	if report.gainStaging != dsp.GainStagingClipping {
+		t.Errorf("Gain-staging hint %v after clipping input, want %v", report.gainStaging, dsp.GainStagingClipping)
+	}
+
+	broken := make([]float32, 9600)
+	for i := range broken {
+		broken[i] = float32(math.NaN())
+	}
+
+	comp.ProcessFrames(broken, make([]float32, len(broken)))
+	report.poll(comp)
+
+	if !report.nanMuted {
+		t.Error("Expected the report to follow the engaged safety mute")
+	}
+
+	comp.ProcessFrames(make([]float32, 19200), make([]float32, 19200))
+	report.poll(comp)
+
+	if report.nanMuted {
+		t.Error("Expected the report to follow the lifted safety mute")
+	}
+}
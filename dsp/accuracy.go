@@ -0,0 +1,53 @@
+package dsp
+
+import "math"
+
+// Accuracy selects how the built-in gain curve evaluates its power function.
+type Accuracy int
+
+const (
+	// Fast evaluates the gain curve with the FastLog2 approximation (default).
+	// The gain stays within FastAccuracyDB of the exact curve, which is
+	// inaudible in real-time use.
+	Fast Accuracy = iota
+
+	// Precise evaluates the gain curve with math.Pow for mastering, where the
+	// small errors of Fast would otherwise accumulate, at a higher CPU cost.
+	Precise
+)
+
+// FastAccuracyDB is the largest deviation of the Fast gain curve from the
+// exact one in dB.
+const FastAccuracyDB = 0.4
+
+// SetAccuracyMode selects the gain curve accuracy. It applies to both
+// precisions; custom gain computers and transfer curves are unaffected.
+// Unknown values are ignored.
+func (c *SoftKneeCompressor) SetAccuracyMode(accuracy Accuracy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if accuracy != Fast && accuracy != Precise {
+		return
+	}
+
+	c.accuracy = accuracy
+}
+
+// GetAccuracyMode returns the gain curve accuracy.
+func (c *SoftKneeCompressor) GetAccuracyMode() Accuracy {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.accuracy
+}
+
+// curvePow raises base to exponent with the selected accuracy (internal,
+// assumes lock held).
+func (c *SoftKneeCompressor) curvePow(base, exponent float64) float64 {
+	if c.accuracy == Precise {
+		return math.Pow(base, exponent)
+	}
+
+	return FastPow(base, exponent)
+}
@@ -0,0 +1,60 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAccuracyMode verifies the Precise gain curve matches the soft-knee curve
+// evaluated with math.Pow exactly across the input range, while the Fast one
+// stays within FastAccuracyDB.
+func TestAccuracyMode(t *testing.T) {
+	t.Parallel()
+
+	fast := NewSoftKneeCompressor(48000.0, 1)
+	precise := NewSoftKneeCompressor(48000.0, 1)
+	precise.SetAccuracyMode(Precise)
+
+	for _, comp := range []*SoftKneeCompressor{fast, precise} {
+		comp.SetThreshold(-30.0)
+		comp.SetRatio(8.0)
+		comp.SetKnee(12.0)
+	}
+
+	if precise.GetAccuracyMode() != Precise || fast.GetAccuracyMode() != Fast {
+		t.Fatalf("accuracy modes = %v, %v", fast.GetAccuracyMode(), precise.GetAccuracyMode())
+	}
+
+	exact := func(c *SoftKneeCompressor, level float64) float64 {
+		slope := 1.0 - 1.0/c.ratio
+
+		switch {
+		case level <= c.kneeLower:
+			return 1.0
+		case level >= c.kneeUpper:
+			return math.Pow(c.threshold/level, slope)
+		}
+
+		kneePos := (level - c.kneeLower) / c.kneeWidth
+		smoothFactor := kneePos * kneePos * (3.0 - 2.0*kneePos)
+
+		return 1.0 + (math.Pow(c.threshold/c.kneeUpper, slope)-1.0)*smoothFactor
+	}
+
+	maxFastErrorDB := 0.0
+
+	for levelDB := -80.0; levelDB <= 6.0; levelDB += 0.01 {
+		level := math.Pow(10.0, levelDB/20.0)
+		want := exact(precise, level)
+
+		if got := precise.computeGain(level); got != want {
+			t.Fatalf("Precise gain at %.2f dBFS = %v, want %v", levelDB, got, want)
+		}
+
+		maxFastErrorDB = math.Max(maxFastErrorDB, math.Abs(20.0*math.Log10(fast.computeGain(level)/want)))
+	}
+
+	if maxFastErrorDB > FastAccuracyDB {
+		t.Errorf("Fast gain deviates by up to %.3f dB, want at most %.1f dB", maxFastErrorDB, FastAccuracyDB)
+	}
+}
@@ -0,0 +1,174 @@
+package dsp
+
+import "math"
+
+const (
+	// Length of the analysis frames used for onset detection in seconds.
+	analysisFrameSec = 0.01
+
+	// Frame level rise in dB, and minimum frame level in dBFS, counted as an onset.
+	analysisOnsetRiseDB  = 6.0
+	analysisOnsetFloorDB = -50.0
+
+	// Crest factor in dB above which material counts as peaky.
+	analysisPeakyCrestDB = 12.0
+
+	// Brightness (RMS of the first difference relative to the signal RMS)
+	// above which material counts as bright.
+	analysisBrightness = 0.5
+
+	// Maximum length of the input capture in seconds.
+	maxInputCaptureSec = 10.0
+)
+
+// CompressorParams holds a suggested set of compressor settings.
+type CompressorParams struct {
+	AttackMs  float64 // Attack time in milliseconds
+	ReleaseMs float64 // Release time in milliseconds
+	Ratio     float64 // Compression ratio
+}
+
+// MaterialAnalysis holds the measurements AnalyzeAndSuggest bases its suggestion on.
+type MaterialAnalysis struct {
+	CrestFactorDB    float64 // Peak to RMS ratio in dB
+	TransientDensity float64 // Detected onsets per second
+	Brightness       float64 // RMS of the first difference relative to the signal RMS
+}
+
+// AnalyzeMaterial measures the crest factor, transient density and spectral
+// tilt (as brightness) of a mono buffer at the compressor's sample rate.
+func (c *SoftKneeCompressor) AnalyzeMaterial(samples []float32) MaterialAnalysis {
+	c.mu.Lock()
+	sampleRate := c.sampleRate
+	c.mu.Unlock()
+
+	if len(samples) == 0 {
+		return MaterialAnalysis{}
+	}
+
+	var peak, sumSquares, diffSquares, prev float64
+
+	for _, sample := range samples {
+		x := float64(sanitizeSample(sample))
+		peak = math.Max(peak, math.Abs(x))
+		sumSquares += x * x
+		diffSquares += (x - prev) * (x - prev)
+		prev = x
+	}
+
+	rms := math.Sqrt(sumSquares / float64(len(samples)))
+	if rms == 0 {
+		return MaterialAnalysis{}
+	}
+
+	frameLen := max(1, int(analysisFrameSec*sampleRate))
+	onsets := 0
+	prevLevelDB := silenceThresholdDB
+
+	for start := 0; start+frameLen <= len(samples); start += frameLen {
+		levelDB := LinearToDB(calculateRMS(samples[start : start+frameLen]))
+		if levelDB > analysisOnsetFloorDB && levelDB-prevLevelDB >= analysisOnsetRiseDB {
+			onsets++
+		}
+
+		prevLevelDB = levelDB
+	}
+
+	return MaterialAnalysis{
+		CrestFactorDB:    LinearToDB(peak / rms),
+		TransientDensity: float64(onsets) * sampleRate / float64(len(samples)),
+		Brightness:       math.Sqrt(diffSquares/float64(len(samples))) / rms,
+	}
+}
+
+// AnalyzeAndSuggest analyzes a representative mono buffer and suggests attack,
+// release and ratio settings. This is an offline helper meant for an "auto"
+// button, not for the audio thread:
+//   - dense transients get a faster attack and a shorter release so the gain
+//     recovers between hits;
+//   - peaky material (high crest factor) gets a faster attack and a higher ratio;
+//   - bright material gets a slightly shorter release.
+func (c *SoftKneeCompressor) AnalyzeAndSuggest(samples []float32) CompressorParams {
+	analysis := c.AnalyzeMaterial(samples)
+
+	attackMs := 20.0 / (1.0 + analysis.TransientDensity)
+	if analysis.CrestFactorDB > analysisPeakyCrestDB {
+		attackMs *= 0.5
+	}
+
+	releaseMs := 400.0 / (1.0 + analysis.TransientDensity)
+	if analysis.Brightness > analysisBrightness {
+		releaseMs *= 0.8
+	}
+
+	return CompressorParams{
+		AttackMs:  math.Max(1.0, math.Min(30.0, attackMs)),
+		ReleaseMs: math.Max(50.0, math.Min(500.0, releaseMs)),
+		Ratio:     math.Max(1.5, math.Min(8.0, 1.0+analysis.CrestFactorDB/4.0)),
+	}
+}
+
+// calculateRMS returns the RMS level of a buffer.
+func calculateRMS(samples []float32) float64 {
+	if len(samples) == 0 {
+		return 0.0
+	}
+
+	var sum float64
+	for _, sample := range samples {
+		sum += float64(sample) * float64(sample)
+	}
+
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+// SetInputCapture keeps the last seconds of the first channel's input so it
+// can be analyzed with AnalyzeAndSuggest. The time is clamped to 10 s; 0
+// disables the capture. Changing it clears the captured audio.
+func (c *SoftKneeCompressor) SetInputCapture(seconds float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(seconds) {
+		return
+	}
+
+	seconds = math.Max(0.0, math.Min(maxInputCaptureSec, seconds))
+
+	c.captureBuf = make([]float32, int(seconds*c.sampleRate))
+	c.capturePos = 0
+	c.captureFilled = false
+}
+
+// RecentInput returns a copy of the captured input of the first channel,
+// oldest first, or nil if the capture is disabled.
+func (c *SoftKneeCompressor) RecentInput() []float32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.captureBuf) == 0 {
+		return nil
+	}
+
+	if !c.captureFilled {
+		return append([]float32(nil), c.captureBuf[:c.capturePos]...)
+	}
+
+	return append(append([]float32(nil), c.captureBuf[c.capturePos:]...), c.captureBuf[:c.capturePos]...)
+}
+
+// captureInput records one input sample of the first channel if the capture is
+// enabled (internal, assumes lock held).
+func (c *SoftKneeCompressor) captureInput(channel int, sample float32) {
+	if channel != 0 || len(c.captureBuf) == 0 {
+		return
+	}
+
+	c.captureBuf[c.capturePos] = sample
+
+	c.capturePos++
+	if c.capturePos == len(c.captureBuf) {
+		c.capturePos = 0
+		c.captureFilled = true
+	}
+}
@@ -0,0 +1,85 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAnalyzeAndSuggestTransients verifies a transient-heavy buffer yields a
+// faster attack and shorter release than a smooth buffer.
+func TestAnalyzeAndSuggestTransients(t *testing.T) {
+	t.Parallel()
+
+	const sampleRate = 48000.0
+
+	comp := NewSoftKneeCompressor(sampleRate, 1)
+
+	smooth := make([]float32, 2*int(sampleRate))
+	drums := make([]float32, len(smooth))
+
+	for i := range smooth {
+		phase := 2.0 * math.Pi * 220.0 * float64(i) / sampleRate
+		smooth[i] = float32(0.3 * math.Sin(phase))
+
+		// Eight decaying hits per second
+		sinceHit := float64(i%(int(sampleRate)/8)) / sampleRate
+		drums[i] = float32(0.9 * math.Exp(-sinceHit/0.01) * math.Sin(phase*4.0))
+	}
+
+	smoothParams := comp.AnalyzeAndSuggest(smooth)
+	drumParams := comp.AnalyzeAndSuggest(drums)
+
+	if drumParams.AttackMs >= smoothParams.AttackMs {
+		t.Errorf("Transient material should get a faster attack: drums %.2f ms, smooth %.2f ms",
+			drumParams.AttackMs, smoothParams.AttackMs)
+	}
+
+	if drumParams.ReleaseMs >= smoothParams.ReleaseMs {
+		t.Errorf("Transient material should get a shorter release: drums %.2f ms, smooth %.2f ms",
+			drumParams.ReleaseMs, smoothParams.ReleaseMs)
+	}
+
+	if drumParams.Ratio <= smoothParams.Ratio {
+		t.Errorf("Peaky material should get a higher ratio: drums %.2f, smooth %.2f",
+			drumParams.Ratio, smoothParams.Ratio)
+	}
+
+	if analysis := comp.AnalyzeMaterial(drums); math.Abs(analysis.TransientDensity-8.0) > 0.5 {
+		t.Errorf("Expected ~8 onsets per second, got %.2f", analysis.TransientDensity)
+	}
+
+	if silent := comp.AnalyzeAndSuggest(make([]float32, 480)); silent.AttackMs != 20.0 {
+		t.Errorf("Silence should yield the neutral attack of 20 ms, got %.2f", silent.AttackMs)
+	}
+}
+
+// TestInputCapture verifies the capture keeps the most recent input of the
+// first channel in order.
+func TestInputCapture(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(1000.0, 2)
+
+	if comp.RecentInput() != nil {
+		t.Error("Input capture should be disabled by default")
+	}
+
+	comp.SetInputCapture(0.005) // 5 samples
+
+	in := []float32{1, 2, 3, 4, 5, 6, 7}
+	comp.ProcessBlock(in, make([]float32, len(in)), 0)
+	comp.ProcessBlock([]float32{9, 9}, make([]float32, 2), 1)
+
+	got := comp.RecentInput()
+	want := []float32{3, 4, 5, 6, 7}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+}
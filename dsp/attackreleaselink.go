@@ -0,0 +1,64 @@
+package dsp
+
+import "math"
+
+// Default release-to-attack multiplier, matching the default 10 ms attack and
+// 100 ms release.
+const defaultAttackReleaseRatio = 10.0
+
+// SetAttackReleaseLink links the release to the attack for a single
+// responsiveness control: while enabled, SetAttack also sets the release to
+// the attack time times the SetAttackReleaseRatio multiplier. Enabling it
+// derives the release from the current attack right away. SetRelease still
+// works and holds until the next attack change; attack times set in samples
+// aren't linked.
+func (c *SoftKneeCompressor) SetAttackReleaseLink(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attackReleaseLink = enabled
+	c.linkRelease()
+}
+
+// GetAttackReleaseLink returns whether the release follows the attack.
+func (c *SoftKneeCompressor) GetAttackReleaseLink() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.attackReleaseLink
+}
+
+// SetAttackReleaseRatio sets the multiplier from attack to release time used
+// by the attack/release link (1-1000, default 10) and applies it if the link
+// is enabled. Non-finite values are ignored.
+func (c *SoftKneeCompressor) SetAttackReleaseRatio(multiplier float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(multiplier) {
+		return
+	}
+
+	c.attackReleaseRatio = math.Max(1.0, math.Min(1000.0, multiplier))
+	c.linkRelease()
+}
+
+// GetAttackReleaseRatio returns the multiplier from attack to release time.
+func (c *SoftKneeCompressor) GetAttackReleaseRatio() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.attackReleaseRatio
+}
+
+// linkRelease derives the release from the attack if the link is enabled
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) linkRelease() {
+	if !c.attackReleaseLink {
+		return
+	}
+
+	c.releaseMs = math.Max(1.0, c.attackMs*c.attackReleaseRatio)
+	c.releaseSamples = 0
+	c.updateEnvelopeConstants()
+}
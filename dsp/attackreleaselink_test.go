@@ -0,0 +1,55 @@
+package dsp
+
+import "testing"
+
+// TestAttackReleaseLink verifies that with the link enabled the release
+// follows every attack change at the set multiple, and stays put without it.
+func TestAttackReleaseLink(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetAttack(5.0)
+
+	if release := comp.GetRelease(); release != 100.0 {
+		t.Fatalf("release without link = %v ms, want the default 100 ms", release)
+	}
+
+	comp.SetAttackReleaseRatio(10.0)
+	comp.SetAttackReleaseLink(true)
+
+	if !comp.GetAttackReleaseLink() || comp.GetAttackReleaseRatio() != 10.0 {
+		t.Fatalf("link = %v, ratio = %v", comp.GetAttackReleaseLink(), comp.GetAttackReleaseRatio())
+	}
+
+	if release := comp.GetRelease(); release != 50.0 {
+		t.Errorf("release after enabling the link at 5 ms attack = %v ms, want 50 ms", release)
+	}
+
+	comp.SetAttack(20.0)
+
+	if release := comp.GetRelease(); release != 200.0 {
+		t.Errorf("release after setting 20 ms attack = %v ms, want 200 ms", release)
+	}
+
+	comp.SetAttackReleaseRatio(4.0)
+
+	if release := comp.GetRelease(); release != 80.0 {
+		t.Errorf("release after setting ratio 4 = %v ms, want 80 ms", release)
+	}
+
+	// The release coefficient follows, not just the reported time
+	reference := NewSoftKneeCompressor(48000.0, 1)
+	reference.SetAttack(20.0)
+	reference.SetRelease(80.0)
+
+	if got, want := comp.GetCoefficients().ReleaseFactor, reference.GetCoefficients().ReleaseFactor; got != want {
+		t.Errorf("release factor = %v, want %v", got, want)
+	}
+
+	comp.SetAttackReleaseLink(false)
+	comp.SetAttack(1.0)
+
+	if release := comp.GetRelease(); release != 80.0 {
+		t.Errorf("release after disabling the link = %v ms, want it kept at 80 ms", release)
+	}
+}
@@ -0,0 +1,38 @@
+package dsp
+
+// ProcessBlockRatioAutomated processes a block like ProcessBlock, but with the
+// ratio taken per sample from ratioCurve, e.g. to scale ducking depth with an
+// external control. The curve must have one value per sample; values below 1
+// are clamped and non-finite values hold the previous ratio. The makeup gain
+// stays at its block-start value so automation only changes the gain reduction,
+// and the configured ratio is restored afterwards.
+func (c *SoftKneeCompressor) ProcessBlockRatioAutomated(in, out []float32, channel int, ratioCurve []float64) {
+	if channel < 0 || channel >= c.channels || len(in) != len(out) || len(in) == 0 || len(ratioCurve) != len(in) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	defer c.applyAutomatedRatio(c.ratio)
+
+	processBlockLocked(c, in, nil, out, channel, func(i int) {
+		c.applyAutomatedRatio(ratioCurve[i])
+	})
+}
+
+// applyAutomatedRatio sets the ratio used by the gain curve without touching
+// makeup gain or the other cached parameters (internal, assumes lock held).
+func (c *SoftKneeCompressor) applyAutomatedRatio(ratio float64) {
+	if !isFinite(ratio) {
+		return
+	}
+
+	if ratio < 1.0 {
+		ratio = 1.0
+	}
+
+	c.ratio = ratio
+	c.slopeRecip = 1.0/ratio - 1.0
+	c.params32.slope = float32(1.0 - 1.0/ratio)
+}
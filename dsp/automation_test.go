@@ -0,0 +1,80 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestRatioAutomationSweep sweeps the ratio from 1:1 to 10:1 over a steady tone
+// above threshold and verifies the gain reduction deepens along the block.
+func TestRatioAutomationSweep(t *testing.T) {
+	t.Parallel()
+
+	const (
+		samples  = 48000
+		segments = 4
+	)
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(4.0)
+	comp.SetAttack(1.0)
+	comp.SetRelease(20.0)
+	comp.SetMakeupGain(0.0)
+
+	in := make([]float32, samples)
+	curve := make([]float64, samples)
+
+	for i := range in {
+		in[i] = float32(0.5 * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+		curve[i] = 1.0 + 9.0*float64(i)/float64(samples-1)
+	}
+
+	out := make([]float32, samples)
+	comp.ProcessBlockRatioAutomated(in, out, 0, curve)
+
+	// Peak gain reduction in the last 10 ms of each segment
+	previousGR := -1.0
+	segmentLength := samples / segments
+
+	for segment := range segments {
+		end := (segment + 1) * segmentLength
+		peakOut := 0.0
+
+		for i := end - 480; i < end; i++ {
+			peakOut = math.Max(peakOut, math.Abs(float64(out[i])))
+		}
+
+		grDB := -LinearToDB(peakOut / 0.5)
+		if grDB <= previousGR {
+			t.Errorf("Segment %d: gain reduction should deepen, got %.2f dB after %.2f dB", segment, grDB, previousGR)
+		}
+
+		previousGR = grDB
+	}
+
+	// 10:1 on a -6 dBFS tone gives at most 12.6 dB; the envelope sits slightly
+	// below the waveform peak, so allow some shortfall
+	if previousGR < 10.0 || previousGR > 12.6 {
+		t.Errorf("Expected 10-12.6 dB of reduction at 10:1, got %.2f dB", previousGR)
+	}
+
+	if got := comp.GetRatio(); got != 4.0 {
+		t.Errorf("Configured ratio should be restored after the block, got %f", got)
+	}
+}
+
+// TestRatioAutomationLengthMismatch verifies a curve of the wrong length is rejected.
+func TestRatioAutomationLengthMismatch(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+
+	in := []float32{0.5, 0.5}
+	out := []float32{0, 0}
+	comp.ProcessBlockRatioAutomated(in, out, 0, []float64{2.0})
+
+	if out[0] != 0 || out[1] != 0 {
+		t.Errorf("Mismatched curve should leave the output untouched, got %v", out)
+	}
+}
@@ -0,0 +1,97 @@
+package dsp
+
+import "math"
+
+const (
+	// Time constant of the short-term energy follower in milliseconds.
+	autoReleaseShortMs = 5.0
+
+	// Time constant of the long-term energy follower in milliseconds.
+	autoReleaseLongMs = 200.0
+
+	// Short/long energy ratio (either way) above which content counts as transient.
+	autoReleaseTransientRatio = 4.0
+
+	// Factor by which the release is shortened for transients and lengthened
+	// for sustained content, relative to the configured release.
+	autoReleaseSpread = 3.0
+
+	// How long a detected onset keeps the content classified as transient, in
+	// milliseconds. It covers the decay of the hit, during which the two
+	// followers briefly agree while the short-term one drops through the long-term one.
+	autoReleaseHoldMs = autoReleaseLongMs
+
+	// Long-term energy below which the classifier keeps its last decision.
+	autoReleaseSilence = 1e-12
+)
+
+// SetAutoRelease enables program-dependent release. Short-term and long-term
+// energy followers classify the detector signal: an onset (short-term energy
+// well above long-term) or a sudden drop shortens the release, while content on
+// which both agree (sustained) lengthens it. The configured release time is the centre of that range.
+func (c *SoftKneeCompressor) SetAutoRelease(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.autoRelease = enabled
+}
+
+// GetAutoRelease returns whether program-dependent release is enabled.
+func (c *SoftKneeCompressor) GetAutoRelease() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.autoRelease
+}
+
+// updateAutoReleaseConstants derives the classifier coefficients from the
+// sample rate; the fast and slow release factors are updated with the other
+// envelope constants (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateAutoReleaseConstants() {
+	c.energyShortCoeff = 1.0 - math.Exp(-1.0/(autoReleaseShortMs*0.001*c.sampleRate))
+	c.energyLongCoeff = 1.0 - math.Exp(-1.0/(autoReleaseLongMs*0.001*c.sampleRate))
+	c.transientHoldSamples = int(autoReleaseHoldMs * 0.001 * c.sampleRate)
+}
+
+// classifyRelease updates the energy followers with one detector level and
+// records whether the channel currently sees transient content.
+func (c *SoftKneeCompressor) classifyRelease(inputLevel float64, channel int) {
+	if math.IsNaN(inputLevel) {
+		inputLevel = 0 // Sanitize
+	}
+
+	energy := inputLevel * inputLevel
+	c.energyShort[channel] += (energy - c.energyShort[channel]) * c.energyShortCoeff
+	c.energyLong[channel] += (energy - c.energyLong[channel]) * c.energyLongCoeff
+
+	if c.energyLong[channel] < autoReleaseSilence {
+		return
+	}
+
+	ratio := c.energyShort[channel] / c.energyLong[channel]
+	if ratio > autoReleaseTransientRatio {
+		c.transientHold[channel] = c.transientHoldSamples
+	}
+
+	if c.transientHold[channel] > 0 {
+		c.transientHold[channel]--
+		c.transient[channel] = true
+
+		return
+	}
+
+	c.transient[channel] = ratio < 1.0/autoReleaseTransientRatio
+}
+
+// releaseFactorFor returns the release coefficient for the channel's current content.
+func (c *SoftKneeCompressor) releaseFactorFor(channel int) float64 {
+	if !c.autoRelease {
+		return c.releaseFactor
+	}
+
+	if c.transient[channel] {
+		return c.releaseFactorFast
+	}
+
+	return c.releaseFactorSlow
+}
@@ -0,0 +1,79 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAutoReleaseAdaptsToContent feeds a drum-like hit followed by a sustained
+// tone and verifies the release is fast after the hit and slow on the tone.
+func TestAutoReleaseAdaptsToContent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		sampleRate = 48000.0
+		hitLength  = 480   // 10 ms decaying burst
+		gapLength  = 480   // 10 ms of silence after the hit
+		toneLength = 48000 // 1 s sustained tone
+	)
+
+	run := func(autoRelease bool) (*SoftKneeCompressor, float64) {
+		comp := NewSoftKneeCompressor(sampleRate, 1)
+		comp.SetThreshold(-20.0)
+		comp.SetAttack(0.1)
+		comp.SetRelease(100.0)
+		comp.SetAutoRelease(autoRelease)
+
+		for i := range hitLength {
+			decay := math.Exp(-float64(i) / 120.0)
+			comp.ProcessSample(float32(0.9*decay*math.Sin(2.0*math.Pi*1000.0*float64(i)/sampleRate)), 0)
+		}
+
+		for range gapLength {
+			comp.ProcessSample(0.0, 0)
+		}
+
+		return comp, comp.peak[0]
+	}
+
+	comp, autoPeak := run(true)
+	_, fixedPeak := run(false)
+
+	if !comp.transient[0] || comp.releaseFactorFor(0) != comp.releaseFactorFast {
+		t.Error("Content after a drum hit should be classified as transient")
+	}
+
+	if autoPeak >= fixedPeak {
+		t.Errorf("Envelope should release faster after a hit: auto %f, fixed %f", autoPeak, fixedPeak)
+	}
+
+	for i := range toneLength {
+		comp.ProcessSample(float32(0.5*math.Sin(2.0*math.Pi*440.0*float64(i)/sampleRate)), 0)
+	}
+
+	if comp.transient[0] || comp.releaseFactorFor(0) != comp.releaseFactorSlow {
+		t.Error("Sustained tone should be classified as sustained")
+	}
+
+	if comp.releaseFactorSlow <= comp.releaseFactor || comp.releaseFactorFast >= comp.releaseFactor {
+		t.Errorf("Release range should straddle the configured release: fast %f, base %f, slow %f",
+			comp.releaseFactorFast, comp.releaseFactor, comp.releaseFactorSlow)
+	}
+}
+
+// TestAutoReleaseDisabled verifies the configured release is used when auto-release is off.
+func TestAutoReleaseDisabled(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+
+	if comp.GetAutoRelease() {
+		t.Fatal("Auto-release should be disabled by default")
+	}
+
+	comp.transient[0] = true
+
+	if comp.releaseFactorFor(0) != comp.releaseFactor {
+		t.Error("Disabled auto-release should use the configured release")
+	}
+}
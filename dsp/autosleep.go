@@ -0,0 +1,108 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Defaults of the auto sleep threshold and hold time.
+const (
+	defaultAutoSleepThresholdDB = -90.0
+	defaultAutoSleepHoldMs      = 500.0
+)
+
+// SetAutoSleep enables sleeping on sustained silence to save CPU: once a
+// channel's detector level stayed below thresholdDB (-160..0 dBFS) for holdMs
+// (0..60000 ms), the envelope follower and gain computer are skipped and the
+// audio passes at unity gain, with makeup, output gain and the output stage
+// still applied. The envelope is cleared on sleep and primed with the waking
+// sample's level, so the first note after a pause isn't met by an envelope
+// still releasing from the last one. Control-rate block gains are not put to
+// sleep. Non-finite values keep the previous setting.
+func (c *SoftKneeCompressor) SetAutoSleep(enabled bool, thresholdDB, holdMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(thresholdDB) || !isFinite(holdMs) {
+		return
+	}
+
+	c.autoSleep = enabled
+	c.autoSleepThresholdDB = math.Max(-160.0, math.Min(0.0, thresholdDB))
+	c.autoSleepHoldMs = math.Max(0.0, math.Min(60000.0, holdMs))
+	c.updateAutoSleep()
+
+	if !enabled {
+		for ch := range c.asleep {
+			c.silentSamples[ch] = 0
+			c.asleep[ch] = false
+		}
+	}
+}
+
+// GetAutoSleep returns whether auto sleep is enabled, its threshold in dBFS
+// and its hold time in milliseconds.
+func (c *SoftKneeCompressor) GetAutoSleep() (enabled bool, thresholdDB, holdMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.autoSleep, c.autoSleepThresholdDB, c.autoSleepHoldMs
+}
+
+// SleptSamples returns how many samples of a channel passed through asleep,
+// skipping the gain computation, or 0 for an invalid channel.
+func (c *SoftKneeCompressor) SleptSamples(channel int) uint64 {
+	if channel < 0 || channel >= c.channels {
+		return 0
+	}
+
+	return atomic.LoadUint64(&c.sleptSamples[channel])
+}
+
+// updateAutoSleep recalculates the linear threshold and the hold time in
+// samples (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateAutoSleep() {
+	c.autoSleepThreshold = DBToLinear(c.autoSleepThresholdDB)
+	c.autoSleepHold = int(c.autoSleepHoldMs * 0.001 * c.sampleRate)
+}
+
+// sleeping tracks the silence run of a channel and reports whether the
+// current sample is passed through asleep. Falling asleep clears the envelope
+// and waking up primes it with the key level (internal, assumes lock held).
+func (c *SoftKneeCompressor) sleeping(key float64, channel int) bool {
+	if !c.autoSleep {
+		return false
+	}
+
+	level := math.Abs(key)
+	if level >= c.autoSleepThreshold {
+		c.silentSamples[channel] = 0
+
+		if c.asleep[channel] {
+			c.asleep[channel] = false
+			c.peak[channel] = level
+			c.peak32[channel] = float32(level)
+			c.currentGain[channel] = c.computeGain(level)
+			c.gainCountdown[channel] = 0
+		}
+
+		return false
+	}
+
+	if !c.asleep[channel] {
+		c.silentSamples[channel]++
+		if c.silentSamples[channel] < c.autoSleepHold {
+			return false
+		}
+
+		c.asleep[channel] = true
+		c.peak[channel] = 0.0
+		c.peak32[channel] = 0.0
+		c.currentGain[channel] = 1.0
+		c.gainCountdown[channel] = 0
+	}
+
+	atomic.AddUint64(&c.sleptSamples[channel], 1)
+
+	return true
+}
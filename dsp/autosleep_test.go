@@ -0,0 +1,82 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAutoSleep verifies that sustained silence puts a channel to sleep,
+// skipping the gain computation, and that it wakes on signal without
+// overshooting a compressor that never slept, then follows it.
+func TestAutoSleep(t *testing.T) {
+	t.Parallel()
+
+	const sampleRate = 48000.0
+
+	sleepy := NewSoftKneeCompressor(sampleRate, 1)
+	sleepy.SetAutoSleep(true, -90.0, 100.0)
+
+	awake := NewSoftKneeCompressor(sampleRate, 1)
+
+	in := make([]float32, 4800)
+	sleepyOut := make([]float32, len(in))
+	awakeOut := make([]float32, len(in))
+
+	run := func(level float64) (overshoot, settledDiff float64) {
+		for i := range in {
+			in[i] = float32(level * math.Sin(2*math.Pi*1000*float64(i)/sampleRate))
+		}
+
+		// ProcessBlock sanitizes in place, so both get their own copy
+		awakeIn := append([]float32(nil), in...)
+
+		sleepy.ProcessBlock(in, sleepyOut, 0)
+		awake.ProcessBlock(awakeIn, awakeOut, 0)
+
+		for i := range sleepyOut {
+			overshoot = math.Max(overshoot, math.Abs(float64(sleepyOut[i]))-math.Abs(float64(awakeOut[i])))
+			if i >= len(sleepyOut)/2 {
+				settledDiff = math.Max(settledDiff, math.Abs(float64(sleepyOut[i]-awakeOut[i])))
+			}
+		}
+
+		return overshoot, settledDiff
+	}
+
+	run(0.5)
+
+	if slept := sleepy.SleptSamples(0); slept != 0 {
+		t.Fatalf("slept %d samples during signal, want 0", slept)
+	}
+
+	// 500 ms of silence: awake for the 100 ms hold, asleep for the rest
+	for range 5 {
+		run(0.0)
+	}
+
+	slept := sleepy.SleptSamples(0)
+	if want := uint64(0.4 * sampleRate); slept < want-1 || slept > want+1 {
+		t.Fatalf("slept %d samples during silence, want about %d", slept, want)
+	}
+
+	// The envelope primed on waking catches the onset the never-slept
+	// compressor's attack lets through
+	if overshoot, _ := run(0.5); overshoot > 1e-6 {
+		t.Errorf("output after waking overshoots a compressor that never slept by %g", overshoot)
+	}
+
+	if _, settledDiff := run(0.5); settledDiff > 1e-3 {
+		t.Errorf("settled output after waking differs by up to %g from a compressor that never slept", settledDiff)
+	}
+
+	// The zero-crossing sample the wake-up block starts with is still silent
+	if extra := sleepy.SleptSamples(0) - slept; extra > 1 {
+		t.Errorf("slept %d samples after the signal returned", extra)
+	}
+
+	sleepy.SetAutoSleep(false, -90.0, 100.0)
+
+	if enabled, thresholdDB, holdMs := sleepy.GetAutoSleep(); enabled || thresholdDB != -90.0 || holdMs != 100.0 {
+		t.Errorf("GetAutoSleep = %v, %v, %v", enabled, thresholdDB, holdMs)
+	}
+}
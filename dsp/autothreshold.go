@@ -0,0 +1,86 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+const (
+	// Threshold change in dB per second for each dB the slow average gain
+	// reduction deviates from the target. Together with the 3 s average this
+	// keeps the loop well damped, settling within tens of seconds.
+	autoThresholdRate = 0.1
+
+	// Range the auto threshold moves within in dB.
+	minAutoThresholdDB = -60.0
+	maxAutoThresholdDB = 0.0
+
+	// Largest gain reduction target in dB.
+	maxAutoThresholdTargetDB = 20.0
+)
+
+// SetAutoThreshold turns the compressor into a "set and forget" leveler: an
+// offset on top of the threshold slowly follows the program level so that the
+// slow average gain reduction (see AverageGainReductionDB) stays around
+// targetGRdB regardless of how loud the input is. The offset moves by 0.1 dB
+// per second for every dB of deviation and keeps the resulting threshold
+// within -60..0 dB; the threshold set with SetThreshold is left untouched. A
+// target of 0 or less disables the mode and drops the offset, ramping back to
+// that threshold; the target is clamped to 20 dB.
+func (c *SoftKneeCompressor) SetAutoThreshold(targetGRdB float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(targetGRdB) {
+		return
+	}
+
+	c.autoThresholdTargetDB = math.Max(0.0, math.Min(maxAutoThresholdTargetDB, targetGRdB))
+
+	if c.autoThresholdTargetDB == 0.0 && c.autoThresholdOffsetDB != 0.0 {
+		c.autoThresholdOffsetDB = 0.0
+		c.updateParameters()
+	}
+}
+
+// GetAutoThreshold returns the auto threshold gain reduction target in dB, or 0 if disabled.
+func (c *SoftKneeCompressor) GetAutoThreshold() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.autoThresholdTargetDB
+}
+
+// GetAutoThresholdOffset returns the offset in dB the auto threshold currently
+// adds to the threshold, 0 while the mode is off.
+func (c *SoftKneeCompressor) GetAutoThresholdOffset() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.autoThresholdOffsetDB
+}
+
+// targetThresholdDB returns the threshold in effect once any threshold ramp
+// settles: the user threshold plus the auto threshold offset (internal,
+// assumes lock held).
+func (c *SoftKneeCompressor) targetThresholdDB() float64 {
+	return c.thresholdDB + c.autoThresholdOffsetDB
+}
+
+// updateAutoThreshold moves the auto threshold offset by one block's step of
+// the feedback loop, driven by the channel's slow average gain reduction.
+// Every channel contributes its share of the block, so the loop runs at the
+// same speed for any channel count (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateAutoThreshold(channel, samples int) {
+	if c.autoThresholdTargetDB <= 0.0 || samples == 0 {
+		return
+	}
+
+	averageGR := math.Float64frombits(atomic.LoadUint64(&c.grAverage[channel]))
+	blockSec := float64(samples) / c.sampleRate / float64(c.channels)
+
+	threshold := c.targetThresholdDB() + (averageGR-c.autoThresholdTargetDB)*autoThresholdRate*blockSec
+	threshold = math.Max(minAutoThresholdDB, math.Min(maxAutoThresholdDB, threshold))
+	c.autoThresholdOffsetDB = threshold - c.thresholdDB
+	c.updateParameters()
+}
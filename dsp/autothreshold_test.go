@@ -0,0 +1,114 @@
+package dsp
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// Sample rate of the auto threshold tests; the loop works in seconds, so a
+// low rate keeps the minute-long runs cheap.
+const autoThresholdTestRate = 8000.0
+
+// runProgram processes seconds of program-like material around the given peak
+// level in 10 ms blocks: noise with a syllable-rate envelope and phrases whose
+// level varies by up to 9 dB, so the block gain reduction fluctuates strongly.
+func runProgram(comp *SoftKneeCompressor, rng *rand.Rand, levelDB, seconds float64) {
+	const (
+		sampleRate  = autoThresholdTestRate
+		blockFrames = 80
+		phraseLen   = 2000 // 250 ms
+	)
+
+	block := make([]float32, blockFrames*2)
+	out := make([]float32, len(block))
+	scale := DBToLinear(levelDB) / 4.0 // Noise peaks around 4 standard deviations
+	phraseGain := 1.0
+
+	for n := range int(seconds * sampleRate / blockFrames) {
+		for i := range blockFrames {
+			pos := n*blockFrames + i
+			if pos%phraseLen == 0 {
+				phraseGain = DBToLinear(-9.0 * rng.Float64())
+			}
+
+			envelope := 0.2 + 0.8*math.Abs(math.Sin(math.Pi*3.0*float64(pos)/sampleRate))
+			gain := scale * phraseGain * envelope
+			block[2*i] = float32(gain * rng.NormFloat64())
+			block[2*i+1] = float32(gain * rng.NormFloat64())
+		}
+
+		comp.ProcessFrames(block, out)
+	}
+}
+
+// TestAutoThreshold verifies the offset follows the program level so the slow
+// average gain reduction settles near the target for loud and quiet program
+// material, without touching the user threshold.
+func TestAutoThreshold(t *testing.T) {
+	t.Parallel()
+
+	const targetDB = 3.0
+
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	comp := NewSoftKneeCompressor(autoThresholdTestRate, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(4.0)
+	comp.SetMakeupGain(0.0)
+	comp.SetAutoThreshold(targetDB)
+
+	runProgram(comp, rng, -6.0, 90.0)
+
+	loudOffset := comp.GetAutoThresholdOffset()
+	if gr := comp.AverageGainReductionDB(0); math.Abs(gr-targetDB) > 1.0 {
+		t.Errorf("Loud input: average gain reduction %.2f dB, want ~%.1f dB (offset %.1f dB)",
+			gr, targetDB, loudOffset)
+	}
+
+	runProgram(comp, rng, -30.0, 150.0)
+
+	quietOffset := comp.GetAutoThresholdOffset()
+	if gr := comp.AverageGainReductionDB(0); math.Abs(gr-targetDB) > 1.0 {
+		t.Errorf("Quiet input: average gain reduction %.2f dB, want ~%.1f dB (offset %.1f dB)",
+			gr, targetDB, quietOffset)
+	}
+
+	// The offset should track the 24 dB level drop
+	if drop := loudOffset - quietOffset; math.Abs(drop-24.0) > 2.0 {
+		t.Errorf("Offset should drop ~24 dB with the input, dropped %.2f dB", drop)
+	}
+
+	if got := comp.GetThreshold(); got != -20.0 {
+		t.Errorf("Auto threshold must keep the user threshold at -20 dB, got %.2f dB", got)
+	}
+
+	comp.SetAutoThreshold(0.0)
+
+	if got := comp.GetAutoThresholdOffset(); got != 0.0 {
+		t.Errorf("Disabling the auto threshold should drop the offset, got %.2f dB", got)
+	}
+}
+
+// TestAutoThresholdSettlesSlowly verifies the loop doesn't chase short-term
+// level changes: a 1 s burst 12 dB louder barely moves the offset.
+func TestAutoThresholdSettlesSlowly(t *testing.T) {
+	t.Parallel()
+
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	comp := NewSoftKneeCompressor(autoThresholdTestRate, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(4.0)
+	comp.SetMakeupGain(0.0)
+	comp.SetAutoThreshold(3.0)
+
+	runProgram(comp, rng, -18.0, 60.0)
+	before := comp.GetAutoThresholdOffset()
+
+	runProgram(comp, rng, -6.0, 1.0)
+
+	if moved := comp.GetAutoThresholdOffset() - before; math.Abs(moved) > 1.5 {
+		t.Errorf("A 1 s burst moved the offset by %.2f dB, want a slow leveler", moved)
+	}
+}
@@ -0,0 +1,106 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// renderRamp processes a 1 kHz sine whose level ramps from -40 to 0 dBFS over
+// two seconds in 256-sample blocks and returns the output and the number of
+// gain computer evaluations.
+func renderRamp(blockGains bool) ([]float32, int) {
+	const (
+		sampleRate = 48000.0
+		length     = 2 * 48000
+		blockSize  = 256
+	)
+
+	comp := NewSoftKneeCompressor(sampleRate, 1)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(4.0)
+	comp.SetMakeupGain(0.0)
+	comp.SetBlockGainInterpolation(blockGains)
+
+	evaluations := 0
+	threshold := DBToLinear(-20.0)
+
+	comp.SetGainComputer(func(peak float64) float64 {
+		evaluations++
+
+		if peak <= threshold {
+			return 1.0
+		}
+
+		return math.Pow(peak/threshold, 1.0/4.0-1.0)
+	})
+
+	in := make([]float32, length)
+	for i := range in {
+		level := DBToLinear(-40.0 + 40.0*float64(i)/length)
+		in[i] = float32(level * math.Sin(2.0*math.Pi*1000.0*float64(i)/sampleRate))
+	}
+
+	out := make([]float32, length)
+	for start := 0; start < length; start += blockSize {
+		comp.ProcessBlock(in[start:start+blockSize], out[start:start+blockSize], 0)
+	}
+
+	return out, evaluations
+}
+
+// TestBlockGainInterpolation verifies control-rate gain with block
+// interpolation stays inaudibly close to full-rate processing on a level ramp
+// while evaluating the gain computer once per block instead of per sample.
+func TestBlockGainInterpolation(t *testing.T) {
+	t.Parallel()
+
+	fullRate, fullEvaluations := renderRamp(false)
+	controlRate, controlEvaluations := renderRamp(true)
+
+	var signal, diff float64
+
+	for i := range fullRate {
+		signal += float64(fullRate[i]) * float64(fullRate[i])
+		errSample := float64(fullRate[i]) - float64(controlRate[i])
+		diff += errSample * errSample
+	}
+
+	// Difference relative to the signal, like a residual after nulling
+	if residualDB := 10.0 * math.Log10(diff/signal); residualDB > -40.0 {
+		t.Errorf("Control-rate output should null against full rate below -40 dB, got %.1f dB", residualDB)
+	}
+
+	if controlEvaluations*200 > fullEvaluations {
+		t.Errorf("Control rate should evaluate the gain once per 256-sample block: %d vs %d evaluations",
+			controlEvaluations, fullEvaluations)
+	}
+}
+
+// BenchmarkBlockGainInterpolation compares per-sample gain computation with
+// control-rate block interpolation.
+func BenchmarkBlockGainInterpolation(b *testing.B) {
+	for _, enabled := range []bool{false, true} {
+		name := "full-rate"
+		if enabled {
+			name = "control-rate"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			comp := NewSoftKneeCompressor(48000.0, 1)
+			comp.SetBlockGainInterpolation(enabled)
+
+			in := make([]float32, 256)
+			for i := range in {
+				in[i] = float32(0.8 * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+			}
+
+			out := make([]float32, len(in))
+
+			b.ResetTimer()
+
+			for range b.N {
+				comp.ProcessBlock(in, out, 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package dsp
+
+import "math"
+
+// Number of half-life coefficients kept by halfLifeCache (a power of two).
+const (
+	halfLifeCacheBits = 6
+	halfLifeCacheSize = 1 << halfLifeCacheBits
+)
+
+// halfLifeCache memoizes exp(-ln2 / samples), the one-pole coefficient of the
+// envelope time constants, keyed by the half-life in samples, i.e. by the time
+// and the sample rate together. Rapid attack/release automation tends to cycle
+// through few values, which then skip math.Exp entirely.
+type halfLifeCache struct {
+	used     [halfLifeCacheSize]bool
+	samples  [halfLifeCacheSize]float64
+	values   [halfLifeCacheSize]float64
+	computed uint64 // Number of math.Exp evaluations, for benchmarks
+}
+
+// decay returns exp(-ln2 / samples), computing it only on a cache miss.
+func (h *halfLifeCache) decay(samples float64) float64 {
+	// Fibonacci hashing spreads the float bits over the slots
+	slot := (math.Float64bits(samples) * 0x9e3779b97f4a7c15) >> (64 - halfLifeCacheBits)
+
+	if h.used[slot] && h.samples[slot] == samples {
+		return h.values[slot]
+	}
+
+	value := math.Exp(-math.Ln2 / samples)
+	h.computed++
+
+	h.used[slot] = true
+	h.samples[slot] = samples
+	h.values[slot] = value
+
+	return value
+}
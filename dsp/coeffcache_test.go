@@ -0,0 +1,74 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCachedCoefficientsMatch verifies the cached attack and release
+// coefficients equal freshly computed ones, also for more distinct times than
+// the cache holds and across sample rates.
+func TestCachedCoefficientsMatch(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+
+	check := func(attackMs, releaseMs, rate float64) {
+		t.Helper()
+
+		coeffs := comp.GetCoefficients()
+
+		if want := 1.0 - math.Exp(-math.Ln2/(attackMs*0.001*rate)); coeffs.AttackFactor != want {
+			t.Errorf("Attack %.2f ms at %.0f Hz: factor %v, want %v", attackMs, rate, coeffs.AttackFactor, want)
+		}
+
+		if want := math.Exp(-math.Ln2 / (releaseMs * 0.001 * rate)); coeffs.ReleaseFactor != want {
+			t.Errorf("Release %.2f ms at %.0f Hz: factor %v, want %v", releaseMs, rate, coeffs.ReleaseFactor, want)
+		}
+	}
+
+	for _, rate := range []float64{48000.0, 96000.0, 48000.0} {
+		comp.SetSampleRate(rate)
+
+		// Twice through more values than the cache holds, so hits, misses and
+		// evictions all occur
+		for range 2 {
+			for i := range 3 * halfLifeCacheSize {
+				attackMs, releaseMs := 1.0+float64(i)*0.5, 50.0+float64(i)*7.0
+
+				comp.SetAttack(attackMs)
+				comp.SetRelease(releaseMs)
+				check(attackMs, releaseMs, rate)
+			}
+		}
+	}
+}
+
+// BenchmarkAttackReleaseAutomation measures attack/release changes at
+// automation rate. "cycling" revisits a few values, so the cache serves the
+// coefficients; "sweeping" never repeats a value and computes every one. The
+// exp/op metric counts the math.Exp evaluations per attack+release change.
+func BenchmarkAttackReleaseAutomation(b *testing.B) {
+	for _, bench := range []struct {
+		name  string
+		times func(i int) (float64, float64)
+	}{
+		{"cycling", func(i int) (float64, float64) { return 5.0 + float64(i%8), 100.0 + 10.0*float64(i%8) }},
+		{"sweeping", func(i int) (float64, float64) { return 5.0 + float64(i)*1e-6, 100.0 + float64(i)*1e-5 }},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			comp := NewSoftKneeCompressor(48000.0, 1)
+			before := comp.halfLives.computed
+
+			b.ResetTimer()
+
+			for i := range b.N {
+				attackMs, releaseMs := bench.times(i)
+				comp.SetAttack(attackMs)
+				comp.SetRelease(releaseMs)
+			}
+
+			b.ReportMetric(float64(comp.halfLives.computed-before)/float64(b.N), "exp/op")
+		})
+	}
+}
@@ -1,81 +1,432 @@
 package dsp
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"sync"
 	"sync/atomic"
 )
 
+const (
+	// Default length of the output fade-in after start or reset in milliseconds.
+	defaultStartupFadeMs = 1.0
+
+	// Default makeup gain smoothing time in milliseconds.
+	defaultMakeupSmoothingMs = 20.0
+
+	// Default threshold smoothing time in milliseconds.
+	defaultThresholdSmoothingMs = 5.0
+
+	// Default hard clipper ceiling in dBFS.
+	defaultHardClipCeilingDB = 0.0
+)
+
+// ErrInvalidChannel is returned when a channel index is outside the configured channel count.
+var ErrInvalidChannel = errors.New("invalid channel index")
+
 // MeterStats holds current levels for UI.
 type MeterStats struct {
-	InputL         float64
-	InputR         float64
-	OutputL        float64
-	OutputR        float64
-	GainReductionL float64
-	GainReductionR float64
-	Blocks         uint64
-	SampleRate     float64
+	InputL                float64
+	InputR                float64
+	OutputL               float64
+	OutputR               float64
+	InputMeterL           float64 // Input peak with meter ballistics (linear)
+	InputMeterR           float64 // Input peak with meter ballistics (linear)
+	OutputMeterL          float64 // Output peak with meter ballistics (linear)
+	OutputMeterR          float64 // Output peak with meter ballistics (linear)
+	GainReductionL        float64
+	GainReductionR        float64
+	MeanGainL             float64         // Average gain of the last block (linear)
+	MeanGainR             float64         // Average gain of the last block (linear)
+	AverageGainReductionL float64         // Slow average of block gain reduction in dB
+	AverageGainReductionR float64         // Slow average of block gain reduction in dB
+	GainReductionDevL     float64         // Standard deviation of block gain reduction in dB
+	GainReductionDevR     float64         // Standard deviation of block gain reduction in dB
+	GainReductionMeterL   float64         // Gain reduction with meter ballistics in dB
+	GainReductionMeterR   float64         // Gain reduction with meter ballistics in dB
+	InputClipL            bool            // Last block's input reached or exceeded 0 dBFS
+	InputClipR            bool            // Last block's input reached or exceeded 0 dBFS
+	InputClipCountL       uint64          // Raw input samples at or above 0 dBFS since the last clip reset
+	InputClipCountR       uint64          // Raw input samples at or above 0 dBFS since the last clip reset
+	OutputClipCountL      uint64          // Output samples at or above 0 dBFS since the last clip reset
+	OutputClipCountR      uint64          // Output samples at or above 0 dBFS since the last clip reset
+	DCOffsetL             float64         // Slow average of the raw input signal
+	DCOffsetR             float64         // Slow average of the raw input signal
+	AverageInputL         float64         // Slow average of the input peak (linear)
+	AverageInputR         float64         // Slow average of the input peak (linear)
+	CrestFactorL          float64         // Input peak to RMS ratio of the last block in dB
+	CrestFactorR          float64         // Input peak to RMS ratio of the last block in dB
+	PeakHoldInputL        float64         // Held input peak (linear)
+	PeakHoldInputR        float64         // Held input peak (linear)
+	PeakHoldOutputL       float64         // Held output peak (linear)
+	PeakHoldOutputR       float64         // Held output peak (linear)
+	GainStagingHint       GainStagingHint // Most severe input gain-staging hint over all channels
+	Blocks                uint64
+	SampleRate            float64
 }
 
+// GainComputer maps the detected (enveloped) peak level, in linear scale, to a
+// linear gain multiplier. It runs on the audio thread for every sample, so it
+// must be fast, allocation-free and must not block.
+type GainComputer func(peakLevel float64) float64
+
 // SoftKneeCompressor implements a professional-quality dynamics processor
 // with soft-knee compression, attack/release envelopes, and automatic makeup gain.
+//
+// Parameter setters ignore NaN and infinite values and keep the previous setting.
 type SoftKneeCompressor struct {
 	mu sync.Mutex // Protects parameters and coefficient updates
 
 	// User parameters
-	thresholdDB  float64 // Compression threshold in dB
-	ratio        float64 // Compression ratio (e.g., 4.0 for 4:1)
-	kneeDB       float64 // Soft knee width in dB
-	attackMs     float64 // Attack time in milliseconds
-	releaseMs    float64 // Release time in milliseconds
-	makeupGainDB float64 // Makeup gain in dB
-	autoMakeup   bool    // Automatic makeup gain calculation
-	bypass       bool    // Bypass processing
+	thresholdDB          float64   // Compression threshold in dB
+	ratio                float64   // Compression ratio (e.g., 4.0 for 4:1)
+	kneeDB               float64   // Soft knee width in dB
+	limiterKneeDB        float64   // Knee width in dB at limiter ratios, negative = kneeDB
+	attackMs             float64   // Attack time in milliseconds
+	releaseMs            float64   // Release time in milliseconds
+	attackReleaseLink    bool      // Derive the release from the attack
+	attackReleaseRatio   float64   // Release time as a multiple of the attack time
+	speed                float64   // Factor scaling attack and release times
+	makeupGainDB         float64   // Makeup gain in dB
+	outputGainDB         float64   // Output trim in dB, applied on top of makeup gain
+	autoMakeup           bool      // Automatic makeup gain calculation
+	bypass               bool      // Bypass processing
+	makeupBypass         bool      // Skip makeup gain (manual and auto) while still compressing
+	startupFadeMs        float64   // Output fade-in length after start/reset in milliseconds
+	stableMode           bool      // Keep the effective release at least as long as the attack
+	freeze               bool      // Hold the envelope (and therefore the gain) at its current value
+	sidechainTilt        float64   // Detection tilt in dB per octave around the pivot
+	precision            Precision // Numeric precision of the envelope and gain curve
+	accuracy             Accuracy  // Power function accuracy of the gain curve
+	hardClip             bool      // Clamp the output to the ceiling as a last resort
+	sidechainListen      bool      // Output the detector key instead of the processed audio
+	makeupSmoothingMs    float64   // Time constant for makeup gain changes in milliseconds
+	thresholdSmoothingMs float64   // Time constant for threshold changes in milliseconds
+	headroomAware        bool      // Back auto makeup off to keep output peaks below the margin
+	headroomMarginDB     float64   // Headroom safety margin below 0 dBFS in dB
+	autoRelease          bool      // Program-dependent release
+	hardClipCeilingDB    float64   // Hard clipper ceiling in dBFS
+	rangeDB              float64   // Largest gain reduction in dB, 0 = unlimited
+	dimDB                float64   // Monitoring dim attenuation in dB, 0 = off
+	attackSamples        int       // Attack time in samples, 0 = set in milliseconds
+	releaseSamples       int       // Release time in samples, 0 = set in milliseconds
+	sampleTimesFollow    bool      // Rescale sample times on rate changes to keep their duration
 
 	// Internal state (per channel)
-	peak          []float64 // Current peak level for each channel
-	attackFactor  float64   // Attack coefficient
-	releaseFactor float64   // Release coefficient
+	peak             []float64 // Current peak level for each channel
+	samplesProcessed []uint64  // Running sample counter for each channel
+	sidechainSource  []int     // Channels driving the shared detector (empty = per-channel detection)
+	linkWeights      []float64 // Contribution of each channel to the shared detector
+	linkGroups       [][]int   // Groups of channels sharing a detector across a frame
+	linkHighPassHz   float64   // Corner of the linked detection high-pass, 0 = off
+	linkHPCoeff      float64   // Pole of the linked detection high-pass
+	invertPolarity   []bool    // Output polarity inversion for each channel
+	solo             int       // Soloed channel whose output alone is heard, -1 = none
+
+	autoThresholdTargetDB float64 // Gain reduction the auto threshold aims for in dB, 0 = disabled
+	autoThresholdOffsetDB float64 // Offset the auto threshold adds to thresholdDB
+
+	// NaN/Inf safety mute
+	nanSafety            bool          // Mute the output on sustained NaN/Inf input
+	nanSafetyLimit       int           // NaN/Inf samples within a window that trigger the mute
+	nanEvents            int           // NaN/Inf samples in the current window
+	nanWindowPos         int           // Samples counted in the current window
+	nanCleanSamples      int           // Consecutive clean samples since the last NaN/Inf
+	nanMuted             uint32        // Whether the safety mute is active (atomic)
+	gainComputer         GainComputer  // Optional replacement for the built-in gain curve
+	linkMode             LinkMode      // How the channels of a frame share gain reduction
+	deltaMonitor         bool          // Output the removed signal instead of the processed one
+	makeupThresholdLock  bool          // Manual makeup moves opposite to threshold changes
+	transferCurve        transferCurve // Optional loaded curve replacing the parametric one
+	gainInterval         int           // Recompute the gain every n samples (1 = every sample)
+	gainCountdown        []int         // Samples left until the next gain update for each channel
+	currentGain          []float64     // Interpolated gain for each channel between updates
+	gainStep             []float64     // Per-sample gain increment towards the last computed gain
+	tiltState            []float64     // Sidechain tilt low-pass state for each channel
+	linkHPIn             []float64     // Linked high-pass input history for each channel
+	linkHPOut            []float64     // Linked high-pass output history for each channel
+	peak32               []float32     // Envelope state for the float32 path
+	smoothedMakeup       []float64     // Makeup gain ramping towards makeupGainLin for each channel
+	smoothedDim          []float64     // Dim gain ramping towards dimGain for each channel
+	smoothedOutput       []float64     // Output gain ramping towards outputGainLin for each channel
+	headroomPeak         []float64     // Pre-makeup output true-peak hold for each channel
+	energyShort          []float64     // Auto-release short-term energy for each channel
+	energyLong           []float64     // Auto-release long-term energy for each channel
+	transient            []bool        // Auto-release classification for each channel
+	transientHold        []int         // Auto-release samples left in which an onset keeps the channel transient
+	gainEnvelopes        [][]float64   // Per-sample gains of the last block for the CV output
+	warmthPrev           []float64     // Warmth DC blocker input history for each channel
+	warmthState          []float64     // Warmth DC blocker output history for each channel
+	transientFast        []float64     // Fast transient detector envelope for each channel
+	transientSlow        []float64     // Slow transient detector envelope for each channel
+	slewedGain           []float64     // Slew-limited total gain for each channel
+	frameKey             []float64     // ProcessFrames scratch: per-channel detector key
+	frameOutput          []float64     // ProcessFrames scratch: per-channel output sample
+	frameGain            []float64     // ProcessFrames scratch: per-channel applied gain
+	frameMaxIn           []float64     // ProcessFrames scratch: per-channel input peak
+	frameMaxOut          []float64     // ProcessFrames scratch: per-channel output peak
+	frameMinGain         []float64     // ProcessFrames scratch: per-channel minimum gain
+	frameGainSum         []float64     // ProcessFrames scratch: per-channel sum of the applied gain
+	planarIn             []float32     // ProcessChannels scratch: interleaved input
+	planarOut            []float32     // ProcessChannels scratch: interleaved output
+	attackFactor         float64       // Attack coefficient
+	releaseFactor        float64       // Release coefficient
+	releaseFactorFast    float64       // Auto-release coefficient for transient content
+	releaseFactorSlow    float64       // Auto-release coefficient for sustained content
+	energyShortCoeff     float64       // Auto-release short-term follower coefficient
+	energyLongCoeff      float64       // Auto-release long-term follower coefficient
+	transientHoldSamples int           // Auto-release onset hold in samples
+	halfLives            halfLifeCache // Memoized attack/release coefficients
 
 	// Cached calculations
-	threshold      float64 // Linear threshold
-	thresholdRecip float64 // 1 / threshold
-	kneeWidth      float64 // Knee width in linear
-	kneeUpper      float64 // Upper knee boundary
-	kneeLower      float64 // Lower knee boundary
-	makeupGainLin  float64 // Linear makeup gain
-	slopeRecip     float64 // 1 / ratio - 1 (for gain calculation)
-	sampleRate     float64 // Current sample rate
-	channels       int     // Number of audio channels
+	threshold               float64       // Linear threshold
+	thresholdRecip          float64       // 1 / threshold
+	kneeWidth               float64       // Knee width in linear
+	kneeUpper               float64       // Upper knee boundary
+	kneeLower               float64       // Lower knee boundary
+	makeupGainLin           float64       // Linear makeup gain
+	outputGainLin           float64       // Linear output trim
+	hardClipCeiling         float64       // Linear hard clipper ceiling
+	rangeGain               float64       // Linear gain floor set by the range
+	dimGain                 float64       // Linear dim gain, 1 while off
+	fadeSamples             float64       // Startup fade length in samples
+	tiltCoeff               float64       // Sidechain tilt low-pass coefficient
+	makeupSmoothingCoeff    float64       // Per-sample makeup gain smoothing coefficient
+	dimSmoothingCoeff       float64       // Per-sample dim gain smoothing coefficient
+	thresholdSmoothingCoeff float64       // Per-sample threshold smoothing coefficient
+	activeThresholdDB       float64       // Threshold in dB ramping towards thresholdDB
+	thresholdRampFromDB     float64       // Active threshold in dB when the current ramp started
+	thresholdRampToDB       float64       // Threshold in dB the current ramp heads to
+	thresholdRampStart      uint64        // Sample position at which the current ramp started
+	headroomCeiling         float64       // Linear output ceiling for headroom-aware makeup
+	headroomRelease         float64       // Per-sample release of the headroom peak hold
+	cvRangeDB               float64       // Gain reduction mapped to full-scale CV, 0 = disabled
+	warmth                  float64       // Warmth amount (0..1)
+	warmthCoeff             float64       // Quadratic coefficient of the warmth shaper
+	warmthDCCoeff           float64       // Pole of the warmth DC blocker
+	transientPreserve       float64       // How far onsets relax the gain reduction (0..1)
+	transientFastRelease    float64       // Release pole of the fast transient envelope
+	transientSlowAttack     float64       // Attack pole of the slow transient envelope
+	transientSlowRelease    float64       // Release pole of the slow transient envelope
+	maxSlewDBPerMs          float64       // Slew limit of the total gain in dB/ms, 0 = off
+	slewStep                float64       // Largest total gain ratio between two samples
+	tiltLowGain             float64       // Sidechain tilt gain below the pivot
+	tiltHighGain            float64       // Sidechain tilt gain above the pivot
+	slopeRecip              float64       // 1 / ratio - 1 (for gain calculation)
+	params32                float32Params // Cached parameters for the float32 path
+	sampleRate              float64       // Current sample rate
+	channels                int           // Number of audio channels
 
 	// Metering (Atomic bits of float64 for lock-free UI reading)
-	inputPeakL      uint64
-	inputPeakR      uint64
-	outputPeakL     uint64
-	outputPeakR     uint64
-	gainReductionL  uint64
-	gainReductionR  uint64
-	processedBlocks uint64 // Atomic counter
+	inputPeak        []uint64 // Per-channel input peak of the last block (atomic float64 bits)
+	outputPeak       []uint64 // Per-channel output peak of the last block (atomic float64 bits)
+	gainReduction    []uint64 // Per-channel minimum gain of the last block (atomic float64 bits)
+	meanGain         []uint64 // Per-channel average gain of the last block (atomic float64 bits)
+	grAverage        []uint64 // Per-channel average gain reduction in dB (atomic float64 bits)
+	grVariance       []uint64 // Per-channel gain reduction variance in dB² (atomic float64 bits)
+	grMeter          []uint64 // Per-channel gain reduction with meter ballistics in dB (atomic float64 bits)
+	inputMeter       []uint64 // Per-channel input peak with meter ballistics (atomic float64 bits)
+	outputMeter      []uint64 // Per-channel output peak with meter ballistics (atomic float64 bits)
+	inputClip        []uint32 // Per-channel input-over-0dBFS flag for the last block (atomic)
+	inputClips       []uint64 // Per-channel count of raw input samples at or above 0 dBFS (atomic)
+	outputClips      []uint64 // Per-channel count of output samples at or above 0 dBFS (atomic)
+	dcOffset         []uint64 // Per-channel DC offset of the raw input (atomic float64 bits)
+	inputAverage     []uint64 // Per-channel slow average of the input peak (atomic float64 bits)
+	crestFactor      []uint64 // Per-channel input crest factor of the last block in dB (atomic float64 bits)
+	peakHoldIn       []uint64 // Per-channel held input peak (atomic float64 bits)
+	peakHoldOut      []uint64 // Per-channel held output peak (atomic float64 bits)
+	peakHoldInAge    []int    // Samples since the held input peak was set
+	peakHoldOutAge   []int    // Samples since the held output peak was set
+	peakHoldInfinite bool     // Held peaks never fall back
+	gainStaging      []uint32 // Per-channel GainStagingHint (atomic)
+	clipHoldSamples  []int    // Per-channel samples left before the clipping hint clears
+	gainStagingLowDB float64  // Averaged input level in dBFS below which the input is under-driven
+
+	// Attack and release times of each meter kind
+	meterBallistics [meterKinds]meterBallistics
+
+	// Output true-peak metering
+	truePeak        []uint64                // Per-channel highest oversampled output peak since the last reset (atomic float64 bits)
+	truePeakHistory [][truePeakTaps]float64 // Per-channel output history of the oversampling filter
+	truePeakEnabled bool                    // Output true peaks are measured
+
+	// True-peak detection for headroom-aware makeup
+	headroomHistory [][truePeakTaps]float64 // Per-channel pre-makeup history of the oversampling filter
+	headroomHold    []int                   // Per-channel samples left before the headroom peak hold releases
+	headroomHoldLen int                     // Samples a new headroom peak is held before releasing
+
+	// Envelope timing for external-key processing
+	sidechainAttackMs      float64 // Attack time for an external key in milliseconds, 0 = main attack
+	sidechainReleaseMs     float64 // Release time for an external key in milliseconds, 0 = main release
+	sidechainAttackFactor  float64 // Attack coefficient for an external key
+	sidechainReleaseFactor float64 // Release coefficient for an external key
+	externalKey            bool    // The block being processed is driven by an external key
+
+	// Auto sleep on sustained silence
+	autoSleep            bool     // Skip the detector and gain computer during silence
+	autoSleepThresholdDB float64  // Detector level in dBFS below which a channel counts as silent
+	autoSleepThreshold   float64  // Linear autoSleepThresholdDB
+	autoSleepHoldMs      float64  // Silence in milliseconds before a channel sleeps
+	autoSleepHold        int      // autoSleepHoldMs in samples
+	silentSamples        []int    // Per-channel run of consecutive silent samples
+	asleep               []bool   // Per-channel sleep state
+	sleptSamples         []uint64 // Per-channel count of samples passed through asleep (atomic)
+
+	// Control-rate gain with block interpolation
+	blockGainInterpolation bool      // Compute the gain once per block and interpolate
+	blockGain              []float64 // Per-channel gain at the end of the last block
+	blockKeys              []float64 // Scratch: detector keys of the current block
+
+	// Lookahead
+	lookaheadMs       float64   // Lookahead time in milliseconds
+	lookaheadSamples  int       // Lookahead delay in samples
+	lookaheadReserved int       // Lookahead samples per channel lookaheadBuf has room for
+	lookaheadBuf      []float64 // Per-channel audio delay lines, lookaheadSamples per channel from the start
+	lookaheadPos      []int     // Per-channel delay line write position
+
+	// Session statistics since construction
+	sessions []sessionStats // Per-channel levels and gain reduction over the whole session
+
+	// Output limiter lookahead
+	limiterMs           float64            // Limiter lookahead time in milliseconds
+	limiterSamples      int                // Limiter lookahead delay in samples
+	limiterReserved     int                // Lookahead samples the limiters have room for
+	limiters            []lookaheadLimiter // Per-channel limiter state
+	limiterReleaseCoeff float64            // Per-sample limiter release coefficient
+
+	// Maximizer on the output limiter
+	maximize           bool        // Boost the output towards the maximize ceiling
+	maximizeTargetDB   float64     // Maximize true-peak ceiling in dBTP
+	maximizeCeiling    float64     // Linear maximize ceiling
+	maximizers         []maximizer // Per-channel maximizer state
+	maximizeHoldLen    int         // Samples a new maximizer peak is held before releasing
+	maximizeRelease    float64     // Per-sample release of the maximizer peak hold
+	maximizeBoostCoeff float64     // Per-sample smoothing coefficient of the boost
+
+	// Input capture for material analysis
+	captureBuf    []float32 // Ring of recent input samples of the first channel
+	capturePos    int       // Next write position in captureBuf
+	captureFilled bool      // Whether captureBuf has wrapped around
+
+	// Gain history for waveform display
+	gainHistories         []gainHistory // Per-channel decimated gain rings
+	gainHistoryLength     int           // Entries kept per channel, 0 = disabled
+	gainHistoryIntervalMs float64       // Capture interval per entry in milliseconds
+	grSegmentCount        uint32        // Gain-reduction segments per block, 0 = disabled (atomic)
+	grSegments            []uint64      // Per-channel segment gain reduction in dB, maxGRSegments per channel (atomic float64 bits)
+	segmentMinGain        []float64     // Scratch: per-channel segment minimum gain of the current block
+	processedBlocks       uint64        // Atomic counter
+	meterSampleRate       uint64        // Copy of sampleRate for the lock-free GetMeters (atomic float64 bits)
 }
 
 // NewSoftKneeCompressor creates a new compressor with default settings.
 func NewSoftKneeCompressor(sampleRate float64, channels int) *SoftKneeCompressor {
 	compressor := &SoftKneeCompressor{
-		thresholdDB:     -20.0,
-		ratio:           4.0,
-		kneeDB:          6.0,
-		attackMs:        10.0,
-		releaseMs:       100.0,
-		makeupGainDB:    0.0,
-		autoMakeup:      true,
-		bypass:          false,
-		sampleRate:      sampleRate,
-		channels:        channels,
-		peak:            make([]float64, channels),
-		processedBlocks: 0,
+		thresholdDB:          -20.0,
+		ratio:                4.0,
+		kneeDB:               6.0,
+		limiterKneeDB:        -1.0,
+		attackMs:             10.0,
+		releaseMs:            100.0,
+		attackReleaseRatio:   defaultAttackReleaseRatio,
+		speed:                1.0,
+		makeupGainDB:         0.0,
+		autoMakeup:           true,
+		bypass:               false,
+		startupFadeMs:        defaultStartupFadeMs,
+		makeupSmoothingMs:    defaultMakeupSmoothingMs,
+		thresholdSmoothingMs: defaultThresholdSmoothingMs,
+		headroomMarginDB:     defaultHeadroomMarginDB,
+		hardClipCeilingDB:    defaultHardClipCeilingDB,
+		sampleRate:           sampleRate,
+		channels:             channels,
+		peak:                 make([]float64, channels),
+		samplesProcessed:     make([]uint64, channels),
+		invertPolarity:       make([]bool, channels),
+		solo:                 -1,
+		nanSafetyLimit:       defaultNaNSafetyLimit,
+		linkWeights:          make([]float64, channels),
+		gainInterval:         1,
+		gainCountdown:        make([]int, channels),
+		currentGain:          make([]float64, channels),
+		gainStep:             make([]float64, channels),
+		tiltState:            make([]float64, channels),
+		linkHPIn:             make([]float64, channels),
+		linkHPOut:            make([]float64, channels),
+		peak32:               make([]float32, channels),
+		smoothedMakeup:       make([]float64, channels),
+		smoothedDim:          make([]float64, channels),
+		smoothedOutput:       make([]float64, channels),
+		headroomPeak:         make([]float64, channels),
+		energyShort:          make([]float64, channels),
+		energyLong:           make([]float64, channels),
+		transient:            make([]bool, channels),
+		transientHold:        make([]int, channels),
+		gainEnvelopes:        make([][]float64, channels),
+		warmthPrev:           make([]float64, channels),
+		warmthState:          make([]float64, channels),
+		transientFast:        make([]float64, channels),
+		transientSlow:        make([]float64, channels),
+		slewedGain:           make([]float64, channels),
+		frameKey:             make([]float64, channels),
+		frameOutput:          make([]float64, channels),
+		frameGain:            make([]float64, channels),
+		frameMaxIn:           make([]float64, channels),
+		frameMaxOut:          make([]float64, channels),
+		frameMinGain:         make([]float64, channels),
+		frameGainSum:         make([]float64, channels),
+		inputPeak:            make([]uint64, channels),
+		outputPeak:           make([]uint64, channels),
+		gainReduction:        make([]uint64, channels),
+		meanGain:             make([]uint64, channels),
+		grAverage:            make([]uint64, channels),
+		grVariance:           make([]uint64, channels),
+		grMeter:              make([]uint64, channels),
+		inputMeter:           make([]uint64, channels),
+		outputMeter:          make([]uint64, channels),
+		meterBallistics:      defaultMeterBallistics(),
+		inputClip:            make([]uint32, channels),
+		inputClips:           make([]uint64, channels),
+		outputClips:          make([]uint64, channels),
+		dcOffset:             make([]uint64, channels),
+		inputAverage:         make([]uint64, channels),
+		crestFactor:          make([]uint64, channels),
+		peakHoldIn:           make([]uint64, channels),
+		peakHoldOut:          make([]uint64, channels),
+		peakHoldInAge:        make([]int, channels),
+		peakHoldOutAge:       make([]int, channels),
+		truePeak:             make([]uint64, channels),
+		truePeakHistory:      make([][truePeakTaps]float64, channels),
+		headroomHistory:      make([][truePeakTaps]float64, channels),
+		headroomHold:         make([]int, channels),
+		autoSleepThresholdDB: defaultAutoSleepThresholdDB,
+		autoSleepHoldMs:      defaultAutoSleepHoldMs,
+		silentSamples:        make([]int, channels),
+		asleep:               make([]bool, channels),
+		sleptSamples:         make([]uint64, channels),
+		gainStaging:          make([]uint32, channels),
+		clipHoldSamples:      make([]int, channels),
+		gainStagingLowDB:     defaultGainStagingLowDB,
+		gainHistories:        make([]gainHistory, channels),
+		lookaheadPos:         make([]int, channels),
+		limiters:             make([]lookaheadLimiter, channels),
+		maximizers:           make([]maximizer, channels),
+		sessions:             make([]sessionStats, channels),
+		blockGain:            make([]float64, channels),
+		grSegments:           make([]uint64, channels*maxGRSegments),
+		segmentMinGain:       make([]float64, channels*maxGRSegments),
+		processedBlocks:      0,
+		meterSampleRate:      math.Float64bits(sampleRate),
+	}
+
+	for ch := range compressor.linkWeights {
+		compressor.linkWeights[ch] = 1.0
 	}
+
 	compressor.updateParameters()
+	compressor.updateTimeConstants()
+	compressor.resetState()
 
 	return compressor
 }
@@ -85,7 +436,19 @@ func (c *SoftKneeCompressor) SetThreshold(dB float64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if !isFinite(dB) {
+		return
+	}
+
+	if c.makeupThresholdLock && !c.autoMakeup {
+		c.makeupGainDB -= dB - c.thresholdDB
+	}
+
 	c.thresholdDB = dB
+	if !c.hasProcessed() {
+		c.snapThreshold()
+	}
+
 	c.updateParameters()
 }
 
@@ -94,6 +457,10 @@ func (c *SoftKneeCompressor) SetRatio(ratio float64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if !isFinite(ratio) {
+		return
+	}
+
 	if ratio < 1.0 {
 		ratio = 1.0
 	}
@@ -102,11 +469,16 @@ func (c *SoftKneeCompressor) SetRatio(ratio float64) {
 	c.updateParameters()
 }
 
-// SetKnee sets the soft knee width in dB.
+// SetKnee sets the soft knee width in dB. See SetLimiterKnee for ratios of
+// 20:1 and above.
 func (c *SoftKneeCompressor) SetKnee(kneeDB float64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if !isFinite(kneeDB) {
+		return
+	}
+
 	if kneeDB < 0.0 {
 		kneeDB = 0.0
 	}
@@ -115,30 +487,48 @@ func (c *SoftKneeCompressor) SetKnee(kneeDB float64) {
 	c.updateParameters()
 }
 
-// SetAttack sets the attack time in milliseconds.
+// SetAttack sets the attack time in milliseconds: the time the envelope takes
+// to rise halfway to a level step, independent of the sample rate.
+//
+// An attack much slower than the release makes the follower rise slowly but
+// fall quickly between waveform peaks, so on sustained tones the envelope
+// settles far below the signal peak and under-compresses. See SetStableMode.
+// With SetAttackReleaseLink the release follows the attack.
 func (c *SoftKneeCompressor) SetAttack(timeMs float64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if !isFinite(timeMs) {
+		return
+	}
+
 	if timeMs < 0.1 {
 		timeMs = 0.1
 	}
 
 	c.attackMs = timeMs
-	c.updateTimeConstants()
+	c.attackSamples = 0
+	c.updateEnvelopeConstants()
+	c.linkRelease()
 }
 
-// SetRelease sets the release time in milliseconds.
+// SetRelease sets the release time in milliseconds: the time the envelope
+// takes to fall halfway, independent of the sample rate.
 func (c *SoftKneeCompressor) SetRelease(timeMs float64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if !isFinite(timeMs) {
+		return
+	}
+
 	if timeMs < 1.0 {
 		timeMs = 1.0
 	}
 
 	c.releaseMs = timeMs
-	c.updateTimeConstants()
+	c.releaseSamples = 0
+	c.updateEnvelopeConstants()
 }
 
 // SetMakeupGain sets the makeup gain in dB.
@@ -146,11 +536,58 @@ func (c *SoftKneeCompressor) SetMakeupGain(dB float64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if !isFinite(dB) {
+		return
+	}
+
 	c.makeupGainDB = dB
 	c.autoMakeup = false
 	c.updateParameters()
 }
 
+// SetOutputGain sets the output trim in dB. It is independent of the makeup
+// gain: with auto makeup enabled, the compensation is still computed from
+// threshold and ratio and the trim is applied on top of it.
+func (c *SoftKneeCompressor) SetOutputGain(dB float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(dB) {
+		return
+	}
+
+	c.outputGainDB = dB
+	c.updateParameters()
+}
+
+// SetHardClip enables a hard clipper after all gain stages that clamps any
+// residual sample to the ceiling. It guarantees no overs even when the envelope
+// can't react fast enough, at the cost of audible distortion on the clipped
+// peaks. SetLimiterLookahead avoids most of that distortion.
+func (c *SoftKneeCompressor) SetHardClip(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hardClip = enabled
+}
+
+// SetHardClipCeiling sets the hard clipper ceiling in dBFS (clamped to <= 0).
+func (c *SoftKneeCompressor) SetHardClipCeiling(dB float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(dB) {
+		return
+	}
+
+	if dB > 0.0 {
+		dB = 0.0
+	}
+
+	c.hardClipCeilingDB = dB
+	c.updateParameters()
+}
+
 // SetAutoMakeup enables automatic makeup gain calculation.
 func (c *SoftKneeCompressor) SetAutoMakeup(enable bool) {
 	c.mu.Lock()
@@ -160,7 +597,8 @@ func (c *SoftKneeCompressor) SetAutoMakeup(enable bool) {
 	c.updateParameters()
 }
 
-// SetBypass toggles bypass.
+// SetBypass toggles bypass. While bypassed the input and output meters keep
+// updating and the gain reduction meters read 0 dB.
 func (c *SoftKneeCompressor) SetBypass(bypass bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -168,17 +606,155 @@ func (c *SoftKneeCompressor) SetBypass(bypass bool) {
 	c.bypass = bypass
 }
 
+// SetMakeupBypass disables only the makeup gain, manual or automatic, while
+// the compressor keeps reducing gain, so the raw compression can be heard
+// without the level being brought back up. Unlike SetBypass the gain
+// reduction stays active. The change ramps with the makeup smoothing time.
+func (c *SoftKneeCompressor) SetMakeupBypass(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.makeupBypass = enabled
+}
+
+// GetMakeupBypass returns whether the makeup gain is bypassed.
+func (c *SoftKneeCompressor) GetMakeupBypass() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.makeupBypass
+}
+
+// SetStartupFade sets the length of the output fade-in applied after the
+// compressor starts processing or is reset, in milliseconds (0 disables it).
+func (c *SoftKneeCompressor) SetStartupFade(timeMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(timeMs) {
+		return
+	}
+
+	if timeMs < 0.0 {
+		timeMs = 0.0
+	}
+
+	c.startupFadeMs = timeMs
+	c.updateTimeConstants()
+}
+
+// SetSolo mutes the output of every channel except the given one so its
+// compression can be auditioned in isolation; -1 disables the solo. The muted
+// channels keep processing, so their meters stay live.
+func (c *SoftKneeCompressor) SetSolo(channel int) error {
+	if channel < -1 || channel >= c.channels {
+		return fmt.Errorf("%w: %d", ErrInvalidChannel, channel)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.solo = channel
+
+	return nil
+}
+
+// GetSolo returns the soloed channel, or -1 if no channel is soloed.
+func (c *SoftKneeCompressor) GetSolo() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.solo
+}
+
+// SetPolarity inverts (or restores) the output polarity of a channel after processing.
+func (c *SoftKneeCompressor) SetPolarity(channel int, invert bool) error {
+	if channel < 0 || channel >= c.channels {
+		return fmt.Errorf("%w: %d", ErrInvalidChannel, channel)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invertPolarity[channel] = invert
+
+	return nil
+}
+
+// SetGainComputer installs a custom gain computer that replaces the built-in
+// soft-knee curve. Pass nil to restore the built-in curve. Makeup gain is still
+// applied on top of the returned gain. The function runs on the audio thread and
+// must be fast and allocation-free.
+func (c *SoftKneeCompressor) SetGainComputer(fn GainComputer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gainComputer = fn
+}
+
+// SetGainUpdateInterval recomputes the gain only every n samples and linearly
+// interpolates towards it in between, while the envelope follower still runs
+// every sample. This trades a little gain accuracy for less CPU at high channel
+// counts; n = 1 (the default) computes the gain every sample.
+func (c *SoftKneeCompressor) SetGainUpdateInterval(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n < 1 {
+		n = 1
+	}
+
+	c.gainInterval = n
+
+	for i := range c.gainCountdown {
+		c.gainCountdown[i] = 0
+	}
+}
+
+// SetFreeze stops (or resumes) the envelope follower. While frozen, audio keeps
+// passing with whatever gain was applied when freeze engaged, which allows A/B
+// listening to a static snapshot of a dynamic moment.
+func (c *SoftKneeCompressor) SetFreeze(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.freeze = enabled
+}
+
+// SetStableMode constrains the envelope coefficients so the effective release
+// is never shorter than the attack, so the follower tracks sustained tones even
+// when the attack is set much slower than the release. The user release time is kept
+// and applies again once it exceeds the attack.
+func (c *SoftKneeCompressor) SetStableMode(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stableMode = enabled
+	c.updateEnvelopeConstants()
+}
+
+// AttackExceedsRelease reports whether the attack time is longer than the
+// release time, which can make the envelope unstable on sustained tones.
+func (c *SoftKneeCompressor) AttackExceedsRelease() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.attackMs > c.releaseMs
+}
+
 // SetSampleRate updates the sample rate and recalculates time constants.
 func (c *SoftKneeCompressor) SetSampleRate(rate float64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if rate <= 0.0 {
+	if rate <= 0.0 || !isFinite(rate) {
 		return
 	}
 
 	if c.sampleRate != rate {
+		c.rescaleSampleTimes(rate)
 		c.sampleRate = rate
+		atomic.StoreUint64(&c.meterSampleRate, math.Float64bits(rate))
 		c.updateTimeConstants()
 	}
 }
@@ -195,7 +771,7 @@ func (c *SoftKneeCompressor) ProcessSample(sample float32, channel int) float32
 
 // ProcessBlock processes a slice of samples for a specific channel.
 func (c *SoftKneeCompressor) ProcessBlock(in []float32, out []float32, channel int) {
-	if channel < 0 || channel >= c.channels || len(in) != len(out) {
+	if channel < 0 || channel >= c.channels || len(in) != len(out) || len(in) == 0 {
 		return
 	}
 
@@ -203,52 +779,205 @@ func (c *SoftKneeCompressor) ProcessBlock(in []float32, out []float32, channel i
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	var maxInput, maxOutput float64
+	processBlockLocked(c, in, nil, out, channel, nil)
+}
+
+// processBlockLocked runs the ProcessBlock loop, shared by the float32 and
+// float64 APIs. A non-nil key drives the detector instead of the input. If
+// automate is non-nil it is called with the sample index before each sample is
+// processed (internal, assumes lock held and arguments validated).
+func processBlockLocked[S float32 | float64](c *SoftKneeCompressor, in, key, out []S, channel int, automate func(i int)) {
+	var maxInput, maxOutput, gainSum float64
 	minGain := 1.0
 
+	c.resetSegmentGains(channel)
+
+	// Control-rate gain doesn't combine with per-sample automation
+	blockGains := c.blockGainInterpolation && automate == nil
+
+	var startGain, endGain float64
+	if blockGains {
+		startGain, endGain = prepareBlockGain(c, in, key, channel)
+	}
+
 	for i := 0; i < len(in); i++ {
+		if automate != nil {
+			automate(i)
+		}
+
 		// NaN Check
-		if math.IsNaN(float64(in[i])) || math.IsInf(float64(in[i]), 0) {
+		nonFinite := math.IsNaN(float64(in[i])) || math.IsInf(float64(in[i]), 0)
+		if nonFinite {
 			in[i] = 0
 		}
 
+		c.trackNonFinite(nonFinite)
+
+		c.captureInput(channel, float32(in[i]))
+
 		// Calculate meters
 		absIn := math.Abs(float64(in[i]))
 		if absIn > maxInput {
 			maxInput = absIn
 		}
 
-		processed, gain := c.processSampleInternal(in[i], channel)
+		countClip(c.inputClips, channel, absIn)
+
+		var processed, gain float64
 
-		// NaN Check Output
-		if math.IsNaN(float64(processed)) || math.IsInf(float64(processed), 0) {
-			processed = 0
+		switch {
+		case blockGains:
+			gain = startGain + (endGain-startGain)*float64(i+1)/float64(len(in))
+			processed = c.processSampleWithGain(float64(in[i]), c.blockKeys[i], gain, channel)
+		case key != nil:
+			processed, gain = c.processSampleKeyed64(float64(in[i]), c.detectorSignal(float64(sanitizeSample(key[i])), channel), channel)
+		default:
+			processed, gain = c.processSampleKeyed64(float64(in[i]), c.detectorSignal(float64(in[i]), channel), channel)
 		}
 
-		out[i] = processed
+		// NaN Check Output, after rounding to the output precision
+		out[i] = sanitizeSample(S(processed))
 
-		absOut := math.Abs(float64(processed))
+		absOut := math.Abs(float64(out[i]))
 		if absOut > maxOutput {
 			maxOutput = absOut
 		}
 
+		countClip(c.outputClips, channel, absOut)
+		c.measureTruePeak(channel, float64(out[i]))
+
 		if gain < minGain {
 			minGain = gain
 		}
+
+		gainSum += gain
+
+		c.trackSegmentGain(channel, i, len(in), gain)
+		c.recordGainEnvelope(channel, i, len(in), gain)
+		c.gainHistories[channel].push(gain)
+	}
+
+	atomic.StoreUint64(&c.dcOffset[channel], math.Float64bits(followDCOffset(c, channel, in, 1)))
+	c.storeCrestFactor(channel, maxInput, blockRMS(in, 1))
+	c.publishMeters(channel, maxInput, maxOutput, minGain, gainSum/float64(len(in)), len(in))
+}
+
+// ProcessFrames processes an interleaved buffer containing all channels.
+// Unlike ProcessBlock it sees every channel of a frame at once, so
+// cross-channel detection such as a sidechain source selection applies.
+func (c *SoftKneeCompressor) ProcessFrames(in []float32, out []float32) {
+	// Empty buffers and partial frames are ignored rather than processed out of step
+	if c.channels <= 0 || len(in) != len(out) || len(in) < c.channels || len(in)%c.channels != 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.processFramesLocked(in, out)
+}
+
+// ProcessChannels processes one block of planar audio, one buffer per channel,
+// as PipeWire delivers it per port. Like ProcessFrames it sees every channel
+// of a frame at once, so the sidechain source, link groups, link weights, the
+// linked high-pass and the link mode apply. in and out need one buffer per
+// channel, all of the same length; anything else is ignored.
+func (c *SoftKneeCompressor) ProcessChannels(in, out [][]float32) {
+	if c.channels <= 0 || len(in) != c.channels || len(out) != c.channels || len(in[0]) == 0 {
+		return
+	}
+
+	samples := len(in[0])
+	for ch := range c.channels {
+		if len(in[ch]) != samples || len(out[ch]) != samples {
+			return
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Interleave into scratch buffers so the frame loop is shared with
+	// ProcessFrames; they only grow when the block size does
+	size := samples * c.channels
+	if cap(c.planarIn) < size {
+		c.planarIn = make([]float32, size)
+		c.planarOut = make([]float32, size)
+	}
+
+	interleaved, processed := c.planarIn[:size], c.planarOut[:size]
+
+	for ch, buf := range in {
+		for i, sample := range buf {
+			interleaved[i*c.channels+ch] = sample
+		}
 	}
 
-	// Update atomic meters
-	switch channel {
-	case 0: // Left
-		atomic.StoreUint64(&c.inputPeakL, math.Float64bits(maxInput))
-		atomic.StoreUint64(&c.outputPeakL, math.Float64bits(maxOutput))
-		atomic.StoreUint64(&c.gainReductionL, math.Float64bits(minGain))
-		// Increment block counter (only on left channel to avoid double counting per stereo frame)
-		atomic.AddUint64(&c.processedBlocks, 1)
-	case 1: // Right
-		atomic.StoreUint64(&c.inputPeakR, math.Float64bits(maxInput))
-		atomic.StoreUint64(&c.outputPeakR, math.Float64bits(maxOutput))
-		atomic.StoreUint64(&c.gainReductionR, math.Float64bits(minGain))
+	c.processFramesLocked(interleaved, processed)
+
+	for ch, buf := range out {
+		for i := range buf {
+			buf[i] = processed[i*c.channels+ch]
+		}
+	}
+}
+
+// processFramesLocked runs the ProcessFrames loop over whole interleaved
+// frames (internal, assumes lock held and arguments validated).
+func (c *SoftKneeCompressor) processFramesLocked(in, out []float32) {
+	for ch := range c.channels {
+		c.frameMaxIn[ch] = 0
+		c.frameMaxOut[ch] = 0
+		c.frameMinGain[ch] = 1.0
+		c.frameGainSum[ch] = 0
+		c.resetSegmentGains(ch)
+	}
+
+	frames := len(in) / c.channels
+
+	for frameIdx := range frames {
+		frame := in[frameIdx*c.channels : (frameIdx+1)*c.channels]
+
+		for ch, sample := range frame {
+			nonFinite := math.IsNaN(float64(sample)) || math.IsInf(float64(sample), 0)
+			if nonFinite {
+				frame[ch] = 0
+			}
+
+			c.trackNonFinite(nonFinite)
+
+			c.frameMaxIn[ch] = math.Max(c.frameMaxIn[ch], math.Abs(float64(frame[ch])))
+			countClip(c.inputClips, ch, math.Abs(float64(frame[ch])))
+			c.captureInput(ch, frame[ch])
+		}
+
+		c.updateFrameKeys(frame)
+		c.processFrame(frame)
+
+		for ch := range frame {
+			processed, gain := float32(c.frameOutput[ch]), c.frameGain[ch]
+
+			if math.IsNaN(float64(processed)) || math.IsInf(float64(processed), 0) {
+				processed = 0
+			}
+
+			out[frameIdx*c.channels+ch] = processed
+
+			c.frameMaxOut[ch] = math.Max(c.frameMaxOut[ch], math.Abs(float64(processed)))
+			countClip(c.outputClips, ch, math.Abs(float64(processed)))
+			c.measureTruePeak(ch, float64(processed))
+			c.frameMinGain[ch] = math.Min(c.frameMinGain[ch], gain)
+			c.frameGainSum[ch] += gain
+			c.trackSegmentGain(ch, frameIdx, frames, gain)
+			c.recordGainEnvelope(ch, frameIdx, frames, gain)
+			c.gainHistories[ch].push(gain)
+		}
+	}
+
+	for ch := range c.channels {
+		c.updateDCOffset(ch, in[ch:], c.channels)
+		c.storeCrestFactor(ch, c.frameMaxIn[ch], blockRMS(in[ch:], c.channels))
+		c.publishMeters(ch, c.frameMaxIn[ch], c.frameMaxOut[ch], c.frameMinGain[ch], c.frameGainSum[ch]/float64(frames), frames)
 	}
 }
 
@@ -257,27 +986,126 @@ func (c *SoftKneeCompressor) Reset() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.resetState()
+}
+
+// resetState clears the per-channel processing state (internal, assumes lock held).
+func (c *SoftKneeCompressor) resetState() {
 	for i := range c.peak {
 		c.peak[i] = 0.0
+		c.samplesProcessed[i] = 0
+		c.gainCountdown[i] = 0
+		c.currentGain[i] = 1.0
+		c.gainStep[i] = 0.0
+		c.blockGain[i] = 1.0
+		c.tiltState[i] = 0.0
+		c.linkHPIn[i] = 0.0
+		c.linkHPOut[i] = 0.0
+		c.peak32[i] = 0.0
+		c.smoothedMakeup[i] = c.makeupGainLin
+		if c.makeupBypass {
+			c.smoothedMakeup[i] = 1.0
+		}
+		c.smoothedDim[i] = c.dimGain
+		c.smoothedOutput[i] = c.outputGainLin
+		c.headroomPeak[i] = 0.0
+		c.energyShort[i] = 0.0
+		c.energyLong[i] = 0.0
+		c.transient[i] = false
+		c.transientHold[i] = 0
+		c.warmthPrev[i] = 0.0
+		c.warmthState[i] = 0.0
+		c.transientFast[i] = 0.0
+		c.transientSlow[i] = 0.0
+		c.slewedGain[i] = 1.0
+		c.truePeakHistory[i] = [truePeakTaps]float64{}
+		c.headroomHistory[i] = [truePeakTaps]float64{}
+		c.headroomHold[i] = 0
+		c.maximizers[i].reset()
+		c.silentSamples[i] = 0
+		c.asleep[i] = false
 	}
+
+	c.clearLookahead()
+	c.clearLimiters()
+	c.resetNaNSafety()
+
+	c.snapThreshold()
+	c.updateThresholdCache()
 }
 
-// GetMeters returns current meter values safely.
-func (c *SoftKneeCompressor) GetMeters() MeterStats {
-	// Sample rate requires lock
+// Coefficients holds the internal values derived from the user parameters.
+type Coefficients struct {
+	AttackFactor  float64 // Per-sample attack coefficient of the envelope follower
+	ReleaseFactor float64 // Per-sample release coefficient of the envelope follower
+	Threshold     float64 // Linear threshold
+	KneeLower     float64 // Linear lower knee boundary
+	KneeUpper     float64 // Linear upper knee boundary
+	KneeWidth     float64 // Linear knee width
+	MakeupGainLin float64 // Linear makeup gain
+}
+
+// GetCoefficients returns a consistent snapshot of the derived coefficients.
+func (c *SoftKneeCompressor) GetCoefficients() Coefficients {
 	c.mu.Lock()
-	sampleRate := c.sampleRate
-	c.mu.Unlock()
+	defer c.mu.Unlock()
+
+	return Coefficients{
+		AttackFactor:  c.attackFactor,
+		ReleaseFactor: c.releaseFactor,
+		Threshold:     c.threshold,
+		KneeLower:     c.kneeLower,
+		KneeUpper:     c.kneeUpper,
+		KneeWidth:     c.kneeWidth,
+		MakeupGainLin: c.makeupGainLin,
+	}
+}
+
+// GetMeters returns current meter values safely. It only reads atomics and
+// never takes the lock, so a UI polling it can't hold up the audio thread.
+func (c *SoftKneeCompressor) GetMeters() MeterStats {
+	// Missing channels (e.g. R on a mono compressor) read as zero
+	left, _ := c.GetChannelMeters(0)
+	right, _ := c.GetChannelMeters(1)
 
 	return MeterStats{
-		InputL:         math.Float64frombits(atomic.LoadUint64(&c.inputPeakL)),
-		InputR:         math.Float64frombits(atomic.LoadUint64(&c.inputPeakR)),
-		OutputL:        math.Float64frombits(atomic.LoadUint64(&c.outputPeakL)),
-		OutputR:        math.Float64frombits(atomic.LoadUint64(&c.outputPeakR)),
-		GainReductionL: math.Float64frombits(atomic.LoadUint64(&c.gainReductionL)),
-		GainReductionR: math.Float64frombits(atomic.LoadUint64(&c.gainReductionR)),
-		Blocks:         atomic.LoadUint64(&c.processedBlocks),
-		SampleRate:     sampleRate,
+		InputL:                left.Input,
+		InputR:                right.Input,
+		OutputL:               left.Output,
+		OutputR:               right.Output,
+		InputMeterL:           left.InputMeter,
+		InputMeterR:           right.InputMeter,
+		OutputMeterL:          left.OutputMeter,
+		OutputMeterR:          right.OutputMeter,
+		GainReductionL:        left.GainReduction,
+		GainReductionR:        right.GainReduction,
+		MeanGainL:             left.MeanGain,
+		MeanGainR:             right.MeanGain,
+		AverageGainReductionL: left.AverageGainReduction,
+		AverageGainReductionR: right.AverageGainReduction,
+		GainReductionDevL:     left.GainReductionDev,
+		GainReductionDevR:     right.GainReductionDev,
+		GainReductionMeterL:   left.GainReductionMeter,
+		GainReductionMeterR:   right.GainReductionMeter,
+		InputClipL:            left.InputClip,
+		InputClipR:            right.InputClip,
+		InputClipCountL:       left.InputClipCount,
+		InputClipCountR:       right.InputClipCount,
+		OutputClipCountL:      left.OutputClipCount,
+		OutputClipCountR:      right.OutputClipCount,
+		DCOffsetL:             left.DCOffset,
+		DCOffsetR:             right.DCOffset,
+		AverageInputL:         left.AverageInput,
+		AverageInputR:         right.AverageInput,
+		CrestFactorL:          left.CrestFactor,
+		CrestFactorR:          right.CrestFactor,
+		PeakHoldInputL:        left.PeakHoldInput,
+		PeakHoldInputR:        right.PeakHoldInput,
+		PeakHoldOutputL:       left.PeakHoldOutput,
+		PeakHoldOutputR:       right.PeakHoldOutput,
+		GainStagingHint:       c.worstGainStaging(),
+		Blocks:                atomic.LoadUint64(&c.processedBlocks),
+		SampleRate:            math.Float64frombits(atomic.LoadUint64(&c.meterSampleRate)),
 	}
 }
 
@@ -321,7 +1149,8 @@ func (c *SoftKneeCompressor) GetRelease() float64 {
 	return c.releaseMs
 }
 
-// GetMakeupGain returns the current makeup gain in dB.
+// GetMakeupGain returns the current makeup gain in dB. With auto makeup
+// enabled this is the computed compensation, excluding the output trim.
 func (c *SoftKneeCompressor) GetMakeupGain() float64 {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -329,6 +1158,35 @@ func (c *SoftKneeCompressor) GetMakeupGain() float64 {
 	return c.makeupGainDB
 }
 
+// GetOutputGain returns the output trim in dB.
+func (c *SoftKneeCompressor) GetOutputGain() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.outputGainDB
+}
+
+// GetHardClip returns whether the hard clipper is enabled.
+func (c *SoftKneeCompressor) GetHardClip() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hardClip
+}
+
+// GetHardClipCeiling returns the hard clipper ceiling in dBFS.
+func (c *SoftKneeCompressor) GetHardClipCeiling() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hardClipCeilingDB
+}
+
+// Channels returns the number of channels the compressor was created with.
+func (c *SoftKneeCompressor) Channels() int {
+	return c.channels
+}
+
 // GetAutoMakeup returns whether automatic makeup gain is enabled.
 func (c *SoftKneeCompressor) GetAutoMakeup() bool {
 	c.mu.Lock()
@@ -337,6 +1195,50 @@ func (c *SoftKneeCompressor) GetAutoMakeup() bool {
 	return c.autoMakeup
 }
 
+// GetStartupFade returns the startup fade-in length in milliseconds.
+func (c *SoftKneeCompressor) GetStartupFade() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.startupFadeMs
+}
+
+// GetPolarity returns whether the output polarity of a channel is inverted.
+func (c *SoftKneeCompressor) GetPolarity(channel int) bool {
+	if channel < 0 || channel >= c.channels {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.invertPolarity[channel]
+}
+
+// GetGainUpdateInterval returns the gain update interval in samples.
+func (c *SoftKneeCompressor) GetGainUpdateInterval() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gainInterval
+}
+
+// GetFreeze returns whether the envelope is frozen.
+func (c *SoftKneeCompressor) GetFreeze() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.freeze
+}
+
+// GetStableMode returns whether stable mode is enabled.
+func (c *SoftKneeCompressor) GetStableMode() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stableMode
+}
+
 // GetBypass returns whether bypass is enabled.
 func (c *SoftKneeCompressor) GetBypass() bool {
 	c.mu.Lock()
@@ -345,67 +1247,295 @@ func (c *SoftKneeCompressor) GetBypass() bool {
 	return c.bypass
 }
 
-// updateTimeConstants recalculates attack and release coefficients (internal, assumes lock held).
+// updateTimeConstants recalculates all sample-rate dependent coefficients
+// (internal, assumes lock held).
 func (c *SoftKneeCompressor) updateTimeConstants() {
-	c.attackFactor = 1.0 - math.Exp(-math.Ln2/(c.attackMs*0.001*c.sampleRate))
-	c.releaseFactor = math.Exp(-math.Ln2 / (c.releaseMs * 0.001 * c.sampleRate))
+	c.updateEnvelopeConstants()
+
+	c.fadeSamples = c.startupFadeMs * 0.001 * c.sampleRate
+	c.tiltCoeff = 1.0 - math.Exp(-2.0*math.Pi*sidechainTiltPivotHz/c.sampleRate)
+	c.updateAutoReleaseConstants()
+	c.makeupSmoothingCoeff = smoothingCoeff(c.makeupSmoothingMs, c.sampleRate)
+	c.dimSmoothingCoeff = smoothingCoeff(dimSmoothingMs, c.sampleRate)
+	c.thresholdSmoothingCoeff = smoothingCoeff(c.thresholdSmoothingMs, c.sampleRate)
+	c.headroomRelease = math.Exp(-1.0 / (headroomReleaseSec * c.sampleRate))
+	c.headroomHoldLen = int(headroomHoldSec * c.sampleRate)
+	c.warmthDCCoeff = math.Exp(-2.0 * math.Pi * warmthDCBlockHz / c.sampleRate)
+	c.updateTransientConstants()
+	c.updateLinkHighPass()
+	c.updateSlewStep()
+	c.updateAutoSleep()
+	c.updateGainHistoryDecimation()
+	c.updateLookahead()
+	c.updateMaximizeConstants()
+	c.updateLimiterLookahead()
 }
 
-// updateParameters recalculates all internal cached values (internal, assumes lock held).
-func (c *SoftKneeCompressor) updateParameters() {
-	c.threshold = DBToLinear(c.thresholdDB)
-	c.thresholdRecip = 1.0 / c.threshold
+// updateEnvelopeConstants recalculates the attack and release coefficients,
+// which is all that attack/release changes need. The exponentials come from
+// the half-life cache (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateEnvelopeConstants() {
+	c.applySampleTimes()
+
+	attackMs := c.attackMs * c.speed
+
+	releaseMs := c.releaseMs * c.speed
+	if c.stableMode && releaseMs < attackMs {
+		releaseMs = attackMs
+	}
+
+	c.attackFactor = 1.0 - c.halfLives.decay(attackMs*0.001*c.sampleRate)
+	c.releaseFactor = c.halfLives.decay(releaseMs * 0.001 * c.sampleRate)
+	c.releaseFactorFast = c.halfLives.decay(releaseMs / autoReleaseSpread * 0.001 * c.sampleRate)
+	c.releaseFactorSlow = c.halfLives.decay(releaseMs * autoReleaseSpread * 0.001 * c.sampleRate)
+	c.updateSidechainEnvelope()
+	c.updateFloat32Params()
+}
 
-	kneeHalfDB := c.kneeDB / 2.0
-	c.kneeLower = DBToLinear(c.thresholdDB - kneeHalfDB)
-	c.kneeUpper = DBToLinear(c.thresholdDB + kneeHalfDB)
-	c.kneeWidth = c.kneeUpper - c.kneeLower
+// updateParameters recalculates the level-dependent cached values (internal,
+// assumes lock held).
+func (c *SoftKneeCompressor) updateParameters() {
+	c.updateThresholdCache()
 
 	c.slopeRecip = 1.0/c.ratio - 1.0
 
 	if c.autoMakeup {
-		gainReductionDB := c.thresholdDB * (1.0 - 1.0/c.ratio)
+		gainReductionDB := c.targetThresholdDB() * (1.0 - 1.0/c.ratio)
+		if c.rangeDB > 0.0 {
+			gainReductionDB = math.Max(gainReductionDB, -c.rangeDB)
+		}
+
 		c.makeupGainDB = -gainReductionDB
 	}
 
+	c.rangeGain = DBToLinear(-c.rangeDB)
+	c.dimGain = DBToLinear(c.dimDB)
+
 	c.makeupGainLin = DBToLinear(c.makeupGainDB)
-	c.updateTimeConstants()
+	c.outputGainLin = DBToLinear(c.outputGainDB)
+	c.hardClipCeiling = DBToLinear(c.hardClipCeilingDB)
+	c.headroomCeiling = DBToLinear(-c.headroomMarginDB)
+	c.updateFloat32Params()
 }
 
 // processSampleInternal processes a single sample (internal DSP logic, called by ProcessBlock).
 // Assumes caller holds lock or is single-threaded context (tests).
 func (c *SoftKneeCompressor) processSampleInternal(sample float32, channel int) (float32, float64) {
-	if c.bypass {
+	if channel < 0 || channel >= c.channels {
 		return sample, 1.0
 	}
 
+	return c.processSampleKeyed(sample, c.detectorSignal(float64(sample), channel), channel)
+}
+
+// processSampleKeyed processes a single sample whose envelope is driven by the
+// given key signal instead of the sample itself (internal, assumes lock held).
+func (c *SoftKneeCompressor) processSampleKeyed(sample float32, key float64, channel int) (float32, float64) {
+	output, gain := c.processSampleKeyed64(float64(sample), key, channel)
+
+	return float32(output), gain
+}
+
+// processSampleKeyed64 is the float64 core of processSampleKeyed shared by the
+// float32 and float64 APIs (internal, assumes lock held).
+func (c *SoftKneeCompressor) processSampleKeyed64(sample, key float64, channel int) (float64, float64) {
 	if channel < 0 || channel >= c.channels {
 		return sample, 1.0
 	}
 
-	inputLevel := math.Abs(float64(sample))
+	// The audio runs through the lookahead delay even in bypass so toggling
+	// it doesn't shift the signal in time; the key stays undelayed
+	sample = c.delayLookahead(sample, channel)
+
+	if c.bypass {
+		return c.applyBypass(sample, channel), 1.0
+	}
+
+	if c.sleeping(key, channel) {
+		return c.applyGain(sample, key, 1.0, channel), 1.0
+	}
+
+	c.advanceDetector(key, channel)
+
+	gain := c.intervalGain(channel)
+	if math.IsNaN(gain) {
+		gain = 1.0
+	}
+
+	gain = c.preserveTransient(gain, channel)
+
+	return c.applyGain(sample, key, gain, channel), gain
+}
+
+// advanceDetector runs the threshold smoothing, release classification,
+// transient detector and envelope follower for one key sample (internal,
+// assumes lock held).
+func (c *SoftKneeCompressor) advanceDetector(key float64, channel int) {
+	inputLevel := math.Abs(key)
+
+	c.advanceThresholdSmoothing(channel)
+
+	if c.freeze {
+		return
+	}
+
+	if c.autoRelease {
+		c.classifyRelease(inputLevel, channel)
+	}
+
+	c.detectTransient(inputLevel, channel)
+
+	if c.precision == Float32 {
+		c.updateEnvelope32(inputLevel, channel)
+	} else {
+		c.updateEnvelope(inputLevel, channel)
+	}
+}
+
+// applyGain applies the gain, makeup, output gain and the output stage
+// (delta, polarity, listen, clip, mutes) to one sample (internal, assumes lock
+// held).
+func (c *SoftKneeCompressor) applyGain(sample, key, gain float64, channel int) float64 {
+	makeup := c.makeupTarget(channel, sample*gain)
+
+	// Settings made before the first sample (or since a reset) apply instantly
+	first := c.samplesProcessed[channel] == 0
+	if first {
+		c.smoothedMakeup[channel] = makeup
+		c.smoothedOutput[channel] = c.outputGainLin
+	} else {
+		c.smoothedMakeup[channel] += (makeup - c.smoothedMakeup[channel]) * c.makeupSmoothingCoeff
+		c.smoothedOutput[channel] += (c.outputGainLin - c.smoothedOutput[channel]) * c.makeupSmoothingCoeff
+	}
+
+	total := gain * c.smoothedMakeup[channel] * c.smoothedOutput[channel] * c.startupFadeGain(channel)
+	if first {
+		c.slewedGain[channel] = total
+	}
+
+	output := sample * c.slewGain(total, channel)
+	output = c.applyWarmth(output, channel)
+
+	if c.deltaMonitor {
+		output = sample * (1.0 - gain)
+	}
+
+	if c.invertPolarity[channel] {
+		output = -output
+	}
+
+	if c.sidechainListen {
+		output = key
+	}
+
+	output = c.limitOutput(output, channel)
+
+	if c.hardClip {
+		output = math.Max(-c.hardClipCeiling, math.Min(c.hardClipCeiling, output))
+	}
+
+	return c.applyMutes(c.applyDim(output, channel), channel)
+}
+
+// applyMutes silences the output of channels muted by a solo, and of every
+// channel while the NaN safety mute is active (internal, assumes lock held).
+func (c *SoftKneeCompressor) applyMutes(output float64, channel int) float64 {
+	if c.solo >= 0 && channel != c.solo {
+		return 0
+	}
+
+	if c.nanMuted != 0 {
+		return 0
+	}
+
+	return output
+}
+
+// sanitizeSample replaces NaN and infinite samples with silence.
+func sanitizeSample[S float32 | float64](sample S) S {
+	if math.IsNaN(float64(sample)) || math.IsInf(float64(sample), 0) {
+		return 0
+	}
+
+	return sample
+}
+
+// updateEnvelope runs the attack/release peak follower for one detection level.
+func (c *SoftKneeCompressor) updateEnvelope(inputLevel float64, channel int) {
 	if math.IsNaN(inputLevel) {
 		inputLevel = 0 // Sanitize
 	}
 
+	attack, release := c.envelopeFactors(channel)
+
 	if inputLevel > c.peak[channel] {
-		c.peak[channel] += (inputLevel - c.peak[channel]) * c.attackFactor
+		c.peak[channel] += (inputLevel - c.peak[channel]) * attack
 	} else {
-		c.peak[channel] = inputLevel + (c.peak[channel]-inputLevel)*c.releaseFactor
+		c.peak[channel] = inputLevel + (c.peak[channel]-inputLevel)*release
 	}
 
 	if math.IsNaN(c.peak[channel]) {
 		c.peak[channel] = 0 // Safety reset
 	}
+}
 
-	gain := c.calculateGain(c.peak[channel])
-	if math.IsNaN(gain) {
-		gain = 1.0
+// startupFadeGain advances the channel's running sample counter and returns the
+// fade-in multiplier for the current sample.
+func (c *SoftKneeCompressor) startupFadeGain(channel int) float64 {
+	position := float64(c.samplesProcessed[channel])
+	c.samplesProcessed[channel]++
+
+	if position >= c.fadeSamples {
+		return 1.0
+	}
+
+	return (position + 1.0) / (c.fadeSamples + 1.0)
+}
+
+// intervalGain returns the gain for the current sample, recomputing it every
+// gainInterval samples and interpolating linearly in between.
+func (c *SoftKneeCompressor) intervalGain(channel int) float64 {
+	if c.gainInterval <= 1 {
+		return c.computeGain(c.peak[channel])
 	}
 
-	output := float32(float64(sample) * gain * c.makeupGainLin)
+	if c.gainCountdown[channel] <= 0 {
+		target := c.computeGain(c.peak[channel])
+		if math.IsNaN(target) {
+			target = 1.0
+		}
+
+		c.gainStep[channel] = (target - c.currentGain[channel]) / float64(c.gainInterval)
+		c.gainCountdown[channel] = c.gainInterval
+	}
+
+	c.gainCountdown[channel]--
+	c.currentGain[channel] += c.gainStep[channel]
+
+	return c.currentGain[channel]
+}
+
+// computeGain runs the user-supplied gain computer if one is installed, then a
+// loaded transfer curve, or the built-in soft-knee curve otherwise.
+func (c *SoftKneeCompressor) computeGain(peakLevel float64) float64 {
+	var gain float64
+
+	switch {
+	case c.gainComputer != nil:
+		gain = c.gainComputer(peakLevel)
+	case c.transferCurve != nil:
+		gain = c.transferCurve.gain(peakLevel)
+	case c.precision == Float32:
+		gain = float64(c.calculateGain32(float32(peakLevel)))
+	default:
+		gain = c.calculateGain(peakLevel)
+	}
+
+	if c.rangeDB > 0.0 {
+		return math.Max(gain, c.rangeGain)
+	}
 
-	return output, gain
+	return gain
 }
 
 // calculateGain computes the gain multiplier.
@@ -415,12 +1545,12 @@ func (c *SoftKneeCompressor) calculateGain(peakLevel float64) float64 {
 	}
 
 	if peakLevel >= c.kneeUpper {
-		return FastPow(c.threshold/peakLevel, 1.0-1.0/c.ratio)
+		return c.curvePow(c.threshold/peakLevel, 1.0-1.0/c.ratio)
 	}
 
 	kneePos := (peakLevel - c.kneeLower) / c.kneeWidth
 	smoothFactor := kneePos * kneePos * (3.0 - 2.0*kneePos)
-	compressedGain := FastPow(c.threshold/c.kneeUpper, 1.0-1.0/c.ratio)
+	compressedGain := c.curvePow(c.threshold/c.kneeUpper, 1.0-1.0/c.ratio)
 
 	return 1.0 + (compressedGain-1.0)*smoothFactor
 }
@@ -1,6 +1,8 @@
 package dsp
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"testing"
 )
@@ -342,6 +344,80 @@ func TestPeakDetectorAttack(t *testing.T) {
 	}
 }
 
+// TestAttackTimeAcrossSampleRates verifies the attack time holds in
+// milliseconds at any negotiated rate: the envelope reaches half the level of
+// a step after the attack time and 63% after one time constant (attack / ln 2).
+func TestAttackTimeAcrossSampleRates(t *testing.T) {
+	t.Parallel()
+
+	const attackMs = 10.0
+
+	for _, rate := range []float64{44100.0, 48000.0, 96000.0} {
+		// Created at the default rate and switched like the PipeWire callback does
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetAttack(attackMs)
+		comp.SetSampleRate(rate)
+
+		half, timeConstant := 0, 0
+
+		for n := 1; timeConstant == 0 && n < int(rate); n++ {
+			comp.ProcessSample(1.0, 0)
+
+			if half == 0 && comp.peak[0] >= 0.5 {
+				half = n
+			}
+
+			if comp.peak[0] >= 1.0-1.0/math.E {
+				timeConstant = n
+			}
+		}
+
+		if want := attackMs * 0.001 * rate; math.Abs(float64(half)-want) > 1.0 {
+			t.Errorf("%.0f Hz: envelope reached 50%% after %d samples, want %.0f", rate, half, want)
+		}
+
+		if want := attackMs * 0.001 * rate / math.Ln2; math.Abs(float64(timeConstant)-want) > 1.0 {
+			t.Errorf("%.0f Hz: envelope reached 63%% after %d samples, want %.0f", rate, timeConstant, want)
+		}
+	}
+}
+
+// TestSampleRateChangeWhileMetering verifies switching the sample rate from
+// the audio thread doesn't race with meter reads; run with -race.
+func TestSampleRateChangeWhileMetering(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	in := make([]float32, 256)
+	out := make([]float32, len(in))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := range 200 {
+			comp.SetSampleRate([]float64{44100.0, 96000.0}[i%2])
+			comp.ProcessBlock(in, out, i%2)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			if got := comp.GetMeters().SampleRate; got != 96000.0 {
+				t.Errorf("Sample rate %.0f after the last switch, want 96000", got)
+			}
+
+			return
+		default:
+			if rate := comp.GetMeters().SampleRate; rate != 44100.0 && rate != 96000.0 && rate != 48000.0 {
+				t.Fatalf("Meters report an unexpected sample rate %.0f", rate)
+			}
+		}
+	}
+}
+
 // TestPeakDetectorRelease verifies release decay.
 func TestPeakDetectorRelease(t *testing.T) {
 	t.Parallel()
@@ -470,6 +546,650 @@ func TestInvalidChannel(t *testing.T) {
 	}
 }
 
+// TestAverageGainReduction verifies the slow average stays below the peak block reduction
+// when the signal only contains intermittent loud bursts.
+func TestAverageGainReduction(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(1.0)
+	comp.SetRelease(10.0)
+
+	const blockSize = 512
+
+	in := make([]float32, blockSize)
+	out := make([]float32, blockSize)
+	peakGR := 0.0
+
+	for block := range 200 {
+		level := float32(0.01)
+		if block%10 == 0 {
+			level = 0.9
+		}
+
+		for i := range in {
+			in[i] = level
+		}
+
+		comp.ProcessBlock(in, out, 0)
+
+		blockGR := -LinearToDB(comp.GetMeters().GainReductionL)
+		if blockGR > peakGR {
+			peakGR = blockGR
+		}
+	}
+
+	avgGR := comp.AverageGainReductionDB(0)
+
+	if avgGR <= 0.0 {
+		t.Errorf("Average gain reduction should be positive after bursts, got %f dB", avgGR)
+	}
+
+	if avgGR >= peakGR {
+		t.Errorf("Average gain reduction %f dB should be lower than peak %f dB", avgGR, peakGR)
+	}
+
+	if comp.GetMeters().AverageGainReductionL != avgGR {
+		t.Error("MeterStats should expose the average gain reduction")
+	}
+
+	if comp.AverageGainReductionDB(5) != 0.0 {
+		t.Error("Invalid channel should report no average gain reduction")
+	}
+}
+
+// TestStartupFade verifies the output fades in after a reset and reaches full level
+// once the configured fade time has elapsed.
+func TestStartupFade(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetMakeupGain(0.0)
+	comp.SetStartupFade(10.0) // 480 samples at 48 kHz
+	comp.Reset()
+
+	input := float32(0.01) // Well below threshold, unity gain
+	fadeSamples := 480
+
+	outputs := make([]float32, fadeSamples+10)
+	for i := range outputs {
+		outputs[i] = comp.ProcessSample(input, 0)
+	}
+
+	if outputs[0] > input*0.01 {
+		t.Errorf("First sample should be strongly attenuated: got %f", outputs[0])
+	}
+
+	if outputs[fadeSamples/2] < input*0.4 || outputs[fadeSamples/2] > input*0.6 {
+		t.Errorf("Fade midpoint should be around half level: got %f", outputs[fadeSamples/2])
+	}
+
+	for i := 1; i < fadeSamples; i++ {
+		if outputs[i] < outputs[i-1] {
+			t.Fatalf("Fade envelope should rise monotonically: sample %d %f < %f", i, outputs[i], outputs[i-1])
+		}
+	}
+
+	if math.Abs(float64(outputs[fadeSamples+5]-input)) > 1e-6 {
+		t.Errorf("Output should reach full level after the fade: got %f", outputs[fadeSamples+5])
+	}
+
+	// Channel 1 has its own counter and still starts faded
+	if out := comp.ProcessSample(input, 1); out > input*0.01 {
+		t.Errorf("Channel 1 should start its own fade: got %f", out)
+	}
+}
+
+// TestPolarityInversion verifies inverting one channel yields an anti-phase output
+// with unchanged levels.
+func TestPolarityInversion(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+
+	err := comp.SetPolarity(1, true)
+	if err != nil {
+		t.Fatalf("SetPolarity failed: %v", err)
+	}
+
+	if !comp.GetPolarity(1) || comp.GetPolarity(0) {
+		t.Fatal("Only channel 1 should report inverted polarity")
+	}
+
+	const frames = 2048
+
+	in := make([]float32, frames*2)
+	for i := range frames {
+		sample := float32(0.5 * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+		in[i*2] = sample
+		in[i*2+1] = sample
+	}
+
+	out := make([]float32, len(in))
+	comp.ProcessFrames(in, out)
+
+	for i := range frames {
+		if out[i*2] != -out[i*2+1] {
+			t.Fatalf("Frame %d should be anti-phase: L %f, R %f", i, out[i*2], out[i*2+1])
+		}
+	}
+
+	if err := comp.SetPolarity(2, true); err == nil {
+		t.Error("SetPolarity should reject an invalid channel")
+	}
+}
+
+// TestCustomGainComputer verifies a user-supplied gain computer replaces the built-in curve.
+func TestCustomGainComputer(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(0.1)
+	comp.SetMakeupGain(6.0)
+	comp.SetStartupFade(0.0)
+	comp.SetGainComputer(func(float64) float64 { return 1.0 })
+
+	makeup := DBToLinear(6.0)
+	input := float32(0.9) // Far above threshold
+
+	for range 1000 {
+		output := comp.ProcessSample(input, 0)
+
+		expected := float32(float64(input) * makeup)
+		if math.Abs(float64(output-expected)) > 1e-6 {
+			t.Fatalf("Pass-through gain computer should only apply makeup: expected %f, got %f", expected, output)
+		}
+	}
+
+	// Removing the hook restores compression
+	comp.SetGainComputer(nil)
+
+	var output float32
+	for range 1000 {
+		output = comp.ProcessSample(input, 0)
+	}
+
+	if float64(output) >= float64(input)*makeup*0.9 {
+		t.Errorf("Built-in gain computer should compress again, got %f", output)
+	}
+}
+
+// TestGainUpdateInterval verifies interpolated control-rate gain stays close to the
+// per-sample gain on a slowly varying signal.
+func TestGainUpdateInterval(t *testing.T) {
+	t.Parallel()
+
+	full := NewSoftKneeCompressor(48000.0, 2)
+	full.SetMakeupGain(0.0)
+
+	decimated := NewSoftKneeCompressor(48000.0, 2)
+	decimated.SetMakeupGain(0.0)
+	decimated.SetGainUpdateInterval(8)
+
+	if decimated.GetGainUpdateInterval() != 8 {
+		t.Fatalf("Expected interval 8, got %d", decimated.GetGainUpdateInterval())
+	}
+
+	const frames = 48000
+
+	maxDiff := 0.0
+
+	for i := range frames {
+		// 1 kHz tone with a slow level sweep from -40 dBFS to -6 dBFS
+		level := DBToLinear(-40.0 + 34.0*float64(i)/frames)
+		sample := float32(level * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+
+		ref := full.ProcessSample(sample, 0)
+		out := decimated.ProcessSample(sample, 0)
+
+		maxDiff = math.Max(maxDiff, math.Abs(float64(out-ref)))
+	}
+
+	// Signal peaks at 0.5, so this bounds the deviation to about 2%
+	if maxDiff > 0.01 {
+		t.Errorf("Interpolated gain deviates too much from per-sample gain: max diff %f", maxDiff)
+	}
+}
+
+// BenchmarkGainUpdateInterval compares per-sample gain computation with n=8.
+func BenchmarkGainUpdateInterval(b *testing.B) {
+	for _, interval := range []int{1, 8} {
+		b.Run(fmt.Sprintf("n=%d", interval), func(b *testing.B) {
+			comp := NewSoftKneeCompressor(48000.0, 2)
+			comp.SetGainUpdateInterval(interval)
+
+			in := make([]float32, 1024)
+			for i := range in {
+				in[i] = float32(0.5 * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+			}
+
+			out := make([]float32, len(in))
+
+			b.ResetTimer()
+
+			for range b.N {
+				comp.ProcessBlock(in, out, 0)
+			}
+		})
+	}
+}
+
+// steadyStateEnvelope runs a sustained 100 Hz tone until steady state and returns
+// the maximum envelope and its relative peak-to-peak ripple over the final cycles.
+func steadyStateEnvelope(comp *SoftKneeCompressor) (float64, float64) {
+	const (
+		sampleRate = 48000.0
+		settle     = 5 * 48000 // 10 attack time constants
+		window     = 4800      // 10 cycles of 100 Hz
+	)
+
+	minPeak, maxPeak := math.Inf(1), 0.0
+
+	for i := range settle + window {
+		comp.ProcessSample(float32(0.5*math.Sin(2.0*math.Pi*100.0*float64(i)/sampleRate)), 0)
+
+		if i >= settle {
+			minPeak = math.Min(minPeak, comp.peak[0])
+			maxPeak = math.Max(maxPeak, comp.peak[0])
+		}
+	}
+
+	return maxPeak, (maxPeak - minPeak) / maxPeak
+}
+
+// TestSlowAttackFastRelease characterizes the steady state for attack=500ms and
+// release=5ms and verifies stable mode keeps the envelope from oscillating.
+func TestSlowAttackFastRelease(t *testing.T) {
+	t.Parallel()
+
+	unstable := NewSoftKneeCompressor(48000.0, 2)
+	unstable.SetAttack(500.0)
+	unstable.SetRelease(5.0)
+
+	if !unstable.AttackExceedsRelease() {
+		t.Fatal("Attack 500ms / release 5ms should be reported as attack exceeding release")
+	}
+
+	stable := NewSoftKneeCompressor(48000.0, 2)
+	stable.SetAttack(500.0)
+	stable.SetRelease(5.0)
+	stable.SetStableMode(true)
+
+	if stable.releaseMs != 5.0 {
+		t.Errorf("Stable mode should keep the user release time, got %f", stable.releaseMs)
+	}
+
+	unstablePeak, unstableRipple := steadyStateEnvelope(unstable)
+	stablePeak, stableRipple := steadyStateEnvelope(stable)
+
+	t.Logf("attack 500ms / release 5ms: envelope %.4f (ripple %.3f), stable mode: %.4f (ripple %.3f)",
+		unstablePeak, unstableRipple, stablePeak, stableRipple)
+
+	// Without stable mode the follower settles far below the tone's peak (0.5),
+	// so the compressor under-detects sustained material
+	if unstablePeak > 0.1 {
+		t.Errorf("Expected the envelope to settle well below the signal peak, got %.4f", unstablePeak)
+	}
+
+	// Neither setting may oscillate once settled
+	if unstableRipple > 0.1 || stableRipple > 0.1 {
+		t.Errorf("Envelope should not oscillate: ripple %.3f (unstable), %.3f (stable)",
+			unstableRipple, stableRipple)
+	}
+
+	if stablePeak < 3.0*unstablePeak {
+		t.Errorf("Stable mode should track the tone much closer: %.4f vs %.4f", stablePeak, unstablePeak)
+	}
+}
+
+// TestChangingBlockSizes verifies a quantum changing between calls, with the
+// buffered features enabled, gives the same output as fixed-size blocks and
+// allocates nothing once every size has been seen.
+//
+//nolint:paralleltest // AllocsPerRun counts allocations process-wide
+func TestChangingBlockSizes(t *testing.T) {
+	sizes := []int{256, 1024, 512}
+
+	newComp := func() *SoftKneeCompressor {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetThreshold(-20.0)
+		comp.SetLookahead(5.0)
+		comp.SetLimiterLookahead(1.0)
+		comp.SetGainReductionSegments(16)
+
+		return comp
+	}
+
+	in := make([]float32, 20*(256+1024+512))
+	for i := range in {
+		envelope := 0.2 + 0.7*float64((i/3000)%2)
+		in[i] = float32(envelope * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+	}
+
+	reference := make([]float32, len(in))
+	refComp := newComp()
+
+	for start := 0; start < len(in); start += 512 {
+		refComp.ProcessBlock(in[start:start+512], reference[start:start+512], 0)
+	}
+
+	out := make([]float32, len(in))
+	comp := newComp()
+
+	for start, i := 0, 0; start < len(in); i++ {
+		size := sizes[i%len(sizes)]
+		comp.ProcessBlock(in[start:start+size], out[start:start+size], 0)
+		start += size
+	}
+
+	for i := range out {
+		if out[i] != reference[i] {
+			t.Fatalf("Sample %d: %f with changing block sizes, %f with fixed ones", i, out[i], reference[i])
+		}
+	}
+
+	// A quantum shorter than the segment count still fills every segment
+	loud := []float32{0.9, -0.9, 0.9, -0.9}
+	comp.ProcessBlock(loud, make([]float32, len(loud)), 0)
+
+	for i, grDB := range comp.GainReductionSegments(0) {
+		if grDB <= 0.0 {
+			t.Errorf("Segment %d of a 4-sample block is empty", i)
+		}
+	}
+
+	comp.SetBlockGainInterpolation(true)
+
+	buffers := make([][]float32, len(sizes))
+	for i, size := range sizes {
+		buffers[i] = make([]float32, size)
+	}
+
+	run := func() {
+		for _, buf := range buffers {
+			comp.ProcessBlock(buf, buf, 0)
+		}
+	}
+
+	run() // Warm-up: grows the block key scratch to the largest size
+
+	if allocs := testing.AllocsPerRun(10, run); allocs != 0 {
+		t.Errorf("Expected no allocations with changing block sizes, got %.1f per cycle", allocs)
+	}
+}
+
+// TestShortBuffers verifies empty and sub-frame buffers are a graceful no-op.
+func TestShortBuffers(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+
+	for _, size := range []int{0, 1, 3} {
+		in := make([]float32, size)
+		out := make([]float32, size)
+
+		for i := range in {
+			in[i] = 0.5
+			out[i] = -1.0
+		}
+
+		comp.ProcessFrames(in, out)
+
+		for i := range out {
+			if out[i] != -1.0 {
+				t.Errorf("ProcessFrames should ignore a %d-sample stereo buffer", size)
+			}
+		}
+	}
+
+	if blocks := comp.GetMeters().Blocks; blocks != 0 {
+		t.Errorf("Ignored buffers should not count as processed blocks, got %d", blocks)
+	}
+
+	// ProcessBlock handles a single sample but ignores an empty block
+	comp.ProcessBlock([]float32{}, []float32{}, 0)
+
+	if blocks := comp.GetMeters().Blocks; blocks != 0 {
+		t.Errorf("Empty block should not count as processed, got %d", blocks)
+	}
+
+	out := []float32{0}
+	comp.ProcessBlock([]float32{0.5}, out, 0)
+
+	if out[0] == 0 {
+		t.Error("Single-sample block should be processed")
+	}
+
+	// Mismatched lengths are ignored
+	comp.ProcessBlock([]float32{0.5}, []float32{}, 0)
+	comp.ProcessFrames([]float32{0.5, 0.5}, []float32{0})
+
+	// A compressor without channels must not panic
+	empty := NewSoftKneeCompressor(48000.0, 0)
+	empty.ProcessFrames([]float32{0.5}, []float32{0})
+	empty.ProcessBlock([]float32{0.5}, []float32{0}, 0)
+}
+
+// TestOutputGainWithAutoMakeup verifies output gain is an independent trim on top of
+// the auto-makeup compensation.
+func TestOutputGainWithAutoMakeup(t *testing.T) {
+	t.Parallel()
+
+	reference := NewSoftKneeCompressor(48000.0, 2)
+	trimmed := NewSoftKneeCompressor(48000.0, 2)
+	trimmed.SetOutputGain(6.0)
+
+	if reference.GetMakeupGain() != trimmed.GetMakeupGain() {
+		t.Errorf("Output gain must not change the auto makeup: %f vs %f",
+			reference.GetMakeupGain(), trimmed.GetMakeupGain())
+	}
+
+	if trimmed.GetOutputGain() != 6.0 {
+		t.Errorf("Expected output gain 6.0 dB, got %f", trimmed.GetOutputGain())
+	}
+
+	// Changing threshold keeps both orthogonal
+	trimmed.SetThreshold(-30.0)
+	reference.SetThreshold(-30.0)
+
+	input := float32(0.5)
+
+	for range 2000 {
+		ref := reference.ProcessSample(input, 0)
+		out := trimmed.ProcessSample(input, 0)
+
+		diffDB := 20.0 * math.Log10(float64(out)/float64(ref))
+		if math.Abs(diffDB-6.0) > 1e-3 {
+			t.Fatalf("Output should be exactly 6 dB above auto makeup level, got %f dB", diffDB)
+		}
+	}
+}
+
+// TestFreezeHoldsGain verifies a frozen envelope keeps the gain reduced through
+// silence until freeze is disabled.
+func TestFreezeHoldsGain(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(1.0)
+	comp.SetRelease(10.0)
+	comp.SetMakeupGain(0.0)
+
+	for range 4800 {
+		comp.ProcessSample(0.9, 0)
+	}
+
+	comp.SetFreeze(true)
+
+	if !comp.GetFreeze() {
+		t.Fatal("Freeze should be enabled")
+	}
+
+	frozenPeak := comp.peak[0]
+
+	// 100 ms of silence is ten release times
+	for range 4800 {
+		comp.ProcessSample(0.0, 0)
+	}
+
+	if comp.peak[0] != frozenPeak {
+		t.Errorf("Frozen envelope should not release: before %f, after %f", frozenPeak, comp.peak[0])
+	}
+
+	// A quiet probe is still attenuated by the held gain
+	probe := float32(0.01)
+	if out := comp.ProcessSample(probe, 0); out >= probe*0.9 {
+		t.Errorf("Held gain should still reduce the signal: in %f, out %f", probe, out)
+	}
+
+	comp.SetFreeze(false)
+
+	for range 4800 {
+		comp.ProcessSample(0.0, 0)
+	}
+
+	if out := comp.ProcessSample(probe, 0); math.Abs(float64(out-probe)) > 1e-4 {
+		t.Errorf("Gain should recover once freeze is disabled: in %f, out %f", probe, out)
+	}
+}
+
+// TestHardClipCatchesTransient verifies the hard clipper holds the ceiling on a
+// transient the envelope is too slow to catch.
+func TestHardClipCatchesTransient(t *testing.T) {
+	t.Parallel()
+
+	const ceilingDB = -1.0
+
+	ceiling := DBToLinear(ceilingDB)
+
+	spike := func(hardClip bool) float64 {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetThreshold(-20.0)
+		comp.SetRatio(4.0)
+		comp.SetAttack(50.0)
+		comp.SetHardClipCeiling(ceilingDB)
+		comp.SetHardClip(hardClip)
+
+		for range 4800 {
+			comp.ProcessSample(0.01, 0)
+		}
+
+		// A single full-scale sample after near-silence, with +15 dB auto makeup
+		return math.Abs(float64(comp.ProcessSample(1.0, 0)))
+	}
+
+	if out := spike(false); out <= ceiling {
+		t.Fatalf("Transient should slip past the envelope without the clipper, got %f", out)
+	}
+
+	if out := spike(true); out > ceiling+1e-6 {
+		t.Errorf("Hard clipper should hold the ceiling %f, got %f", ceiling, out)
+	}
+}
+
+// TestHardClipCeilingClamp verifies the ceiling can't be set above 0 dBFS.
+func TestHardClipCeilingClamp(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetHardClipCeiling(3.0)
+
+	if got := comp.GetHardClipCeiling(); got != 0.0 {
+		t.Errorf("Ceiling should be clamped to 0 dBFS, got %f", got)
+	}
+}
+
+// TestSettersRejectNonFinite verifies NaN and Inf leave every parameter and the
+// derived coefficients unchanged.
+func TestSettersRejectNonFinite(t *testing.T) {
+	t.Parallel()
+
+	setters := []struct {
+		name string
+		set  func(c *SoftKneeCompressor, v float64)
+		get  func(c *SoftKneeCompressor) float64
+	}{
+		{"Threshold", (*SoftKneeCompressor).SetThreshold, (*SoftKneeCompressor).GetThreshold},
+		{"Ratio", (*SoftKneeCompressor).SetRatio, (*SoftKneeCompressor).GetRatio},
+		{"Knee", (*SoftKneeCompressor).SetKnee, (*SoftKneeCompressor).GetKnee},
+		{"Attack", (*SoftKneeCompressor).SetAttack, (*SoftKneeCompressor).GetAttack},
+		{"Release", (*SoftKneeCompressor).SetRelease, (*SoftKneeCompressor).GetRelease},
+		{"MakeupGain", (*SoftKneeCompressor).SetMakeupGain, (*SoftKneeCompressor).GetMakeupGain},
+		{"OutputGain", (*SoftKneeCompressor).SetOutputGain, (*SoftKneeCompressor).GetOutputGain},
+		{"HardClipCeiling", (*SoftKneeCompressor).SetHardClipCeiling, (*SoftKneeCompressor).GetHardClipCeiling},
+		{"StartupFade", (*SoftKneeCompressor).SetStartupFade, (*SoftKneeCompressor).GetStartupFade},
+		{"SidechainTilt", (*SoftKneeCompressor).SetSidechainTilt, (*SoftKneeCompressor).GetSidechainTilt},
+		{
+			"SampleRate", (*SoftKneeCompressor).SetSampleRate,
+			func(c *SoftKneeCompressor) float64 { return c.GetMeters().SampleRate },
+		},
+		{
+			"GRMeterAttack",
+			func(c *SoftKneeCompressor, v float64) { c.SetGRMeterBallistics(v, 300.0) },
+			func(c *SoftKneeCompressor) float64 { attack, _ := c.GetGRMeterBallistics(); return attack },
+		},
+	}
+
+	for _, setter := range setters {
+		for _, bad := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+			comp := NewSoftKneeCompressor(48000.0, 2)
+			before := setter.get(comp)
+
+			setter.set(comp, bad)
+
+			if got := setter.get(comp); got != before {
+				t.Errorf("%s(%v): parameter changed from %f to %f", setter.name, bad, before, got)
+			}
+
+			derived := []float64{
+				comp.threshold, comp.kneeLower, comp.kneeUpper, comp.kneeWidth, comp.slopeRecip,
+				comp.makeupGainLin, comp.outputGainLin, comp.hardClipCeiling, comp.fadeSamples,
+				comp.attackFactor, comp.releaseFactor, comp.tiltCoeff, comp.tiltLowGain, comp.tiltHighGain,
+			}
+			for i, value := range derived {
+				if !isFinite(value) {
+					t.Errorf("%s(%v): derived coefficient %d is not finite: %f", setter.name, bad, i, value)
+				}
+			}
+		}
+	}
+}
+
+// TestGetCoefficientsSnapshot verifies the coefficients are read as a consistent
+// snapshot while parameters change concurrently.
+func TestGetCoefficientsSnapshot(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetKnee(6.0)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := range 1000 {
+			comp.SetThreshold(-10.0 - float64(i%2)*20.0)
+		}
+	}()
+
+	// With a 6 dB knee the lower boundary is always 3 dB below the threshold
+	expected := DBToLinear(-3.0)
+
+	for range 1000 {
+		coeffs := comp.GetCoefficients()
+		if ratio := coeffs.KneeLower / coeffs.Threshold; math.Abs(ratio-expected) > 1e-9 {
+			t.Fatalf("Inconsistent snapshot: knee lower %f, threshold %f", coeffs.KneeLower, coeffs.Threshold)
+		}
+	}
+
+	<-done
+}
+
 // BenchmarkProcessSample benchmarks single sample processing.
 func BenchmarkProcessSample(b *testing.B) {
 	comp := NewSoftKneeCompressor(48000.0, 2)
@@ -501,3 +1221,153 @@ func BenchmarkProcessStereo(b *testing.B) {
 		comp.ProcessSample(sampleR, 1)
 	}
 }
+
+// TestSolo verifies soloing channel 1 silences every other channel while
+// channel 1 is processed exactly as without the solo.
+func TestSolo(t *testing.T) {
+	t.Parallel()
+
+	const (
+		channels = 4
+		frames   = 4800
+	)
+
+	in := make([]float32, frames*channels)
+	for i := range in {
+		in[i] = float32(0.8 * math.Sin(2.0*math.Pi*440.0*float64(i/channels)/48000.0))
+	}
+
+	reference := NewSoftKneeCompressor(48000.0, channels)
+	soloed := NewSoftKneeCompressor(48000.0, channels)
+
+	if err := soloed.SetSolo(1); err != nil {
+		t.Fatalf("SetSolo(1) failed: %v", err)
+	}
+
+	want := make([]float32, len(in))
+	got := make([]float32, len(in))
+
+	reference.ProcessFrames(append([]float32(nil), in...), want)
+	soloed.ProcessFrames(append([]float32(nil), in...), got)
+
+	for i := range got {
+		ch := i % channels
+		if ch == 1 && got[i] != want[i] {
+			t.Fatalf("Soloed channel sample %d = %f, want %f", i, got[i], want[i])
+		}
+
+		if ch != 1 && got[i] != 0 {
+			t.Fatalf("Muted channel %d sample %d = %f, want silence", ch, i, got[i])
+		}
+	}
+
+	if meters, _ := soloed.GetChannelMeters(0); meters.GainReduction >= 1.0 {
+		t.Error("Muted channels should keep processing and metering gain reduction")
+	}
+
+	if err := soloed.SetSolo(channels); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Expected ErrInvalidChannel for channel %d, got %v", channels, err)
+	}
+
+	if err := soloed.SetSolo(-1); err != nil || soloed.GetSolo() != -1 {
+		t.Errorf("SetSolo(-1) should disable the solo, got %d, %v", soloed.GetSolo(), err)
+	}
+}
+
+// TestMakeupBypass verifies an above-threshold signal is still compressed but
+// not boosted back while the makeup is bypassed, and passes unchanged in full bypass.
+func TestMakeupBypass(t *testing.T) {
+	t.Parallel()
+
+	settle := func(configure func(comp *SoftKneeCompressor)) float64 {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetThreshold(-20.0)
+		comp.SetRatio(4.0)
+		comp.SetStartupFade(0.0)
+		configure(comp)
+
+		in := make([]float32, 4800)
+		for i := range in {
+			in[i] = 0.5
+		}
+
+		out := make([]float32, len(in))
+		for range 10 {
+			comp.ProcessBlock(in, out, 0)
+		}
+
+		return float64(out[len(out)-1])
+	}
+
+	withMakeup := settle(func(*SoftKneeCompressor) {})
+	makeupBypassed := settle(func(comp *SoftKneeCompressor) { comp.SetMakeupBypass(true) })
+	bypassed := settle(func(comp *SoftKneeCompressor) { comp.SetBypass(true) })
+
+	if makeupBypassed >= 0.5 {
+		t.Errorf("Makeup bypass output %f should be compressed below the 0.5 input", makeupBypassed)
+	}
+
+	if withMakeup <= makeupBypassed*1.5 {
+		t.Errorf("Auto makeup output %f should be boosted well above %f", withMakeup, makeupBypassed)
+	}
+
+	if bypassed != 0.5 {
+		t.Errorf("Full bypass output %f, want the unchanged 0.5 input", bypassed)
+	}
+}
+
+// TestLowRatios verifies a ratio of exactly 1.0 passes audio bit-exactly in
+// both precisions, and that ratios just above 1.0 reduce correspondingly
+// little instead of showing divide-by-near-zero artifacts.
+func TestLowRatios(t *testing.T) {
+	t.Parallel()
+
+	in := make([]float32, 4800)
+	for i := range in {
+		in[i] = float32(0.9 * math.Sin(2.0*math.Pi*440.0*float64(i)/48000.0))
+	}
+
+	process := func(ratio float64, precision Precision) []float32 {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetPrecision(precision)
+		comp.SetThreshold(-30.0)
+		comp.SetRatio(ratio)
+		comp.SetMakeupGain(0.0)
+		comp.SetStartupFade(0.0)
+
+		out := make([]float32, len(in))
+		for range 5 {
+			comp.ProcessBlock(append([]float32(nil), in...), out, 0)
+		}
+
+		return out
+	}
+
+	for _, precision := range []Precision{Float64, Float32} {
+		out := process(1.0, precision)
+		for i := range out {
+			if out[i] != in[i] {
+				t.Fatalf("Precision %d: ratio 1.0 sample %d = %g, want bit-exact %g", precision, i, out[i], in[i])
+			}
+		}
+	}
+
+	// The envelope settles near the 0.9 peak, about 29 dB above the threshold
+	overshootDB := 20.0*math.Log10(0.9) + 30.0
+
+	for _, ratio := range []float64{1.0001, 1.001, 1.01, 1.5, 2.0} {
+		out := process(ratio, Float64)
+
+		peak := 0.0
+		for _, sample := range out[len(out)/2:] {
+			peak = math.Max(peak, math.Abs(float64(sample)))
+		}
+
+		reductionDB := 20.0 * math.Log10(0.9/peak)
+		wantDB := overshootDB * (1.0 - 1.0/ratio)
+
+		if math.IsNaN(reductionDB) || math.Abs(reductionDB-wantDB) > 0.05+0.05*wantDB {
+			t.Errorf("Ratio %g: reduction %.4f dB, want about %.4f dB", ratio, reductionDB, wantDB)
+		}
+	}
+}
@@ -7,6 +7,11 @@ const (
 	silenceThresholdDB = -144.0
 )
 
+// isFinite reports whether x is neither NaN nor infinite.
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
 // DBToLinear converts decibels to linear amplitude scale.
 // Uses the formula: linear = 10^(dB/20).
 //
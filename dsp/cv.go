@@ -0,0 +1,91 @@
+package dsp
+
+import "math"
+
+// Largest gain reduction in dB the CV output can be scaled to.
+const maxCVRangeDB = 60.0
+
+// SetGainReductionCV records the per-sample gain envelope of every block so
+// GainReductionCV can output it as a control voltage, e.g. to modulate other
+// effects in a modular setup. rangeDB is the gain reduction that maps to full
+// scale (clamped to 60 dB); 0 or less disables the recording.
+func (c *SoftKneeCompressor) SetGainReductionCV(rangeDB float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(rangeDB) {
+		return
+	}
+
+	c.cvRangeDB = math.Max(0.0, math.Min(maxCVRangeDB, rangeDB))
+
+	if c.cvRangeDB == 0.0 {
+		for ch := range c.gainEnvelopes {
+			c.gainEnvelopes[ch] = c.gainEnvelopes[ch][:0]
+		}
+	}
+}
+
+// GetGainReductionCV returns the gain reduction in dB mapped to full-scale CV,
+// or 0 if the CV output is disabled.
+func (c *SoftKneeCompressor) GetGainReductionCV() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.cvRangeDB
+}
+
+// GainToCV maps a linear gain multiplier to a unipolar control value: 0 without
+// gain reduction, rising linearly in dB to 1 at rangeDB of reduction and
+// clamped there. Gains above unity read 0.
+func GainToCV(gain, rangeDB float64) float64 {
+	if rangeDB <= 0.0 || gain >= 1.0 || math.IsNaN(gain) {
+		return 0.0
+	}
+
+	if gain <= 0.0 {
+		return 1.0
+	}
+
+	return math.Min(1.0, -20.0*math.Log10(gain)/rangeDB)
+}
+
+// GainReductionCV fills out with the CV of the last processed block, sample by
+// sample, following the deepest gain reduction over all channels. Samples past
+// the end of a channel's last block hold its final value; everything reads 0
+// while the CV output is disabled.
+func (c *SoftKneeCompressor) GainReductionCV(out []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range out {
+		gain := 1.0
+
+		for _, envelope := range c.gainEnvelopes {
+			if len(envelope) > 0 {
+				gain = math.Min(gain, envelope[min(i, len(envelope)-1)])
+			}
+		}
+
+		out[i] = float32(GainToCV(gain, c.cvRangeDB))
+	}
+}
+
+// recordGainEnvelope stores the gain of sample index out of total for the CV
+// output. The buffer only grows when a larger block arrives (internal, assumes
+// lock held).
+func (c *SoftKneeCompressor) recordGainEnvelope(channel, index, total int, gain float64) {
+	if c.cvRangeDB == 0.0 {
+		return
+	}
+
+	if index == 0 {
+		if cap(c.gainEnvelopes[channel]) < total {
+			c.gainEnvelopes[channel] = make([]float64, total)
+		}
+
+		c.gainEnvelopes[channel] = c.gainEnvelopes[channel][:total]
+	}
+
+	c.gainEnvelopes[channel][index] = gain
+}
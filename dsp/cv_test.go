@@ -0,0 +1,80 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestGainToCV verifies the gain multiplier maps linearly in dB onto 0..1.
+func TestGainToCV(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		gain    float64
+		rangeDB float64
+		want    float64
+	}{
+		{1.0, 24.0, 0.0},
+		{DBToLinear(-6.0), 24.0, 0.25},
+		{DBToLinear(-12.0), 24.0, 0.5},
+		{DBToLinear(-24.0), 24.0, 1.0},
+		{DBToLinear(-40.0), 24.0, 1.0},
+		{DBToLinear(-6.0), 12.0, 0.5},
+		{2.0, 24.0, 0.0},
+		{0.0, 24.0, 1.0},
+		{0.5, 0.0, 0.0},
+	}
+
+	for _, tt := range tests {
+		if got := GainToCV(tt.gain, tt.rangeDB); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("GainToCV(%f, %.0f) = %f, want %f", tt.gain, tt.rangeDB, got, tt.want)
+		}
+	}
+}
+
+// TestGainReductionCV verifies the CV follows the deepest reduction of the
+// last block over all channels and reads 0 while disabled.
+func TestGainReductionCV(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(8.0)
+
+	const frames = 480
+
+	loud := make([]float32, frames)
+	for i := range loud {
+		loud[i] = 0.9
+	}
+
+	cv := make([]float32, frames)
+
+	comp.ProcessBlock(loud, make([]float32, frames), 0)
+	comp.GainReductionCV(cv)
+
+	if cv[frames-1] != 0 {
+		t.Errorf("CV %f while disabled, want 0", cv[frames-1])
+	}
+
+	comp.SetGainReductionCV(24.0)
+
+	out := make([]float32, frames)
+	comp.ProcessBlock(loud, out, 0)
+	comp.ProcessBlock(make([]float32, frames), make([]float32, frames), 1)
+	comp.GainReductionCV(cv)
+
+	// The quiet right channel doesn't mask the left's reduction
+	gain := float64(out[frames-1]) / float64(loud[frames-1]) / DBToLinear(comp.GetMakeupGain())
+	if want := GainToCV(gain, 24.0); math.Abs(float64(cv[frames-1])-want) > 1e-3 || want < 0.1 {
+		t.Errorf("CV %f, want %f for the left channel's reduction", cv[frames-1], want)
+	}
+
+	// A longer output buffer holds the last value
+	long := make([]float32, 2*frames)
+	comp.GainReductionCV(long)
+
+	if long[2*frames-1] != long[frames-1] {
+		t.Errorf("CV past the block %f, want the held %f", long[2*frames-1], long[frames-1])
+	}
+}
@@ -0,0 +1,22 @@
+package dsp
+
+// SetDeltaMonitor replaces the output with the part of the signal the
+// compressor removes, the lookahead-aligned input minus the gain-reduced
+// signal, to hear exactly what the gain reduction takes away while tuning.
+// Makeup and output gain are left out so they don't mask the difference, and
+// a signal below the threshold is silent. The output stage (limiter, clip,
+// dim, mutes) still applies; while bypassed the dry signal passes as usual.
+func (c *SoftKneeCompressor) SetDeltaMonitor(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.deltaMonitor = enabled
+}
+
+// GetDeltaMonitor returns whether the delta monitor is enabled.
+func (c *SoftKneeCompressor) GetDeltaMonitor() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.deltaMonitor
+}
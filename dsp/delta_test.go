@@ -0,0 +1,47 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestDeltaMonitor verifies the delta monitor is near-silent for a signal
+// below the threshold and carries the removed signal when compressing hard.
+func TestDeltaMonitor(t *testing.T) {
+	t.Parallel()
+
+	deltaRMS := func(amplitude float64) float64 {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetThreshold(-20.0)
+		comp.SetRatio(10.0)
+		comp.SetMakeupGain(12.0)
+		comp.SetDeltaMonitor(true)
+
+		in := make([]float32, 48000)
+		for i := range in {
+			in[i] = float32(amplitude * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+		}
+
+		out := make([]float32, len(in))
+		comp.ProcessBlock(in, out, 0)
+
+		// Skip the attack to measure the settled delta
+		sum := 0.0
+		for _, sample := range out[len(out)/2:] {
+			sum += float64(sample) * float64(sample)
+		}
+
+		return math.Sqrt(sum / float64(len(out)/2))
+	}
+
+	if quiet := deltaRMS(DBToLinear(-40.0)); quiet > 1e-4 {
+		t.Errorf("Delta of a signal below the threshold has RMS %g, want near silence", quiet)
+	}
+
+	loud := deltaRMS(DBToLinear(-6.0))
+	inputRMS := DBToLinear(-6.0) / math.Sqrt2
+
+	if loud < 0.5*inputRMS {
+		t.Errorf("Delta of a heavily compressed signal has RMS %g, want at least half the input RMS %g", loud, inputRMS)
+	}
+}
@@ -0,0 +1,44 @@
+package dsp
+
+import "math"
+
+const (
+	// Time constant in milliseconds of the dim gain ramp.
+	dimSmoothingMs = 20.0
+
+	// Strongest dim attenuation in dB.
+	minDimDB = -60.0
+)
+
+// SetDim attenuates the output by dB (e.g. -20) for a quick level reference
+// while mixing, and 0 turns the dim off. The attenuation ramps in and out over
+// about 20 ms so toggling it doesn't click. It is applied after the output
+// gain, also while bypassed, and never reaches the detector. Positive values
+// are treated as 0; the limit is -60 dB.
+func (c *SoftKneeCompressor) SetDim(dB float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(dB) {
+		return
+	}
+
+	c.dimDB = math.Max(minDimDB, math.Min(0.0, dB))
+	c.dimGain = DBToLinear(c.dimDB)
+}
+
+// GetDim returns the dim attenuation in dB, or 0 if the dim is off.
+func (c *SoftKneeCompressor) GetDim() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.dimDB
+}
+
+// applyDim ramps the channel's dim gain towards its target and applies it
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) applyDim(output float64, channel int) float64 {
+	c.smoothedDim[channel] += (c.dimGain - c.smoothedDim[channel]) * c.dimSmoothingCoeff
+
+	return output * c.smoothedDim[channel]
+}
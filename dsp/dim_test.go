@@ -0,0 +1,76 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestDimAttenuatesAndRestores verifies engaging the dim lowers the output by
+// exactly the configured amount and disengaging ramps back to full level
+// without a step.
+func TestDimAttenuatesAndRestores(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetBypass(true)
+
+	in := make([]float32, 480)
+	for i := range in {
+		in[i] = 0.5
+	}
+
+	out := make([]float32, len(in))
+
+	comp.SetDim(-20.0)
+
+	for range 20 { // 200 ms
+		comp.ProcessBlock(in, out, 0)
+	}
+
+	gotDB := 20.0 * math.Log10(float64(out[len(out)-1])/0.5)
+	if math.Abs(gotDB+20.0) > 0.01 {
+		t.Errorf("Dimmed output at %.3f dB, want -20 dB", gotDB)
+	}
+
+	comp.SetDim(0.0)
+
+	previous := float64(out[len(out)-1])
+	maxStep := 0.0
+
+	for range 20 {
+		comp.ProcessBlock(in, out, 0)
+
+		for _, sample := range out {
+			maxStep = math.Max(maxStep, math.Abs(float64(sample)-previous))
+			previous = float64(sample)
+		}
+	}
+
+	if math.Abs(previous-0.5) > 1e-4 {
+		t.Errorf("Output after disengaging %.5f, want 0.5", previous)
+	}
+
+	// A 20 ms ramp over a 0.45 jump moves well under 1 % of it per sample
+	if maxStep > 0.001 {
+		t.Errorf("Largest sample step %.5f while disengaging, want a smooth ramp", maxStep)
+	}
+}
+
+// TestDimClamp verifies positive values turn the dim off and the depth is limited.
+func TestDimClamp(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+
+	comp.SetDim(6.0)
+
+	if got := comp.GetDim(); got != 0.0 {
+		t.Errorf("Positive dim stored as %.1f, want 0", got)
+	}
+
+	comp.SetDim(-100.0)
+
+	if got := comp.GetDim(); got != minDimDB {
+		t.Errorf("Dim stored as %.1f, want %.1f", got, minDimDB)
+	}
+}
@@ -0,0 +1,125 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Maximum number of entries kept in a channel's gain history.
+const maxGainHistory = 1024
+
+// gainHistory is a per-channel ring of decimated gain values. Each entry holds
+// the deepest gain over one capture interval, so the capture rate is
+// independent of the block size. Entries are written on the audio thread with
+// the compressor lock held and read lock-free.
+type gainHistory struct {
+	values     [maxGainHistory]uint64 // Ring of linear gains (atomic float64 bits)
+	length     uint32                 // Entries kept, 0 = disabled (atomic)
+	written    uint64                 // Total entries written (atomic)
+	decimation int                    // Samples per entry
+	pending    int                    // Samples folded into the current entry
+	minGain    float64                // Deepest gain of the current entry
+}
+
+// configure clears the history and sets its length and samples per entry.
+func (h *gainHistory) configure(length, decimation int) {
+	atomic.StoreUint32(&h.length, uint32(length))
+	atomic.StoreUint64(&h.written, 0)
+
+	h.decimation = decimation
+	h.pending = 0
+	h.minGain = 1.0
+}
+
+// push folds one sample's gain into the current entry and stores the entry
+// once the capture interval is complete.
+func (h *gainHistory) push(gain float64) {
+	length := uint64(atomic.LoadUint32(&h.length))
+	if length == 0 {
+		return
+	}
+
+	h.minGain = math.Min(h.minGain, gain)
+
+	h.pending++
+	if h.pending < h.decimation {
+		return
+	}
+
+	pos := atomic.LoadUint64(&h.written)
+	atomic.StoreUint64(&h.values[pos%length], math.Float64bits(h.minGain))
+	atomic.StoreUint64(&h.written, pos+1)
+
+	h.pending = 0
+	h.minGain = 1.0
+}
+
+// snapshot returns the stored gains, oldest first, or nil if disabled.
+func (h *gainHistory) snapshot() []float64 {
+	length := uint64(atomic.LoadUint32(&h.length))
+	if length == 0 {
+		return nil
+	}
+
+	written := atomic.LoadUint64(&h.written)
+	count := min(written, length)
+
+	gains := make([]float64, count)
+	for i := range gains {
+		gains[i] = math.Float64frombits(atomic.LoadUint64(&h.values[(written-count+uint64(i))%length]))
+	}
+
+	return gains
+}
+
+// SetGainHistory keeps the last length gain values of every channel, each the
+// deepest gain over intervalMs milliseconds, for drawing the gain reduction as
+// a waveform. length is clamped to 1024; 0 disables the history. Changing the
+// settings clears the recorded values.
+func (c *SoftKneeCompressor) SetGainHistory(length int, intervalMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(intervalMs) {
+		return
+	}
+
+	c.gainHistoryLength = max(0, min(length, maxGainHistory))
+	c.gainHistoryIntervalMs = math.Max(intervalMs, 0.0)
+
+	for ch := range c.gainHistories {
+		c.gainHistories[ch].configure(c.gainHistoryLength, c.gainHistoryDecimation())
+	}
+}
+
+// GetGainHistory returns the gain history length and capture interval in milliseconds.
+func (c *SoftKneeCompressor) GetGainHistory() (int, float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gainHistoryLength, c.gainHistoryIntervalMs
+}
+
+// GainHistory returns the recorded linear gains of a channel, oldest first, or
+// nil if the history is disabled or the channel is invalid.
+func (c *SoftKneeCompressor) GainHistory(channel int) []float64 {
+	if channel < 0 || channel >= c.channels {
+		return nil
+	}
+
+	return c.gainHistories[channel].snapshot()
+}
+
+// gainHistoryDecimation returns the number of samples per gain history entry
+// at the current sample rate (internal, assumes lock held).
+func (c *SoftKneeCompressor) gainHistoryDecimation() int {
+	return max(1, int(math.Round(c.gainHistoryIntervalMs*0.001*c.sampleRate)))
+}
+
+// updateGainHistoryDecimation keeps the capture interval constant in time
+// after a sample rate change (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateGainHistoryDecimation() {
+	for ch := range c.gainHistories {
+		c.gainHistories[ch].decimation = c.gainHistoryDecimation()
+	}
+}
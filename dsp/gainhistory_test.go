@@ -0,0 +1,86 @@
+package dsp
+
+import (
+	"testing"
+)
+
+// TestGainHistoryRing verifies the ring keeps the most recent entries, oldest
+// first, and that each entry holds the deepest gain of its interval.
+func TestGainHistoryRing(t *testing.T) {
+	t.Parallel()
+
+	var history gainHistory
+
+	if history.snapshot() != nil {
+		t.Error("An unconfigured history should be disabled")
+	}
+
+	history.configure(4, 3)
+
+	// Ten entries of three samples each; the middle sample is the deepest
+	for entry := range 10 {
+		gain := 1.0 / float64(entry+2)
+		history.push(1.0)
+		history.push(gain)
+		history.push(0.9)
+	}
+
+	// A partial interval must not show up yet
+	history.push(0.01)
+
+	got := history.snapshot()
+	want := []float64{1.0 / 8.0, 1.0 / 9.0, 1.0 / 10.0, 1.0 / 11.0}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d entries, got %d: %v", len(want), len(got), got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Entry %d = %f, want %f", i, got[i], want[i])
+		}
+	}
+}
+
+// TestGainHistoryCapture verifies the compressor records one entry per capture
+// interval regardless of the block size, and that the entries show the gain
+// reduction of a loud signal.
+func TestGainHistoryCapture(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+
+	if comp.GainHistory(0) != nil {
+		t.Error("Gain history should be disabled by default")
+	}
+
+	comp.SetGainHistory(100, 10.0) // 480 samples per entry
+
+	in := make([]float32, 1000)
+	for i := range in {
+		in[i] = 0.8
+	}
+
+	out := make([]float32, len(in))
+	for range 12 {
+		comp.ProcessBlock(in, out, 0)
+	}
+
+	history := comp.GainHistory(0)
+	if len(history) != 12000/480 {
+		t.Fatalf("Expected %d entries, got %d", 12000/480, len(history))
+	}
+
+	if last := history[len(history)-1]; last > 0.5 {
+		t.Errorf("Entries should show the gain reduction, got gain %f", last)
+	}
+
+	if len(comp.GainHistory(1)) != 0 {
+		t.Errorf("Unprocessed channel should have no entries, got %d", len(comp.GainHistory(1)))
+	}
+
+	if comp.GainHistory(2) != nil {
+		t.Error("Invalid channel should return nil")
+	}
+}
@@ -0,0 +1,112 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+const (
+	// Default averaged input peak in dBFS below which the input counts as
+	// under-driven, and the range it can be set within.
+	defaultGainStagingLowDB = -40.0
+	minGainStagingLowDB     = -70.0
+	maxGainStagingLowDB     = -20.0
+
+	// Averaged input peak in dBFS below which the input counts as silent, so
+	// pauses aren't reported as under-driven.
+	gainStagingSilenceDB = -80.0
+
+	// Time in seconds the clipping hint stays up after the last clipped block.
+	gainStagingClipHoldSec = 2.0
+)
+
+// GainStagingHint is the result of the input gain-staging diagnostic.
+type GainStagingHint uint32
+
+const (
+	// GainStagingOK means the input level is reasonable (or silent).
+	GainStagingOK GainStagingHint = iota
+	// GainStagingLow means the input peaks sit far below -20 dBFS.
+	GainStagingLow
+	// GainStagingClipping means the input reached 0 dBFS recently.
+	GainStagingClipping
+)
+
+// String returns a suggestion for the user, or an empty string if the gain
+// staging is fine.
+func (h GainStagingHint) String() string {
+	switch h {
+	case GainStagingLow:
+		return "Input low — increase upstream gain."
+	case GainStagingClipping:
+		return "Input clipping — reduce upstream gain."
+	case GainStagingOK:
+		return ""
+	default:
+		return ""
+	}
+}
+
+// SetGainStagingLowThreshold sets the averaged input peak level in dBFS below
+// which the input is reported as under-driven (clamped to -70..-20 dB). Input
+// below -80 dBFS counts as silence and never triggers the hint.
+func (c *SoftKneeCompressor) SetGainStagingLowThreshold(dB float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(dB) {
+		return
+	}
+
+	c.gainStagingLowDB = math.Max(minGainStagingLowDB, math.Min(maxGainStagingLowDB, dB))
+}
+
+// GetGainStagingLowThreshold returns the under-driven input level in dBFS.
+func (c *SoftKneeCompressor) GetGainStagingLowThreshold() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gainStagingLowDB
+}
+
+// updateGainStaging derives a channel's gain-staging hint from the slow input
+// average and recent clipping (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateGainStaging(channel int, maxInput float64, samples int) {
+	if maxInput >= 1.0 {
+		c.clipHoldSamples[channel] = int(gainStagingClipHoldSec * c.sampleRate)
+	} else {
+		c.clipHoldSamples[channel] = max(0, c.clipHoldSamples[channel]-samples)
+	}
+
+	averageDB := LinearToDB(math.Float64frombits(atomic.LoadUint64(&c.inputAverage[channel])))
+
+	hint := GainStagingOK
+
+	switch {
+	case c.clipHoldSamples[channel] > 0:
+		hint = GainStagingClipping
+	case averageDB < c.gainStagingLowDB && averageDB > gainStagingSilenceDB:
+		hint = GainStagingLow
+	}
+
+	atomic.StoreUint32(&c.gainStaging[channel], uint32(hint))
+}
+
+// GainStaging returns the gain-staging hint of a channel.
+func (c *SoftKneeCompressor) GainStaging(channel int) GainStagingHint {
+	if channel < 0 || channel >= c.channels {
+		return GainStagingOK
+	}
+
+	return GainStagingHint(atomic.LoadUint32(&c.gainStaging[channel]))
+}
+
+// worstGainStaging returns the most severe hint over all channels.
+func (c *SoftKneeCompressor) worstGainStaging() GainStagingHint {
+	worst := GainStagingOK
+	for ch := range c.channels {
+		worst = max(worst, c.GainStaging(ch))
+	}
+
+	return worst
+}
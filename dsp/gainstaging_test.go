@@ -0,0 +1,36 @@
+package dsp
+
+import (
+	"testing"
+)
+
+// TestGainStagingHint verifies an under-driven input and a clipping input are
+// flagged, while a healthy level and silence are not.
+func TestGainStagingHint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		level float32
+		want  GainStagingHint
+	}{
+		{"under-driven", float32(DBToLinear(-50.0)), GainStagingLow},
+		{"clipping", 1.2, GainStagingClipping},
+		{"healthy", float32(DBToLinear(-12.0)), GainStagingOK},
+		{"silence", 0.0, GainStagingOK},
+	}
+
+	for _, tt := range tests {
+		comp := NewSoftKneeCompressor(48000.0, 2)
+		processLevel(comp, tt.level, 300) // 3 s
+
+		meters := comp.GetMeters()
+		if meters.GainStagingHint != tt.want {
+			t.Errorf("%s: hint %q, want %q", tt.name, meters.GainStagingHint, tt.want)
+		}
+	}
+
+	if GainStagingLow.String() != "Input low — increase upstream gain." {
+		t.Errorf("Unexpected under-driven hint text %q", GainStagingLow.String())
+	}
+}
@@ -0,0 +1,122 @@
+package dsp
+
+import "math"
+
+const (
+	// Default safety margin below 0 dBFS kept by headroom-aware makeup, in dB.
+	defaultHeadroomMarginDB = 1.0
+
+	// Time the output true peak used by headroom-aware makeup is held, in
+	// seconds; longer than a period of the lowest audible frequencies, so the
+	// hold doesn't sag between waveform peaks.
+	headroomHoldSec = 0.05
+
+	// Release time of the output peak hold used by headroom-aware makeup, in seconds.
+	headroomReleaseSec = 1.0
+)
+
+// SetHeadroomAwareMakeup backs auto makeup off whenever it would push the
+// output true peak above 0 dBFS minus the headroom margin. The peak is
+// measured on the compressed signal before makeup with the 4x oversampling
+// filter of the true-peak meter (see SetTruePeakMetering), held for 50 ms and
+// then released over 1 s, across all channels. New peaks are caught a few
+// samples late and the makeup ramps down over its smoothing time, so onsets
+// can briefly exceed the margin.
+func (c *SoftKneeCompressor) SetHeadroomAwareMakeup(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if enabled && !c.headroomAware {
+		for ch := range c.headroomHistory {
+			c.headroomHistory[ch] = [truePeakTaps]float64{}
+			c.headroomPeak[ch] = 0.0
+			c.headroomHold[ch] = 0
+		}
+	}
+
+	c.headroomAware = enabled
+}
+
+// GetHeadroomAwareMakeup returns whether auto makeup respects the headroom margin.
+func (c *SoftKneeCompressor) GetHeadroomAwareMakeup() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.headroomAware
+}
+
+// SetHeadroomMargin sets the safety margin below 0 dBFS in dB (clamped to >= 0).
+func (c *SoftKneeCompressor) SetHeadroomMargin(dB float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(dB) {
+		return
+	}
+
+	c.headroomMarginDB = math.Max(dB, 0.0)
+	c.headroomCeiling = DBToLinear(-c.headroomMarginDB)
+}
+
+// GetHeadroomMargin returns the headroom safety margin in dB.
+func (c *SoftKneeCompressor) GetHeadroomMargin() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.headroomMarginDB
+}
+
+// GetAppliedMakeupGain returns the makeup gain in dB the output currently
+// receives: the smoothed makeup after any headroom back-off or makeup bypass,
+// taking the lowest across channels. Before any audio was processed it equals
+// GetMakeupGain.
+func (c *SoftKneeCompressor) GetAppliedMakeupGain() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.hasProcessed() {
+		return c.makeupGainDB
+	}
+
+	applied := math.Inf(1)
+	for _, makeup := range c.smoothedMakeup {
+		applied = math.Min(applied, makeup)
+	}
+
+	return 20.0 * math.Log10(applied)
+}
+
+// makeupTarget tracks the pre-makeup true peak of a channel and returns the
+// makeup gain to ramp towards (internal, assumes lock held).
+func (c *SoftKneeCompressor) makeupTarget(channel int, preMakeup float64) float64 {
+	if c.makeupBypass {
+		return 1.0
+	}
+
+	if !c.headroomAware || !c.autoMakeup {
+		return c.makeupGainLin
+	}
+
+	level := oversampledPeak(&c.headroomHistory[channel], preMakeup)
+	switch {
+	case level >= c.headroomPeak[channel]:
+		c.headroomPeak[channel] = level
+		c.headroomHold[channel] = c.headroomHoldLen
+	case c.headroomHold[channel] > 0:
+		c.headroomHold[channel]--
+	default:
+		c.headroomPeak[channel] *= c.headroomRelease
+	}
+
+	peak := 0.0
+	for _, channelPeak := range c.headroomPeak {
+		peak = math.Max(peak, channelPeak)
+	}
+
+	// Output gain is a deliberate user trim, so only the makeup is backed off
+	if peak*c.makeupGainLin*c.outputGainLin <= c.headroomCeiling {
+		return c.makeupGainLin
+	}
+
+	return math.Max(c.headroomCeiling/(peak*c.outputGainLin), 1.0)
+}
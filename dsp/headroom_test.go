@@ -0,0 +1,70 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// headroomOutputTruePeak runs a hot tone through heavy compression with auto
+// makeup and returns the output true peak over the last 100 ms. The run is long
+// enough for the peak hold to forget the uncompressed onset. The tone sits at a
+// quarter of the sample rate with a 45 degree phase, so every sample misses the
+// waveform crest by 3 dB and only true-peak detection sees it.
+func headroomOutputTruePeak(headroomAware bool) float64 {
+	const sampleRate = 48000.0
+
+	comp := NewSoftKneeCompressor(sampleRate, 1)
+	comp.SetThreshold(-40.0)
+	comp.SetRatio(4.0)
+	comp.SetAttack(1.0)
+	comp.SetHeadroomMargin(3.0)
+	comp.SetHeadroomAwareMakeup(headroomAware)
+	comp.SetTruePeakMetering(true)
+
+	const (
+		blockSize = 480
+		blocks    = 400
+	)
+
+	in := make([]float32, blockSize)
+	out := make([]float32, blockSize)
+
+	for n := range blocks {
+		if n == blocks-10 {
+			comp.ResetTruePeaks()
+		}
+
+		for i := range in {
+			phase := 2.0*math.Pi*float64(n*blockSize+i)/4.0 + math.Pi/4.0
+			in[i] = float32(0.9 * math.Sin(phase))
+		}
+
+		comp.ProcessBlock(in, out, 0)
+	}
+
+	return comp.TruePeak(0)
+}
+
+// TestHeadroomAwareMakeup verifies auto makeup backs off on a hot signal to keep
+// output true peaks under the configured margin, including inter-sample peaks
+// that sample peaks miss.
+func TestHeadroomAwareMakeup(t *testing.T) {
+	t.Parallel()
+
+	ceiling := DBToLinear(-3.0)
+
+	// 30 dB of auto makeup pushes this signal far above the ceiling
+	if peak := headroomOutputTruePeak(false); peak <= ceiling {
+		t.Fatalf("Plain auto makeup should exceed the margin on this signal, got true peak %f", peak)
+	}
+
+	// Allow for float32 rounding of the output only
+	peak := headroomOutputTruePeak(true)
+	if peak > ceiling*DBToLinear(0.001) {
+		t.Errorf("Headroom-aware makeup should keep true peaks below %.4f, got %.4f", ceiling, peak)
+	}
+
+	if peak < ceiling*0.9 {
+		t.Errorf("Makeup should only back off as far as needed: true peak %.4f, ceiling %.4f", peak, ceiling)
+	}
+}
@@ -0,0 +1,229 @@
+package dsp
+
+import "math"
+
+const (
+	// Maximum limiter lookahead time in milliseconds.
+	maxLimiterLookaheadMs = 10.0
+
+	// Release time constant of the limiter gain in milliseconds.
+	limiterReleaseMs = 50.0
+)
+
+// lookaheadLimiter is the state of one channel's lookahead limiter: the audio
+// is delayed by n samples while the gain needed to keep each incoming sample
+// under the ceiling is held as a sliding minimum over n+1 samples and then
+// averaged over n samples. The gain therefore ramps down over the lookahead and
+// is fully in place when the peak leaves the delay.
+type lookaheadLimiter struct {
+	delay   []float64 // Audio delay line
+	box     []float64 // Held gains averaged by the box filter
+	pos     int       // Write position in delay and box
+	boxSum  float64   // Sum of box
+	env     float64   // Held gain after release smoothing
+	holdVal []float64 // Sliding minimum queue of gains, ring of n+1
+	holdAt  []int     // Sample index of each queued gain
+	head    int       // Oldest queue entry
+	size    int       // Queue entries in use
+	count   int       // Samples processed
+}
+
+// reserve allocates room for a lookahead of up to n samples.
+func (l *lookaheadLimiter) reserve(n int) {
+	l.delay = make([]float64, 0, n)
+	l.box = make([]float64, 0, n)
+	l.holdVal = make([]float64, 0, n+1)
+	l.holdAt = make([]int, 0, n+1)
+}
+
+// resize sets the lookahead to n samples within the reserved room and resets
+// the limiter.
+func (l *lookaheadLimiter) resize(n int) {
+	l.delay = l.delay[:n]
+	l.box = l.box[:n]
+	l.holdVal = l.holdVal[:n+1]
+	l.holdAt = l.holdAt[:n+1]
+	l.reset()
+}
+
+// reset silences the delay line and releases the gain.
+func (l *lookaheadLimiter) reset() {
+	for i := range l.delay {
+		l.delay[i] = 0
+		l.box[i] = 1.0
+	}
+
+	l.pos = 0
+	l.boxSum = float64(len(l.box))
+	l.env = 1.0
+	l.head = 0
+	l.size = 0
+	l.count = 0
+}
+
+// process pushes a sample and the gain it needs into the limiter and returns
+// the delayed sample with the gain to apply to it.
+func (l *lookaheadLimiter) process(sample, target, releaseCoeff float64) (float64, float64) {
+	window := len(l.holdVal)
+
+	// Sliding minimum: drop queued gains that can never be the minimum again
+	for l.size > 0 {
+		back := (l.head + l.size - 1) % window
+		if l.holdVal[back] < target {
+			break
+		}
+
+		l.size--
+	}
+
+	back := (l.head + l.size) % window
+	l.holdVal[back] = target
+	l.holdAt[back] = l.count
+	l.size++
+
+	if l.holdAt[l.head] <= l.count-window {
+		l.head = (l.head + 1) % window
+		l.size--
+	}
+
+	l.count++
+
+	held := l.holdVal[l.head]
+	if held < l.env {
+		l.env = held
+	} else {
+		l.env = held + (l.env-held)*releaseCoeff
+	}
+
+	l.boxSum += l.env - l.box[l.pos]
+	l.box[l.pos] = l.env
+
+	delayed := l.delay[l.pos]
+	l.delay[l.pos] = sample
+
+	l.pos = (l.pos + 1) % len(l.delay)
+
+	return delayed, l.boxSum / float64(len(l.box))
+}
+
+// SetLimiterLookahead gives the hard clipper (see SetHardClip) its own
+// lookahead of timeMs milliseconds, turning it into a brick-wall limiter: the
+// output is delayed and its gain ramps down smoothly ahead of peaks above the
+// ceiling instead of flattening them, and the clipper only catches what is
+// left. The delay is separate from SetLookahead and adds to LatencySamples; it
+// stays in place while bypassed or with the clipper off, so toggling those
+// doesn't shift the audio. The time is clamped to 0-10 ms; 0 disables it.
+func (c *SoftKneeCompressor) SetLimiterLookahead(timeMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(timeMs) {
+		return
+	}
+
+	c.limiterMs = math.Max(0.0, math.Min(maxLimiterLookaheadMs, timeMs))
+	c.reserveLimiters()
+	c.updateLimiterLookahead()
+}
+
+// GetLimiterLookahead returns the limiter lookahead time in milliseconds.
+func (c *SoftKneeCompressor) GetLimiterLookahead() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.limiterMs
+}
+
+// limiterLookaheadMs returns the limiter lookahead in effect, lengthened while
+// maximizing (internal, assumes lock held).
+func (c *SoftKneeCompressor) limiterLookaheadMs() float64 {
+	if c.maximize {
+		return math.Max(c.limiterMs, minMaximizeLookaheadMs)
+	}
+
+	return c.limiterMs
+}
+
+// reserveLimiters allocates the limiters for the lookahead in effect at up to
+// maxLookaheadRate, so sample rate changes on the audio thread don't allocate
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) reserveLimiters() {
+	reserve := int(math.Ceil(c.limiterLookaheadMs() * 0.001 * maxLookaheadRate))
+	if reserve <= c.limiterReserved {
+		return
+	}
+
+	for ch := range c.limiters {
+		c.limiters[ch].reserve(reserve)
+	}
+
+	c.limiterReserved = reserve
+	c.limiterSamples = 0
+}
+
+// updateLimiterLookahead sizes the limiters for the current lookahead and
+// sample rate within the room reserveLimiters allocated (internal, assumes
+// lock held).
+func (c *SoftKneeCompressor) updateLimiterLookahead() {
+	c.limiterReleaseCoeff = math.Exp(-1.0 / (limiterReleaseMs * 0.001 * c.sampleRate))
+
+	samples := min(int(math.Round(c.limiterLookaheadMs()*0.001*c.sampleRate)), c.limiterReserved)
+	if samples == c.limiterSamples {
+		return
+	}
+
+	c.limiterSamples = samples
+
+	for ch := range c.limiters {
+		c.limiters[ch].resize(samples)
+	}
+}
+
+// clearLimiters resets every channel's limiter (internal, assumes lock held).
+func (c *SoftKneeCompressor) clearLimiters() {
+	if c.limiterSamples == 0 {
+		return
+	}
+
+	for ch := range c.limiters {
+		c.limiters[ch].reset()
+	}
+}
+
+// limitOutput runs a sample through the channel's lookahead limiter, which
+// only reduces gain while the hard clipper is enabled, or through the
+// maximizer (internal, assumes lock held).
+func (c *SoftKneeCompressor) limitOutput(sample float64, channel int) float64 {
+	if c.maximize {
+		return c.maximizeOutput(sample, channel)
+	}
+
+	if c.limiterSamples == 0 {
+		return sample
+	}
+
+	target := 1.0
+	if level := math.Abs(sample); c.hardClip && level > c.hardClipCeiling {
+		target = c.hardClipCeiling / level
+	}
+
+	delayed, gain := c.limiters[channel].process(sample, target, c.limiterReleaseCoeff)
+
+	return delayed * gain
+}
+
+// delayLimiter runs a bypassed sample through the limiter delay without
+// limiting it, so the latency stays the same (internal, assumes lock held).
+func (c *SoftKneeCompressor) delayLimiter(sample float64, channel int) float64 {
+	if c.maximize {
+		sample = c.delayMaximizer(sample, channel)
+	}
+
+	if c.limiterSamples == 0 {
+		return sample
+	}
+
+	delayed, _ := c.limiters[channel].process(sample, 1.0, c.limiterReleaseCoeff)
+
+	return delayed
+}
@@ -0,0 +1,115 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// limiterTransient returns a quiet tone with a sudden loud burst well above
+// full scale in the middle.
+func limiterTransient() []float32 {
+	in := make([]float32, 9600)
+	for i := range in {
+		amplitude := 0.3
+		if i >= 4800 && i < 5280 {
+			amplitude = 1.8
+		}
+
+		in[i] = float32(amplitude * math.Sin(2.0*math.Pi*440.0*float64(i)/48000.0))
+	}
+
+	return in
+}
+
+// flatTopRun returns the longest run of consecutive samples at the ceiling,
+// i.e. the audible flattening caused by clipping.
+func flatTopRun(out []float32, ceiling float64) int {
+	longest, run := 0, 0
+
+	for _, sample := range out {
+		if math.Abs(float64(sample)) >= ceiling*0.999 {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+
+	return longest
+}
+
+// TestLimiterLookahead verifies the limiter with lookahead keeps a sharp
+// transient under the ceiling without the flat tops of plain clipping, and
+// that its delay is reported as latency.
+func TestLimiterLookahead(t *testing.T) {
+	t.Parallel()
+
+	ceiling := DBToLinear(-1.0)
+
+	process := func(lookaheadMs float64) ([]float32, *SoftKneeCompressor) {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetRatio(1.0) // Transparent, so only the limiter acts
+		comp.SetStartupFade(0.0)
+		comp.SetHardClip(true)
+		comp.SetHardClipCeiling(-1.0)
+		comp.SetLimiterLookahead(lookaheadMs)
+
+		in := limiterTransient()
+		out := make([]float32, len(in))
+		comp.ProcessBlock(in, out, 0)
+
+		return out, comp
+	}
+
+	clipped, _ := process(0.0)
+	limited, comp := process(5.0)
+
+	peak := 0.0
+	for _, sample := range limited {
+		peak = math.Max(peak, math.Abs(float64(sample)))
+	}
+
+	if peak > ceiling+1e-6 {
+		t.Errorf("Limited peak %.6f exceeds the ceiling %.6f", peak, ceiling)
+	}
+
+	clippedRun, limitedRun := flatTopRun(clipped, ceiling), flatTopRun(limited, ceiling)
+	if clippedRun < 10 {
+		t.Fatalf("Plain clipping should flatten the burst, longest flat top %d samples", clippedRun)
+	}
+
+	if limitedRun > 2 {
+		t.Errorf("Limiter flattens %d consecutive samples, want at most 2 (clipping: %d)", limitedRun, clippedRun)
+	}
+
+	if got := comp.LatencySamples(); got != 240 {
+		t.Errorf("LatencySamples = %d, want 240", got)
+	}
+
+	// The quiet tone before the burst passes unchanged, only delayed
+	in := limiterTransient()
+	for i := 240; i < 4000; i++ {
+		if math.Abs(float64(limited[i]-in[i-240])) > 1e-6 {
+			t.Fatalf("Sample %d = %f, want the delayed input %f", i, limited[i], in[i-240])
+		}
+	}
+}
+
+// TestLimiterLookaheadBypass verifies bypass keeps the limiter delay.
+func TestLimiterLookaheadBypass(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetLimiterLookahead(1.0)
+	comp.SetBypass(true)
+
+	in := make([]float32, 96)
+	in[0] = 0.5
+
+	out := make([]float32, len(in))
+	comp.ProcessBlock(in, out, 0)
+
+	if out[48] != 0.5 || out[0] != 0 {
+		t.Errorf("Bypassed impulse should arrive after 48 samples, got out[0]=%f out[48]=%f", out[0], out[48])
+	}
+}
@@ -0,0 +1,43 @@
+package dsp
+
+import "math"
+
+// Ratio from which the compressor counts as a limiter for SetLimiterKnee.
+const limiterModeRatio = 20.0
+
+// SetLimiterKnee sets the knee width in dB used while the ratio is at or above
+// 20:1. A limiter usually wants a hard knee so the ceiling is exact, while the
+// soft knee suited to the compressor's normal ratio rounds the corner off and
+// starts reducing gain up to half the knee width below the threshold. A
+// negative width (the default) keeps the main knee in limiter mode as well.
+// Non-finite values are ignored.
+func (c *SoftKneeCompressor) SetLimiterKnee(kneeDB float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(kneeDB) {
+		return
+	}
+
+	c.limiterKneeDB = math.Max(kneeDB, -1.0)
+	c.updateThresholdCache()
+}
+
+// GetLimiterKnee returns the limiter mode knee width in dB, negative if the
+// main knee applies.
+func (c *SoftKneeCompressor) GetLimiterKnee() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.limiterKneeDB
+}
+
+// effectiveKneeDB returns the knee width for the current ratio (internal,
+// assumes lock held).
+func (c *SoftKneeCompressor) effectiveKneeDB() float64 {
+	if c.ratio >= limiterModeRatio && c.limiterKneeDB >= 0.0 {
+		return c.limiterKneeDB
+	}
+
+	return c.kneeDB
+}
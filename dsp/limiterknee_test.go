@@ -0,0 +1,69 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestLimiterKnee verifies a limiter with a hard limiter knee holds an exact
+// ceiling at the threshold and leaves levels below it alone, a soft one
+// approaches the ceiling gradually, and the limiter knee has no effect at
+// compressor ratios.
+func TestLimiterKnee(t *testing.T) {
+	t.Parallel()
+
+	const thresholdDB = -20.0
+
+	newLimiter := func(ratio, limiterKneeDB float64) *SoftKneeCompressor {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetThreshold(thresholdDB)
+		comp.SetRatio(ratio)
+		comp.SetKnee(12.0)
+		comp.SetLimiterKnee(limiterKneeDB)
+		comp.SetAccuracyMode(Precise) // Keep the curve exact to check the ceiling
+
+		return comp
+	}
+
+	// Static output level in dBFS for an input level in dBFS
+	outputDB := func(comp *SoftKneeCompressor, inputDB float64) float64 {
+		return inputDB + 20*math.Log10(comp.computeGain(math.Pow(10, inputDB/20)))
+	}
+
+	hard := newLimiter(1000.0, 0.0)
+
+	if got := outputDB(hard, thresholdDB-1.0); math.Abs(got-(thresholdDB-1.0)) > 1e-9 {
+		t.Errorf("hard knee output 1 dB below the threshold = %.3f dBFS, want it untouched", got)
+	}
+
+	for _, inputDB := range []float64{thresholdDB + 0.5, thresholdDB + 3.0, thresholdDB + 12.0} {
+		if got := outputDB(hard, inputDB); math.Abs(got-thresholdDB) > 0.05 {
+			t.Errorf("hard knee output at %.1f dBFS = %.3f dBFS, want the %.0f dBFS ceiling", inputDB, got, thresholdDB)
+		}
+	}
+
+	// A soft knee already reduces below the threshold and only reaches the
+	// ceiling at the top of the knee
+	soft := newLimiter(1000.0, 12.0)
+
+	if got := outputDB(soft, thresholdDB-1.0); got > thresholdDB-1.5 {
+		t.Errorf("soft knee output 1 dB below the threshold = %.3f dBFS, want gain reduction", got)
+	}
+
+	if got := outputDB(soft, thresholdDB+0.5); got > thresholdDB-0.5 {
+		t.Errorf("soft knee output 0.5 dB above the threshold = %.3f dBFS, want it well below the ceiling", got)
+	}
+
+	// Below 20:1 the main knee applies even with a hard limiter knee
+	compressor, reference := newLimiter(4.0, 0.0), newLimiter(4.0, -1.0)
+
+	for inputDB := thresholdDB - 8.0; inputDB <= thresholdDB+8.0; inputDB += 0.5 {
+		if got, want := outputDB(compressor, inputDB), outputDB(reference, inputDB); got != want {
+			t.Fatalf("4:1 output at %.1f dBFS = %.3f dBFS, want the main knee's %.3f dBFS", inputDB, got, want)
+		}
+	}
+
+	if knee := hard.GetLimiterKnee(); knee != 0.0 {
+		t.Errorf("GetLimiterKnee = %v, want 0", knee)
+	}
+}
@@ -0,0 +1,71 @@
+package dsp
+
+import "math"
+
+// Highest corner frequency of the linked detection high-pass in Hz.
+const maxLinkHighPassHz = 2000.0
+
+// SetLinkHighPass high-passes the detection signal of each linked channel, so
+// e.g. the bass both channels share doesn't dominate the stereo-linked gain
+// reduction. It applies to the channels of a sidechain source or a link group;
+// unlinked channels keep their own key. 0 (the default) disables the filter;
+// the corner is clamped to 2 kHz.
+//
+// The detection signal of ProcessChannels and ProcessFrames runs through these
+// stages in order:
+//
+//  1. sidechain tilt of each channel's own signal (SetSidechainTilt)
+//  2. link weights scaling each channel's contribution (SetLinkWeights)
+//  3. linked high-pass of each weighted channel key (SetLinkHighPass)
+//  4. link: the largest filtered key of the sidechain source or link group
+//  5. envelope follower (attack/release)
+//  6. gain computer (threshold/ratio/knee or a transfer curve)
+//
+// The first three stages are linear and may be swapped without changing the
+// key; the linking maximum is not. Filtering before it lets the channel with
+// the most energy above the corner win the link, whereas filtering the linked
+// key would high-pass whichever bass-heavy channel won the maximum.
+func (c *SoftKneeCompressor) SetLinkHighPass(hz float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(hz) {
+		return
+	}
+
+	c.linkHighPassHz = math.Max(0.0, math.Min(maxLinkHighPassHz, hz))
+	c.updateLinkHighPass()
+
+	if c.linkHighPassHz == 0.0 {
+		clear(c.linkHPIn)
+		clear(c.linkHPOut)
+	}
+}
+
+// GetLinkHighPass returns the corner frequency of the linked detection high-pass in Hz.
+func (c *SoftKneeCompressor) GetLinkHighPass() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.linkHighPassHz
+}
+
+// updateLinkHighPass recalculates the linked high-pass pole (internal, assumes
+// lock held).
+func (c *SoftKneeCompressor) updateLinkHighPass() {
+	c.linkHPCoeff = math.Exp(-2.0 * math.Pi * c.linkHighPassHz / c.sampleRate)
+}
+
+// filterLinkedKey runs a linked channel's weighted key through the
+// first-order high-pass before linking (internal, assumes lock held).
+func (c *SoftKneeCompressor) filterLinkedKey(key float64, channel int) float64 {
+	if c.linkHighPassHz == 0.0 {
+		return key
+	}
+
+	filtered := c.linkHPCoeff * (c.linkHPOut[channel] + key - c.linkHPIn[channel])
+	c.linkHPIn[channel] = key
+	c.linkHPOut[channel] = filtered
+
+	return filtered
+}
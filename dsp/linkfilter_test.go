@@ -0,0 +1,150 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestLinkHighPassSignalFlow verifies the detection stages of ProcessFrames
+// compose as documented: each weighted channel key is high-passed and the
+// filtered keys are linked afterwards, matching a mono compressor keyed by the
+// same chain computed by hand and differing from high-passing the linked key.
+func TestLinkHighPassSignalFlow(t *testing.T) {
+	t.Parallel()
+
+	const (
+		sampleRate = 48000.0
+		frames     = 9600
+		cornerHz   = 300.0
+	)
+
+	weights := []float64{1.0, 0.5}
+
+	configure := func(comp *SoftKneeCompressor) {
+		comp.SetThreshold(-20.0)
+		comp.SetRatio(4.0)
+		comp.SetAttack(1.0)
+		comp.SetRelease(50.0)
+		comp.SetAutoMakeup(false)
+	}
+
+	stereo := NewSoftKneeCompressor(sampleRate, 2)
+	configure(stereo)
+	stereo.SetLinkHighPass(cornerHz)
+
+	if err := stereo.SetLinkWeights(weights); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stereo.SetLinkGroups([][]int{{0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A loud bass on the left, a weighted-down tone on the right
+	left := make([]float32, frames)
+	right := make([]float32, frames)
+	frameBuf := make([]float32, 2*frames)
+
+	for i := range frames {
+		left[i] = float32(0.5 * math.Sin(2.0*math.Pi*60.0*float64(i)/sampleRate))
+		right[i] = float32(0.9 * math.Sin(2.0*math.Pi*1000.0*float64(i)/sampleRate))
+		frameBuf[2*i] = left[i]
+		frameBuf[2*i+1] = right[i]
+	}
+
+	stereo.ProcessFrames(frameBuf, frameBuf)
+
+	// highPass filters a key with the same first-order high-pass
+	coeff := math.Exp(-2.0 * math.Pi * cornerHz / sampleRate)
+	highPass := func(key []float64) []float32 {
+		out := make([]float32, len(key))
+
+		var prevIn, prevOut float64
+		for i, x := range key {
+			prevOut = coeff * (prevOut + x - prevIn)
+			prevIn = x
+			out[i] = float32(prevOut)
+		}
+
+		return out
+	}
+
+	largest := func(a, b float64) float64 {
+		if math.Abs(b) > math.Abs(a) {
+			return b
+		}
+
+		return a
+	}
+
+	linked := make([]float64, frames)
+	leftKey, rightKey := make([]float64, frames), make([]float64, frames)
+
+	for i := range frames {
+		linked[i] = largest(weights[0]*float64(left[i]), weights[1]*float64(right[i]))
+		leftKey[i] = weights[0] * float64(left[i])
+		rightKey[i] = weights[1] * float64(right[i])
+	}
+
+	// Gain of the left channel for a mono compressor keyed by the given key
+	keyedGain := func(key []float32) float64 {
+		mono := NewSoftKneeCompressor(sampleRate, 1)
+		configure(mono)
+
+		in := append([]float32(nil), left...)
+		mono.ProcessBlockSidechain(in, key, make([]float32, frames), 0)
+
+		return mono.GetMeters().GainReductionL
+	}
+
+	filteredLeft, filteredRight := highPass(leftKey), highPass(rightKey)
+	preLinked := make([]float32, frames)
+
+	for i := range frames {
+		preLinked[i] = float32(largest(float64(filteredLeft[i]), float64(filteredRight[i])))
+	}
+
+	want := keyedGain(preLinked)
+	got := stereo.GetMeters().GainReductionL
+
+	if math.Abs(LinearToDB(got)-LinearToDB(want)) > 0.01 {
+		t.Errorf("Linked gain %.3f dB, want %.3f dB for high-pass then link", LinearToDB(got), LinearToDB(want))
+	}
+
+	// Filtering after linking gives a different key
+	if other := keyedGain(highPass(linked)); math.Abs(LinearToDB(other)-LinearToDB(want)) < 0.1 {
+		t.Errorf("High-passing after linking gives %.3f dB, expected it to differ from %.3f dB",
+			LinearToDB(other), LinearToDB(want))
+	}
+
+	if gainR := stereo.GetMeters().GainReductionR; gainR != got {
+		t.Errorf("Linked channels should share the gain, got %.4f and %.4f", got, gainR)
+	}
+}
+
+// TestLinkHighPassLeavesUnlinkedChannels verifies channels outside any link
+// keep their own unfiltered key.
+func TestLinkHighPassLeavesUnlinkedChannels(t *testing.T) {
+	t.Parallel()
+
+	buffer := make([]float32, 2*4800)
+	for i := range 4800 {
+		buffer[2*i] = float32(0.9 * math.Sin(2.0*math.Pi*50.0*float64(i)/48000.0))
+		buffer[2*i+1] = buffer[2*i]
+	}
+
+	plain := NewSoftKneeCompressor(48000.0, 2)
+	plain.ProcessFrames(append([]float32(nil), buffer...), make([]float32, len(buffer)))
+
+	filtered := NewSoftKneeCompressor(48000.0, 2)
+	filtered.SetLinkHighPass(1000.0)
+	filtered.ProcessFrames(append([]float32(nil), buffer...), make([]float32, len(buffer)))
+
+	if plain.GetMeters().GainReductionL != filtered.GetMeters().GainReductionL {
+		t.Errorf("Unlinked gain changed from %.4f to %.4f", plain.GetMeters().GainReductionL, filtered.GetMeters().GainReductionL)
+	}
+
+	if got := filtered.GetLinkHighPass(); got != 1000.0 {
+		t.Errorf("GetLinkHighPass = %f, want 1000", got)
+	}
+}
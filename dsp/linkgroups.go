@@ -0,0 +1,138 @@
+package dsp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrInvalidLinkGroups is returned when link groups reference a channel twice.
+	ErrInvalidLinkGroups = errors.New("invalid link groups")
+
+	// ErrUnknownLayout is returned for unsupported surround layouts or layouts
+	// that don't match the channel count.
+	ErrUnknownLayout = errors.New("unknown surround layout")
+)
+
+// surroundPairs maps a layout name to its channel count and the linked pairs in
+// PipeWire channel order (FL FR FC LFE RL RR SL SR). Channels outside the pairs,
+// such as center and LFE, keep independent detection.
+var surroundPairs = map[string]struct {
+	channels int
+	pairs    [][]int
+}{
+	"quad": {channels: 4, pairs: [][]int{{0, 1}, {2, 3}}},
+	"5.1":  {channels: 6, pairs: [][]int{{0, 1}, {4, 5}}},
+	"7.1":  {channels: 8, pairs: [][]int{{0, 1}, {4, 5}, {6, 7}}},
+}
+
+// SetLinkGroups links the detectors of each group of channels so they share
+// gain reduction, like a stereo link per group. The group key is the weighted
+// maximum of its members (see SetLinkWeights). Channels not in any group keep
+// independent detection; nil removes all groups. A sidechain source set with
+// SetSidechainSource takes precedence. Only ProcessChannels and ProcessFrames
+// see whole frames, so linking applies there.
+func (c *SoftKneeCompressor) SetLinkGroups(groups [][]int) error {
+	seen := make([]bool, c.channels)
+
+	for _, group := range groups {
+		for _, ch := range group {
+			if ch < 0 || ch >= c.channels {
+				return fmt.Errorf("%w: %d", ErrInvalidChannel, ch)
+			}
+
+			if seen[ch] {
+				return fmt.Errorf("%w: channel %d is in more than one group", ErrInvalidLinkGroups, ch)
+			}
+
+			seen[ch] = true
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.linkGroups = c.linkGroups[:0]
+	for _, group := range groups {
+		if len(group) > 0 {
+			c.linkGroups = append(c.linkGroups, append([]int(nil), group...))
+		}
+	}
+
+	return nil
+}
+
+// GetLinkGroups returns a copy of the configured link groups.
+func (c *SoftKneeCompressor) GetLinkGroups() [][]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	groups := make([][]int, 0, len(c.linkGroups))
+	for _, group := range c.linkGroups {
+		groups = append(groups, append([]int(nil), group...))
+	}
+
+	return groups
+}
+
+// SetSurroundPairs configures link groups for a surround layout ("quad", "5.1"
+// or "7.1"): front, rear and side L/R are linked as pairs to keep the image
+// stable within each pair, while center and LFE are processed independently.
+func (c *SoftKneeCompressor) SetSurroundPairs(layout string) error {
+	config, ok := surroundPairs[layout]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownLayout, layout)
+	}
+
+	if config.channels != c.channels {
+		return fmt.Errorf("%w: %q needs %d channels, compressor has %d",
+			ErrUnknownLayout, layout, config.channels, c.channels)
+	}
+
+	return c.SetLinkGroups(config.pairs)
+}
+
+// SurroundChannels returns the channel count of a surround layout accepted by
+// SetSurroundPairs, so the compressor can be created to match it.
+func SurroundChannels(layout string) (int, error) {
+	config, ok := surroundPairs[layout]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownLayout, layout)
+	}
+
+	return config.channels, nil
+}
+
+// updateFrameKeys computes the detector key of every channel for one
+// interleaved frame, high-passing the weighted keys of linked channels and
+// then applying the sidechain source or link groups (internal, assumes lock
+// held).
+func (c *SoftKneeCompressor) updateFrameKeys(frame []float32) {
+	if key, shared := c.sidechainKey(frame); shared {
+		for ch := range frame {
+			c.frameKey[ch] = key
+		}
+
+		return
+	}
+
+	for ch, sample := range frame {
+		c.frameKey[ch] = c.detectorSignal(float64(sample), ch)
+	}
+
+	for _, group := range c.linkGroups {
+		key := 0.0
+
+		for _, ch := range group {
+			weighted := c.filterLinkedKey(c.linkWeights[ch]*c.frameKey[ch], ch)
+			if math.Abs(weighted) > math.Abs(key) {
+				key = weighted
+			}
+		}
+
+		for _, ch := range group {
+			c.frameKey[ch] = key
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package dsp
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// surroundGains runs a 5.1 frame stream with the given per-channel levels and
+// returns the gain applied to each channel in the last frame.
+func surroundGains(t *testing.T, comp *SoftKneeCompressor, levels []float32) []float64 {
+	t.Helper()
+
+	const frames = 4800
+
+	channels := len(levels)
+
+	in := make([]float32, frames*channels)
+	for i := range frames {
+		copy(in[i*channels:], levels)
+	}
+
+	out := make([]float32, len(in))
+	comp.ProcessFrames(in, out)
+
+	gains := make([]float64, channels)
+	last := (frames - 1) * channels
+
+	for ch := range channels {
+		gains[ch] = float64(out[last+ch]) / float64(in[last+ch])
+	}
+
+	return gains
+}
+
+// TestSurroundPairs51 verifies "5.1" links FL/FR and RL/RR while center and
+// LFE stay independent.
+func TestSurroundPairs51(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 6)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(1.0)
+	comp.SetMakeupGain(0.0)
+
+	err := comp.SetSurroundPairs("5.1")
+	if err != nil {
+		t.Fatalf("SetSurroundPairs failed: %v", err)
+	}
+
+	if groups := comp.GetLinkGroups(); !reflect.DeepEqual(groups, [][]int{{0, 1}, {4, 5}}) {
+		t.Fatalf("Unexpected groups for 5.1: %v", groups)
+	}
+
+	// Loud FL and RL, everything else quiet (below the knee on its own)
+	const (
+		loud  = 0.9
+		quiet = 0.02
+	)
+
+	gains := surroundGains(t, comp, []float32{loud, quiet, loud, loud, loud, quiet})
+
+	for _, pair := range [][2]int{{0, 1}, {4, 5}} {
+		if gains[pair[0]] > 0.5 || math.Abs(gains[pair[0]]-gains[pair[1]]) > 1e-6 {
+			t.Errorf("Pair %v should share the loud channel's reduction: gains %f, %f",
+				pair, gains[pair[0]], gains[pair[1]])
+		}
+	}
+
+	// Center and LFE are loud too but reduce on their own
+	if gains[2] > 0.5 || gains[3] > 0.5 {
+		t.Errorf("Loud center and LFE should be compressed: %f, %f", gains[2], gains[3])
+	}
+}
+
+// TestSurroundPairsIndependentCenter verifies a loud center doesn't duck the pairs.
+func TestSurroundPairsIndependentCenter(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 6)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(1.0)
+	comp.SetMakeupGain(0.0)
+
+	err := comp.SetSurroundPairs("5.1")
+	if err != nil {
+		t.Fatalf("SetSurroundPairs failed: %v", err)
+	}
+
+	gains := surroundGains(t, comp, []float32{0.02, 0.02, 0.9, 0.02, 0.02, 0.02})
+
+	for _, ch := range []int{0, 1, 3, 4, 5} {
+		if gains[ch] < 0.99 {
+			t.Errorf("Channel %d should not follow the center, got gain %f", ch, gains[ch])
+		}
+	}
+}
+
+// TestSurroundPairsValidation verifies unknown layouts and channel mismatches are rejected.
+func TestSurroundPairsValidation(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 6)
+
+	if err := comp.SetSurroundPairs("9.1.4"); !errors.Is(err, ErrUnknownLayout) {
+		t.Errorf("Expected ErrUnknownLayout for an unknown layout, got %v", err)
+	}
+
+	if err := comp.SetSurroundPairs("quad"); !errors.Is(err, ErrUnknownLayout) {
+		t.Errorf("Expected ErrUnknownLayout for a channel mismatch, got %v", err)
+	}
+
+	if err := comp.SetLinkGroups([][]int{{0, 1}, {1, 2}}); !errors.Is(err, ErrInvalidLinkGroups) {
+		t.Errorf("Expected ErrInvalidLinkGroups for overlapping groups, got %v", err)
+	}
+}
+
+// TestSurroundChannels verifies the channel count reported for each layout.
+func TestSurroundChannels(t *testing.T) {
+	t.Parallel()
+
+	for layout, want := range map[string]int{"quad": 4, "5.1": 6, "7.1": 8} {
+		got, err := SurroundChannels(layout)
+		if err != nil || got != want {
+			t.Errorf("SurroundChannels(%q) = %d, %v; want %d", layout, got, err, want)
+		}
+
+		if err := NewSoftKneeCompressor(48000.0, got).SetSurroundPairs(layout); err != nil {
+			t.Errorf("SetSurroundPairs(%q) failed with its own channel count: %v", layout, err)
+		}
+	}
+
+	if _, err := SurroundChannels("stereo"); !errors.Is(err, ErrUnknownLayout) {
+		t.Errorf("Expected ErrUnknownLayout for an unknown layout, got %v", err)
+	}
+}
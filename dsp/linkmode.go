@@ -0,0 +1,80 @@
+package dsp
+
+import "math"
+
+// LinkMode selects how the channels of a frame share gain reduction.
+type LinkMode int
+
+const (
+	// LinkDetector applies each channel's own gain, computed from its own key
+	// or the key shared through link groups or a sidechain source (default).
+	LinkDetector LinkMode = iota
+
+	// LinkMaxReduction lets every channel detect independently but applies the
+	// deepest gain reduction of all channels to each of them, so any channel
+	// can trigger compression while the stereo image is preserved.
+	LinkMaxReduction
+)
+
+// SetLinkMode selects how channels share gain reduction. Only ProcessChannels
+// and ProcessFrames see whole frames, so the mode applies there. Unknown values
+// are ignored.
+func (c *SoftKneeCompressor) SetLinkMode(mode LinkMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if mode != LinkDetector && mode != LinkMaxReduction {
+		return
+	}
+
+	c.linkMode = mode
+}
+
+// GetLinkMode returns how channels share gain reduction.
+func (c *SoftKneeCompressor) GetLinkMode() LinkMode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.linkMode
+}
+
+// processFrame runs one interleaved frame with the keys from updateFrameKeys
+// and leaves the outputs and applied gains in frameOutput and frameGain
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) processFrame(frame []float32) {
+	if c.linkMode != LinkMaxReduction || c.bypass {
+		for ch, sample := range frame {
+			c.frameOutput[ch], c.frameGain[ch] = c.processSampleKeyed64(float64(sample), c.frameKey[ch], ch)
+		}
+
+		return
+	}
+
+	// Compute every channel's gain first, then apply the deepest to all. A
+	// sleeping channel passes at unity, so a frame of sleeping channels does too
+	linked := math.Inf(1)
+
+	for ch, sample := range frame {
+		c.frameOutput[ch] = c.delayLookahead(float64(sample), ch)
+
+		if c.sleeping(c.frameKey[ch], ch) {
+			linked = math.Min(linked, 1.0)
+
+			continue
+		}
+
+		c.advanceDetector(c.frameKey[ch], ch)
+
+		gain := c.intervalGain(ch)
+		if math.IsNaN(gain) {
+			gain = 1.0
+		}
+
+		linked = math.Min(linked, c.preserveTransient(gain, ch))
+	}
+
+	for ch := range frame {
+		c.frameOutput[ch] = c.applyGain(c.frameOutput[ch], c.frameKey[ch], linked, ch)
+		c.frameGain[ch] = linked
+	}
+}
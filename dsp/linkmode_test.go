@@ -0,0 +1,78 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestLinkMaxReduction verifies a loud left channel pulls the quiet right
+// channel down by the same (deeper) reduction, while without the link mode
+// the right channel passes unchanged.
+func TestLinkMaxReduction(t *testing.T) {
+	t.Parallel()
+
+	newComp := func(mode LinkMode) *SoftKneeCompressor {
+		comp := NewSoftKneeCompressor(48000.0, 2)
+		comp.SetAutoMakeup(false)
+		comp.SetMakeupGain(0.0)
+		comp.SetThreshold(-20.0)
+		comp.SetRatio(4.0)
+		comp.SetLinkMode(mode)
+
+		return comp
+	}
+
+	levels := []float32{0.9, 0.01}
+
+	linked := surroundGains(t, newComp(LinkMaxReduction), levels)
+	independent := surroundGains(t, newComp(LinkDetector), levels)
+
+	if linked[0] > 0.5 {
+		t.Fatalf("Left channel gain %.3f, expected clear gain reduction", linked[0])
+	}
+
+	if math.Abs(linked[1]-linked[0]) > 1e-6 {
+		t.Errorf("Right gain %.4f, want the left's reduction %.4f", linked[1], linked[0])
+	}
+
+	if math.Abs(linked[0]-independent[0]) > 1e-6 {
+		t.Errorf("Left gain %.4f, want its own reduction %.4f", linked[0], independent[0])
+	}
+
+	if math.Abs(independent[1]-1.0) > 1e-6 {
+		t.Errorf("Right gain without linking %.4f, want unity", independent[1])
+	}
+
+	comp := newComp(LinkMaxReduction)
+	comp.SetLinkMode(LinkMode(42))
+
+	if got := comp.GetLinkMode(); got != LinkMaxReduction {
+		t.Errorf("Unknown mode changed the link mode to %d", got)
+	}
+}
+
+// TestLinkMaxReductionAsleep verifies a frame whose channels are all asleep
+// passes at unity instead of being muted by an unset linked gain.
+func TestLinkMaxReductionAsleep(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetAutoMakeup(false)
+	comp.SetMakeupGain(0.0)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(4.0)
+	comp.SetLinkMode(LinkMaxReduction)
+	comp.SetAutoSleep(true, -90.0, 10.0)
+
+	gains := surroundGains(t, comp, []float32{1e-5, 1e-5})
+
+	if comp.SleptSamples(0) == 0 || comp.SleptSamples(1) == 0 {
+		t.Fatal("Expected both channels to fall asleep on -100 dBFS input")
+	}
+
+	for ch, gain := range gains {
+		if math.Abs(gain-1.0) > 1e-6 {
+			t.Errorf("Channel %d gain %.4f while asleep, want unity", ch, gain)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package dsp
+
+import "math"
+
+const (
+	// Maximum lookahead time in milliseconds.
+	maxLookaheadMs = 20.0
+
+	// Highest sample rate the lookahead delay lines are allocated for, so a
+	// sample rate change on the audio thread never allocates. Above it the
+	// lookahead is shortened to fit.
+	maxLookaheadRate = 384000.0
+)
+
+// SetLookahead delays the audio by timeMs milliseconds while the detector keeps
+// reading the undelayed signal, so gain reduction is already in place when a
+// transient reaches the output. With an external sidechain the key drives the
+// detector undelayed as well. The delay adds the same latency to every channel
+// and is clamped to 0-20 ms; 0 disables it.
+func (c *SoftKneeCompressor) SetLookahead(timeMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(timeMs) {
+		return
+	}
+
+	c.lookaheadMs = math.Max(0.0, math.Min(maxLookaheadMs, timeMs))
+
+	if reserve := int(math.Ceil(c.lookaheadMs * 0.001 * maxLookaheadRate)); reserve > c.lookaheadReserved {
+		c.lookaheadBuf = make([]float64, reserve*c.channels)
+		c.lookaheadReserved = reserve
+		c.lookaheadSamples = 0
+	}
+
+	c.updateLookahead()
+}
+
+// GetLookahead returns the lookahead time in milliseconds.
+func (c *SoftKneeCompressor) GetLookahead() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lookaheadMs
+}
+
+// LatencySamples returns the delay the compressor and limiter lookaheads and
+// the maximizer add to the audio in samples.
+func (c *SoftKneeCompressor) LatencySamples() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	latency := c.lookaheadSamples + c.limiterSamples
+	if c.maximize {
+		latency += maximizeDelay
+	}
+
+	return latency
+}
+
+// updateLookahead sizes the per-channel delay lines for the current lookahead
+// and sample rate within the buffer SetLookahead allocated, and clears them
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateLookahead() {
+	samples := min(int(math.Round(c.lookaheadMs*0.001*c.sampleRate)), c.lookaheadReserved)
+	if samples == c.lookaheadSamples {
+		return
+	}
+
+	c.lookaheadSamples = samples
+	c.clearLookahead()
+
+	for ch := range c.lookaheadPos {
+		c.lookaheadPos[ch] = 0
+	}
+}
+
+// clearLookahead silences the delay lines (internal, assumes lock held).
+func (c *SoftKneeCompressor) clearLookahead() {
+	clear(c.lookaheadBuf[:c.lookaheadSamples*c.channels])
+}
+
+// delayLookahead pushes a sample into the channel's delay line and returns the
+// sample leaving it (internal, assumes lock held).
+func (c *SoftKneeCompressor) delayLookahead(sample float64, channel int) float64 {
+	if c.lookaheadSamples == 0 {
+		return sample
+	}
+
+	pos := c.lookaheadPos[channel]
+	idx := channel*c.lookaheadSamples + pos
+	delayed := c.lookaheadBuf[idx]
+	c.lookaheadBuf[idx] = sample
+
+	c.lookaheadPos[channel] = (pos + 1) % c.lookaheadSamples
+
+	return delayed
+}
@@ -0,0 +1,127 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// renderSidechainStep runs a step at onset through the compressor with an
+// external key carrying the same step and returns the output.
+func renderSidechainStep(lookaheadMs float64, onset, length int) ([]float32, *SoftKneeCompressor) {
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(10.0)
+	comp.SetKnee(0.0)
+	comp.SetAttack(2.0)
+	comp.SetMakeupGain(0.0)
+	comp.SetStartupFade(0.0)
+	comp.SetLookahead(lookaheadMs)
+
+	step := make([]float32, length)
+	for i := onset; i < length; i++ {
+		step[i] = 0.9
+	}
+
+	out := make([]float32, length)
+	comp.ProcessBlockSidechain(step, step, out, 0)
+
+	return out, comp
+}
+
+// TestLookaheadSidechain verifies that with an external sidechain the main
+// audio is delayed by the lookahead while the key is read undelayed, so the
+// gain reduction is in place before the transient reaches the output.
+func TestLookaheadSidechain(t *testing.T) {
+	t.Parallel()
+
+	const (
+		onset  = 4800
+		length = 9600
+	)
+
+	plain, _ := renderSidechainStep(0.0, onset, length)
+	ahead, comp := renderSidechainStep(5.0, onset, length)
+
+	delay := comp.LatencySamples()
+	if delay != 240 {
+		t.Fatalf("5 ms lookahead at 48 kHz should delay by 240 samples, got %d", delay)
+	}
+
+	if ahead[onset+delay-1] != 0 || ahead[onset+delay] == 0 {
+		t.Errorf("The main signal should reach the output %d samples late", delay)
+	}
+
+	if peak := maxAbs(plain[onset:]); peak < 0.6 {
+		t.Errorf("Without lookahead the 2 ms attack should let the transient through, peak %f", peak)
+	}
+
+	if peak := maxAbs(ahead[onset:]); peak > 0.3 {
+		t.Errorf("With lookahead the transient should already be reduced, peak %f", peak)
+	}
+}
+
+// TestLookaheadBypassKeepsLatency verifies that bypass keeps the audio delayed
+// so toggling it doesn't shift the signal in time.
+func TestLookaheadBypassKeepsLatency(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetLookahead(1.0)
+	comp.SetBypass(true)
+
+	in := make([]float32, 200)
+	for i := range in {
+		in[i] = float32(i + 1)
+	}
+
+	out := make([]float32, len(in))
+	comp.ProcessBlock(in, out, 0)
+
+	for i := range out {
+		want := float32(0)
+		if i >= 48 {
+			want = in[i-48]
+		}
+
+		if out[i] != want {
+			t.Fatalf("Sample %d = %f, want %f", i, out[i], want)
+		}
+	}
+}
+
+// maxAbs returns the largest magnitude in a buffer.
+func maxAbs(samples []float32) float64 {
+	peak := 0.0
+	for _, sample := range samples {
+		peak = math.Max(peak, math.Abs(float64(sample)))
+	}
+
+	return peak
+}
+
+// TestLookaheadRateChangeNoAlloc verifies a sample rate change resizes the
+// lookahead and limiter delays without allocating, as it happens on the audio
+// thread.
+//
+//nolint:paralleltest // AllocsPerRun counts allocations process-wide
+func TestLookaheadRateChangeNoAlloc(t *testing.T) {
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetLookahead(5.0)
+	comp.SetLimiterLookahead(2.0)
+
+	rate := 48000.0
+
+	allocs := testing.AllocsPerRun(10, func() {
+		rate = 144000.0 - rate
+		comp.SetSampleRate(rate)
+	})
+	if allocs != 0 {
+		t.Errorf("Sample rate change allocated %.0f times, want none", allocs)
+	}
+
+	comp.SetSampleRate(96000.0)
+
+	if got := comp.LatencySamples(); got != 480+192 {
+		t.Errorf("Latency %d samples at 96 kHz, want %d", got, 480+192)
+	}
+}
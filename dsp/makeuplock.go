@@ -0,0 +1,22 @@
+package dsp
+
+// SetLinkMakeupToThreshold locks the manual makeup gain to the threshold:
+// every threshold change moves the makeup by the opposite amount, so lowering
+// the threshold by 3 dB raises the makeup by 3 dB. Sweeping the threshold then
+// keeps heavily compressed material at a constant output level, which makes
+// comparing threshold settings by ear fair. Auto makeup already follows the
+// threshold, so the lock only acts on manual makeup.
+func (c *SoftKneeCompressor) SetLinkMakeupToThreshold(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.makeupThresholdLock = enabled
+}
+
+// GetLinkMakeupToThreshold returns whether the manual makeup follows the threshold.
+func (c *SoftKneeCompressor) GetLinkMakeupToThreshold() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.makeupThresholdLock
+}
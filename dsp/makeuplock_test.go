@@ -0,0 +1,90 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestLinkMakeupToThreshold verifies sweeping the threshold with the lock keeps
+// the output of a heavily compressed tone level, while it drops without the lock.
+func TestLinkMakeupToThreshold(t *testing.T) {
+	t.Parallel()
+
+	in := make([]float32, 4800)
+	for i := range in {
+		in[i] = float32(0.9 * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+	}
+
+	// Settled output peak in dBFS at every threshold of the sweep
+	sweep := func(locked bool) []float64 {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetRatio(50.0)
+		comp.SetKnee(0.0)
+		comp.SetAttack(0.1)
+		comp.SetRelease(200.0)
+		comp.SetThreshold(-10.0)
+		comp.SetMakeupGain(0.0)
+		comp.SetLinkMakeupToThreshold(locked)
+
+		levels := []float64{}
+		out := make([]float32, len(in))
+
+		for threshold := -10.0; threshold >= -30.0; threshold -= 5.0 {
+			comp.SetThreshold(threshold)
+
+			for range 5 { // 500 ms to settle the envelope and makeup smoothing
+				comp.ProcessBlock(in, out, 0)
+			}
+
+			peak := 0.0
+			for _, sample := range out {
+				peak = math.Max(peak, math.Abs(float64(sample)))
+			}
+
+			levels = append(levels, LinearToDB(peak))
+		}
+
+		return levels
+	}
+
+	// At 50:1 the output still moves by 1/50 of the 20 dB sweep
+	locked := sweep(true)
+	for i, level := range locked {
+		if math.Abs(level-locked[0]) > 1.0 {
+			t.Errorf("Locked output at step %d is %.2f dBFS, want %.2f dBFS", i, level, locked[0])
+		}
+	}
+
+	if free := sweep(false); free[0]-free[len(free)-1] < 15.0 {
+		t.Errorf("Without the lock the output should drop with the threshold, got %.2f to %.2f dBFS",
+			free[0], free[len(free)-1])
+	}
+}
+
+// TestLinkMakeupToThresholdManualOnly verifies the lock leaves auto makeup alone
+// and moves the manual makeup by the opposite of the threshold change.
+func TestLinkMakeupToThresholdManualOnly(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(-20.0)
+	comp.SetMakeupGain(4.0)
+	comp.SetLinkMakeupToThreshold(true)
+	comp.SetThreshold(-26.0)
+
+	if got := comp.GetMakeupGain(); got != 10.0 {
+		t.Errorf("Makeup after lowering the threshold by 6 dB is %.2f dB, want 10 dB", got)
+	}
+
+	comp.SetAutoMakeup(true)
+	auto := comp.GetMakeupGain()
+	comp.SetThreshold(-20.0)
+
+	if got := comp.GetMakeupGain(); got >= auto {
+		t.Errorf("Auto makeup should follow its own formula, got %.2f dB after raising the threshold from %.2f dB", got, auto)
+	}
+
+	if !comp.GetLinkMakeupToThreshold() {
+		t.Error("GetLinkMakeupToThreshold should report the lock")
+	}
+}
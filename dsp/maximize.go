@@ -0,0 +1,157 @@
+package dsp
+
+import "math"
+
+const (
+	// Range of the maximize true-peak ceiling in dBTP.
+	minMaximizeTargetDB = -20.0
+	maxMaximizeTargetDB = 0.0
+
+	// Largest boost the maximizer applies in dB, so silence and noise floors
+	// aren't pushed up without bound.
+	maxMaximizeBoostDB = 30.0
+
+	// Shortest limiter lookahead in milliseconds while maximizing.
+	minMaximizeLookaheadMs = 1.5
+
+	// Time the input true peak driving the boost is held, and its release
+	// time, in seconds; long enough that the boost follows the program level
+	// rather than individual peaks.
+	maximizeHoldSec    = 0.5
+	maximizeReleaseSec = 2.0
+
+	// Smoothing time of the boost in milliseconds.
+	maximizeBoostMs = 200.0
+
+	// Samples the oversampling filter lags behind its newest input: the
+	// interpolated samples lie between history[maximizeDelay] and the one
+	// after it.
+	maximizeDelay = truePeakTaps / 2
+)
+
+// maximizer is the state of one channel's maximizer stage in front of its
+// lookahead limiter.
+type maximizer struct {
+	history  [truePeakTaps]float64 // Oversampling filter history of the unboosted signal
+	lastPeak float64               // Interpolated peak of the previous sample interval
+	peak     float64               // Held true peak driving the boost
+	hold     int                   // Samples left before the held peak releases
+	boost    float64               // Smoothed linear boost
+}
+
+// reset clears the filter history and the held peak and returns to unity boost.
+func (m *maximizer) reset() {
+	*m = maximizer{boost: 1.0}
+}
+
+// SetMaximize turns the output limiter into a maximizer for loudness: the
+// output is boosted until its true peaks reach targetDBTP (-20..0 dBTP) and
+// the lookahead limiter holds the true peaks of the boosted signal at that
+// ceiling. The boost follows the true peak of all channels, held for 0.5 s and
+// released over 2 s, is smoothed over 200 ms and never exceeds 30 dB. Peaks
+// are measured with the 4x oversampling filter of the true-peak meter (see
+// SetTruePeakMetering), and the hard clipper ceiling still applies if lower.
+// Maximizing needs at least 1.5 ms of limiter lookahead, which it enables if
+// SetLimiterLookahead is shorter, plus 6 samples for the oversampling filter;
+// both add to LatencySamples. A positive target disables the maximizer.
+func (c *SoftKneeCompressor) SetMaximize(targetDBTP float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(targetDBTP) {
+		return
+	}
+
+	enabled := targetDBTP <= maxMaximizeTargetDB
+	if enabled && !c.maximize {
+		for ch := range c.maximizers {
+			c.maximizers[ch].reset()
+		}
+	}
+
+	c.maximize = enabled
+
+	if enabled {
+		c.maximizeTargetDB = math.Max(minMaximizeTargetDB, targetDBTP)
+		c.maximizeCeiling = DBToLinear(c.maximizeTargetDB)
+	}
+
+	c.reserveLimiters()
+	c.updateLimiterLookahead()
+}
+
+// GetMaximize returns whether the maximizer is enabled and its true-peak
+// ceiling in dBTP.
+func (c *SoftKneeCompressor) GetMaximize() (enabled bool, targetDBTP float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.maximize, c.maximizeTargetDB
+}
+
+// updateMaximizeConstants recalculates the sample-rate dependent maximizer
+// coefficients (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateMaximizeConstants() {
+	c.maximizeHoldLen = int(maximizeHoldSec * c.sampleRate)
+	c.maximizeRelease = math.Exp(-1.0 / (maximizeReleaseSec * c.sampleRate))
+	c.maximizeBoostCoeff = smoothingCoeff(maximizeBoostMs, c.sampleRate)
+}
+
+// maximizeOutput boosts a sample towards the maximize ceiling and runs it
+// through the channel's lookahead limiter, which holds the boosted true peaks
+// at the ceiling (internal, assumes lock held).
+func (c *SoftKneeCompressor) maximizeOutput(sample float64, channel int) float64 {
+	m := &c.maximizers[channel]
+
+	// The filter lags by maximizeDelay samples, so the audio is taken from its
+	// history to line up with the interpolated peaks around it
+	interval := oversampledPeak(&m.history, sample)
+	delayed := m.history[maximizeDelay]
+	level := math.Max(math.Max(interval, m.lastPeak), math.Abs(delayed))
+	m.lastPeak = interval
+
+	switch {
+	case level >= m.peak:
+		m.peak = level
+		m.hold = c.maximizeHoldLen
+	case m.hold > 0:
+		m.hold--
+	default:
+		m.peak *= c.maximizeRelease
+	}
+
+	peak := 0.0
+	for ch := range c.maximizers {
+		peak = math.Max(peak, c.maximizers[ch].peak)
+	}
+
+	boost := DBToLinear(maxMaximizeBoostDB)
+	if peak*boost > c.maximizeCeiling {
+		boost = c.maximizeCeiling / peak
+	}
+
+	m.boost += (boost - m.boost) * c.maximizeBoostCoeff
+
+	ceiling := c.maximizeCeiling
+	if c.hardClip {
+		ceiling = math.Min(ceiling, c.hardClipCeiling)
+	}
+
+	target := 1.0
+	if boosted := level * m.boost; boosted > ceiling {
+		target = ceiling / boosted
+	}
+
+	out, gain := c.limiters[channel].process(delayed*m.boost, target, c.limiterReleaseCoeff)
+
+	return out * gain
+}
+
+// delayMaximizer runs a bypassed sample through the maximizer's filter delay
+// without boosting it, so the latency stays the same (internal, assumes lock held).
+func (c *SoftKneeCompressor) delayMaximizer(sample float64, channel int) float64 {
+	m := &c.maximizers[channel]
+	m.lastPeak = oversampledPeak(&m.history, sample)
+
+	return m.history[maximizeDelay]
+}
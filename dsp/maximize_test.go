@@ -0,0 +1,104 @@
+package dsp
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// maximizeTruePeak runs a quiet signal through the maximizer and returns the
+// output true peak over the whole run and over the 200 ms before the burst,
+// once the boost has settled. The signal is a tone at a quarter of the sample rate with a 45 degree phase, whose
+// crest every sample misses by 3 dB, mixed with noise and jumping 12 dB
+// louder for a burst halfway through that the limiter has to catch.
+func maximizeTruePeak(targetDBTP float64) (overall, settled float64) {
+	const (
+		sampleRate = 48000.0
+		blockSize  = 480
+		blocks     = 300
+	)
+
+	rng := rand.New(rand.NewPCG(5, 6))
+
+	comp := NewSoftKneeCompressor(sampleRate, 1)
+	comp.SetAutoMakeup(false)
+	comp.SetMakeupGain(0.0)
+	comp.SetTruePeakMetering(true)
+	comp.SetMaximize(targetDBTP)
+
+	in := make([]float32, blockSize)
+	out := make([]float32, blockSize)
+
+	for n := range blocks {
+		switch n {
+		case blocks/2 - 20:
+			overall = comp.TruePeak(0)
+			comp.ResetTruePeaks()
+		case blocks / 2:
+			settled = comp.TruePeak(0)
+		}
+
+		level := DBToLinear(-30.0)
+		if n >= blocks/2 && n < blocks/2+5 {
+			level = DBToLinear(-18.0)
+		}
+
+		for i := range in {
+			phase := 2.0*math.Pi*float64(n*blockSize+i)/4.0 + math.Pi/4.0
+			in[i] = float32(level * (math.Sin(phase) + 0.1*rng.NormFloat64()))
+		}
+
+		comp.ProcessBlock(in, out, 0)
+	}
+
+	return math.Max(overall, comp.TruePeak(0)), settled
+}
+
+// TestMaximize verifies a quiet signal is pushed up to the true-peak ceiling
+// and a sudden louder burst doesn't exceed it.
+func TestMaximize(t *testing.T) {
+	t.Parallel()
+
+	const targetDBTP = -1.0
+
+	overall, settled := maximizeTruePeak(targetDBTP)
+	ceiling := DBToLinear(targetDBTP)
+
+	// Allow for float32 rounding of the output only
+	if overall > ceiling*DBToLinear(0.001) {
+		t.Errorf("True peak %.2f dBTP exceeds the %.1f dBTP ceiling", 20*math.Log10(overall), targetDBTP)
+	}
+
+	if settled < ceiling*DBToLinear(-0.2) {
+		t.Errorf("True peak %.2f dBTP after settling, want the %.1f dBTP ceiling", 20*math.Log10(settled), targetDBTP)
+	}
+}
+
+// TestMaximizeLatency verifies the maximizer reports the limiter lookahead it
+// enables plus the oversampling filter delay, and that a positive target
+// disables it again.
+func TestMaximizeLatency(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetMaximize(-1.0)
+
+	if enabled, target := comp.GetMaximize(); !enabled || target != -1.0 {
+		t.Errorf("GetMaximize = %t, %.1f dBTP, want enabled at -1 dBTP", enabled, target)
+	}
+
+	// 1.5 ms of limiter lookahead at 48 kHz plus the filter delay
+	if got := comp.LatencySamples(); got != 72+maximizeDelay {
+		t.Errorf("Latency %d samples while maximizing, want %d", got, 72+maximizeDelay)
+	}
+
+	comp.SetMaximize(1.0)
+
+	if enabled, _ := comp.GetMaximize(); enabled {
+		t.Error("A positive target should disable the maximizer")
+	}
+
+	if got := comp.LatencySamples(); got != 0 {
+		t.Errorf("Latency %d samples after disabling the maximizer, want 0", got)
+	}
+}
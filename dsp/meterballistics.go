@@ -0,0 +1,94 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Default input and output level meter ballistics in milliseconds (instant
+// attack, peak-meter style release).
+const (
+	defaultLevelMeterAttackMs  = 0.0
+	defaultLevelMeterReleaseMs = 300.0
+)
+
+// MeterKind selects the meter SetMeterBallistics configures.
+type MeterKind int
+
+const (
+	// MeterInput is the input level meter.
+	MeterInput MeterKind = iota
+
+	// MeterOutput is the output level meter.
+	MeterOutput
+
+	// MeterGainReduction is the gain-reduction meter.
+	MeterGainReduction
+
+	meterKinds // Number of meter kinds
+)
+
+// meterBallistics holds the attack and release times of one meter.
+type meterBallistics struct {
+	attackMs  float64
+	releaseMs float64
+}
+
+// defaultMeterBallistics returns the ballistics of every meter kind.
+func defaultMeterBallistics() [meterKinds]meterBallistics {
+	return [meterKinds]meterBallistics{
+		MeterInput:         {defaultLevelMeterAttackMs, defaultLevelMeterReleaseMs},
+		MeterOutput:        {defaultLevelMeterAttackMs, defaultLevelMeterReleaseMs},
+		MeterGainReduction: {defaultGRMeterAttackMs, defaultGRMeterReleaseMs},
+	}
+}
+
+// SetMeterBallistics sets the attack and release times of one meter kind in
+// milliseconds, so e.g. a peaky input can use a slower release than the
+// compressed output. They only shape the displayed readings and are
+// independent of the detector's attack/release. Unknown kinds are ignored.
+func (c *SoftKneeCompressor) SetMeterBallistics(kind MeterKind, attackMs, releaseMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if kind < 0 || kind >= meterKinds || !isFinite(attackMs) || !isFinite(releaseMs) {
+		return
+	}
+
+	c.meterBallistics[kind] = meterBallistics{
+		attackMs:  math.Max(attackMs, 0.0),
+		releaseMs: math.Max(releaseMs, 0.0),
+	}
+}
+
+// GetMeterBallistics returns the attack and release times of one meter kind
+// in milliseconds, or zeros for an unknown kind.
+func (c *SoftKneeCompressor) GetMeterBallistics(kind MeterKind) (float64, float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if kind < 0 || kind >= meterKinds {
+		return 0.0, 0.0
+	}
+
+	return c.meterBallistics[kind].attackMs, c.meterBallistics[kind].releaseMs
+}
+
+// updateLevelMeter moves a channel's ballistic level reading towards one
+// block's peak (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateLevelMeter(meter []uint64, kind MeterKind, channel int, peak float64, samples int) {
+	if samples == 0 {
+		return
+	}
+
+	reading := math.Float64frombits(atomic.LoadUint64(&meter[channel]))
+
+	timeMs := c.meterBallistics[kind].releaseMs
+	if peak > reading {
+		timeMs = c.meterBallistics[kind].attackMs
+	}
+
+	reading += (peak - reading) * ballisticsCoeff(timeMs, samples, c.sampleRate)
+
+	atomic.StoreUint64(&meter[channel], math.Float64bits(reading))
+}
@@ -0,0 +1,83 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestMeterBallisticsPerKind verifies the input, output and gain-reduction
+// meters each decay with their own release and that setting one kind leaves
+// the others alone.
+func TestMeterBallisticsPerKind(t *testing.T) {
+	t.Parallel()
+
+	const blockSize = 480 // 10 ms at 48 kHz
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(0.1)
+	comp.SetRelease(5.0)
+	comp.SetMeterBallistics(MeterInput, 0.0, 1000.0)
+	comp.SetMeterBallistics(MeterOutput, 0.0, 50.0)
+	comp.SetMeterBallistics(MeterGainReduction, 0.0, 200.0)
+
+	for kind, want := range map[MeterKind][2]float64{
+		MeterInput:         {0.0, 1000.0},
+		MeterOutput:        {0.0, 50.0},
+		MeterGainReduction: {0.0, 200.0},
+	} {
+		if attack, release := comp.GetMeterBallistics(kind); attack != want[0] || release != want[1] {
+			t.Errorf("Meter kind %d has attack %f, release %f, want %v", kind, attack, release, want)
+		}
+	}
+
+	loud := make([]float32, blockSize)
+	for i := range loud {
+		loud[i] = 0.9
+	}
+
+	silence := make([]float32, blockSize)
+
+	// Let the detector recover so every meter only decays from here
+	comp.ProcessBlock(loud, make([]float32, blockSize), 0)
+	comp.ProcessBlock(silence, make([]float32, blockSize), 0)
+	comp.ProcessBlock(silence, make([]float32, blockSize), 0)
+
+	start := comp.GetMeters()
+
+	// 100 ms of silence decays every meter by its own time constant
+	for range 10 {
+		comp.ProcessBlock(silence, make([]float32, blockSize), 0)
+	}
+
+	meters := comp.GetMeters()
+
+	tests := []struct {
+		name      string
+		start     float64
+		got       float64
+		releaseMs float64
+	}{
+		{"Input", start.InputMeterL, meters.InputMeterL, 1000.0},
+		{"Output", start.OutputMeterL, meters.OutputMeterL, 50.0},
+		{"Gain reduction", start.GainReductionMeterL, meters.GainReductionMeterL, 200.0},
+	}
+
+	for _, tt := range tests {
+		if tt.start <= 0.0 {
+			t.Fatalf("%s meter should read the loud block, got %f", tt.name, tt.start)
+		}
+
+		want := tt.start * math.Exp(-100.0/tt.releaseMs)
+		if math.Abs(tt.got-want) > 0.01*tt.start {
+			t.Errorf("%s meter decayed to %f, want %f", tt.name, tt.got, want)
+		}
+	}
+
+	// An unknown kind changes nothing
+	comp.SetMeterBallistics(meterKinds, 5.0, 5.0)
+
+	if attack, release := comp.GetMeterBallistics(MeterInput); attack != 0.0 || release != 1000.0 {
+		t.Errorf("Unknown kind changed the input ballistics to %f/%f", attack, release)
+	}
+}
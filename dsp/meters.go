@@ -0,0 +1,418 @@
+package dsp
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+const (
+	// Time constant of the slow gain-reduction average in seconds.
+	grAverageTimeSec = 3.0
+
+	// Default gain-reduction meter ballistics in milliseconds (instant attack, slow release).
+	defaultGRMeterAttackMs  = 0.0
+	defaultGRMeterReleaseMs = 300.0
+
+	// Time constant of the DC-offset meter in seconds.
+	dcMeterTimeSec = 1.0
+
+	// Time constant of the input level average behind the balance reading in seconds.
+	inputAverageTimeSec = 1.0
+
+	// Maximum number of gain-reduction segments per block.
+	maxGRSegments = 64
+)
+
+// publishMeters stores one block's meter values for a channel: the peaks, the
+// deepest gain and the average gain over the block (internal, assumes lock held).
+func (c *SoftKneeCompressor) publishMeters(channel int, maxInput, maxOutput, minGain, meanGain float64, samples int) {
+	c.updateGainReductionAverage(channel, minGain, samples)
+	c.updateGainReductionMeter(channel, minGain, samples)
+	c.updateLevelMeter(c.inputMeter, MeterInput, channel, maxInput, samples)
+	c.updateLevelMeter(c.outputMeter, MeterOutput, channel, maxOutput, samples)
+	c.updateInputAverage(channel, maxInput, samples)
+	c.updateGainStaging(channel, maxInput, samples)
+	c.updateAutoThreshold(channel, samples)
+	c.updatePeakHold(channel, maxInput, maxOutput, samples)
+	c.updateSession(channel, maxInput, maxOutput, minGain, samples)
+
+	// Flag input that already arrives at or above full scale (upstream gain staging)
+	var clipped uint32
+	if maxInput >= 1.0 {
+		clipped = 1
+	}
+
+	atomic.StoreUint32(&c.inputClip[channel], clipped)
+
+	// Update atomic meters
+	atomic.StoreUint64(&c.inputPeak[channel], math.Float64bits(maxInput))
+	atomic.StoreUint64(&c.outputPeak[channel], math.Float64bits(maxOutput))
+	atomic.StoreUint64(&c.gainReduction[channel], math.Float64bits(minGain))
+	atomic.StoreUint64(&c.meanGain[channel], math.Float64bits(meanGain))
+
+	c.publishGainReductionSegments(channel)
+
+	// Increment block counter (only on the first channel to avoid counting every channel of a frame)
+	if channel == 0 {
+		atomic.AddUint64(&c.processedBlocks, 1)
+	}
+}
+
+// ChannelMeters holds the current meter values of a single channel.
+type ChannelMeters struct {
+	Input                float64 // Input peak of the last block (linear)
+	Output               float64 // Output peak of the last block (linear)
+	InputMeter           float64 // Input peak with meter ballistics (linear)
+	OutputMeter          float64 // Output peak with meter ballistics (linear)
+	GainReduction        float64 // Minimum gain of the last block (linear)
+	MeanGain             float64 // Average gain of the last block (linear)
+	AverageGainReduction float64 // Slow average of block gain reduction in dB
+	GainReductionDev     float64 // Standard deviation of block gain reduction around the average in dB
+	GainReductionMeter   float64 // Gain reduction with meter ballistics in dB
+	InputClip            bool    // Last input block reached 0 dBFS
+	InputClipCount       uint64  // Raw input samples at or above 0 dBFS since the last clip reset
+	OutputClipCount      uint64  // Output samples at or above 0 dBFS since the last clip reset
+	DCOffset             float64 // DC offset of the raw input
+	AverageInput         float64 // Slow average of the input peak (linear)
+	CrestFactor          float64 // Input peak to RMS ratio of the last block in dB
+	PeakHoldInput        float64 // Held input peak (linear)
+	PeakHoldOutput       float64 // Held output peak (linear)
+	TruePeak             float64 // Highest 4x oversampled output peak since the last true-peak reset (linear)
+}
+
+// GetChannelMeters returns the current meter values of any channel, including
+// those beyond the L/R pair reported by GetMeters.
+func (c *SoftKneeCompressor) GetChannelMeters(channel int) (ChannelMeters, error) {
+	if channel < 0 || channel >= c.channels {
+		return ChannelMeters{}, fmt.Errorf("%w: %d", ErrInvalidChannel, channel)
+	}
+
+	return ChannelMeters{
+		Input:                math.Float64frombits(atomic.LoadUint64(&c.inputPeak[channel])),
+		Output:               math.Float64frombits(atomic.LoadUint64(&c.outputPeak[channel])),
+		InputMeter:           math.Float64frombits(atomic.LoadUint64(&c.inputMeter[channel])),
+		OutputMeter:          math.Float64frombits(atomic.LoadUint64(&c.outputMeter[channel])),
+		GainReduction:        math.Float64frombits(atomic.LoadUint64(&c.gainReduction[channel])),
+		MeanGain:             math.Float64frombits(atomic.LoadUint64(&c.meanGain[channel])),
+		AverageGainReduction: c.AverageGainReductionDB(channel),
+		GainReductionDev:     c.GainReductionDeviationDB(channel),
+		GainReductionMeter:   c.GainReductionMeterDB(channel),
+		InputClip:            c.InputClipped(channel),
+		InputClipCount:       atomic.LoadUint64(&c.inputClips[channel]),
+		OutputClipCount:      atomic.LoadUint64(&c.outputClips[channel]),
+		DCOffset:             c.DCOffset(channel),
+		AverageInput:         math.Float64frombits(atomic.LoadUint64(&c.inputAverage[channel])),
+		CrestFactor:          math.Float64frombits(atomic.LoadUint64(&c.crestFactor[channel])),
+		PeakHoldInput:        math.Float64frombits(atomic.LoadUint64(&c.peakHoldIn[channel])),
+		PeakHoldOutput:       math.Float64frombits(atomic.LoadUint64(&c.peakHoldOut[channel])),
+		TruePeak:             c.TruePeak(channel),
+	}, nil
+}
+
+// storeCrestFactor stores the peak to RMS ratio of a channel's last input
+// block in dB; silent blocks read as 0 dB (internal, assumes lock held).
+func (c *SoftKneeCompressor) storeCrestFactor(channel int, peak, rms float64) {
+	crestDB := 0.0
+	if rms > 0 {
+		crestDB = LinearToDB(peak / rms)
+	}
+
+	atomic.StoreUint64(&c.crestFactor[channel], math.Float64bits(crestDB))
+}
+
+// blockRMS returns the RMS of every stride-th sample, so interleaved buffers
+// can be passed directly.
+func blockRMS[S float32 | float64](samples []S, stride int) float64 {
+	var sum float64
+
+	count := 0
+	for i := 0; i < len(samples); i += stride {
+		sum += float64(samples[i]) * float64(samples[i])
+		count++
+	}
+
+	if count == 0 {
+		return 0.0
+	}
+
+	return math.Sqrt(sum / float64(count))
+}
+
+// ChannelBalance returns the level difference between the averaged left and
+// right input in dB. Positive values lean left, negative values lean right;
+// silence on both channels reads as centered.
+func (m MeterStats) ChannelBalance() float64 {
+	if m.AverageInputL <= 0 && m.AverageInputR <= 0 {
+		return 0.0
+	}
+
+	return LinearToDB(m.AverageInputL) - LinearToDB(m.AverageInputR)
+}
+
+// updateInputAverage folds one block's input peak into the slow per-channel
+// level average (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateInputAverage(channel int, maxInput float64, samples int) {
+	if samples == 0 {
+		return
+	}
+
+	coeff := 1.0 - math.Exp(-float64(samples)/(inputAverageTimeSec*c.sampleRate))
+	avg := math.Float64frombits(atomic.LoadUint64(&c.inputAverage[channel]))
+	avg += (maxInput - avg) * coeff
+
+	atomic.StoreUint64(&c.inputAverage[channel], math.Float64bits(avg))
+}
+
+// updateGainReductionAverage folds one block's gain reduction into the slow
+// per-channel average and variance (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateGainReductionAverage(channel int, minGain float64, samples int) {
+	if samples == 0 {
+		return
+	}
+
+	blockGR := -LinearToDB(minGain)
+	if blockGR < 0 {
+		blockGR = 0
+	}
+
+	coeff := 1.0 - math.Exp(-float64(samples)/(grAverageTimeSec*c.sampleRate))
+	avg := math.Float64frombits(atomic.LoadUint64(&c.grAverage[channel]))
+	variance := math.Float64frombits(atomic.LoadUint64(&c.grVariance[channel]))
+
+	// Exponentially weighted mean and variance over the same window
+	diff := blockGR - avg
+	avg += diff * coeff
+	variance = (1.0 - coeff) * (variance + diff*diff*coeff)
+
+	atomic.StoreUint64(&c.grAverage[channel], math.Float64bits(avg))
+	atomic.StoreUint64(&c.grVariance[channel], math.Float64bits(variance))
+}
+
+// AverageGainReductionDB returns the slow rolling average of the per-block
+// gain reduction for a channel in dB (positive values mean reduction).
+func (c *SoftKneeCompressor) AverageGainReductionDB(channel int) float64 {
+	if channel < 0 || channel >= c.channels {
+		return 0.0
+	}
+
+	return math.Float64frombits(atomic.LoadUint64(&c.grAverage[channel]))
+}
+
+// GainReductionDeviationDB returns the standard deviation of the per-block gain
+// reduction around AverageGainReductionDB in dB, over the same window. It
+// indicates how steady the compression is: a fraction of a dB means steady
+// leveling, several dB mean the gain reduction reacts strongly to the program.
+func (c *SoftKneeCompressor) GainReductionDeviationDB(channel int) float64 {
+	if channel < 0 || channel >= c.channels {
+		return 0.0
+	}
+
+	return math.Sqrt(math.Float64frombits(atomic.LoadUint64(&c.grVariance[channel])))
+}
+
+// InputClipped reports whether the last block of a channel contained input at
+// or above 0 dBFS, before any processing.
+func (c *SoftKneeCompressor) InputClipped(channel int) bool {
+	if channel < 0 || channel >= c.channels {
+		return false
+	}
+
+	return atomic.LoadUint32(&c.inputClip[channel]) != 0
+}
+
+// InputClipCount returns how many raw input samples of a channel reached or
+// exceeded 0 dBFS since the last ResetClipCounts. Unlike the input meters it
+// counts before any sidechain filtering or processing, so it shows whether
+// distortion already arrives from the source; compare with OutputClipCount.
+func (c *SoftKneeCompressor) InputClipCount(channel int) uint64 {
+	if channel < 0 || channel >= c.channels {
+		return 0
+	}
+
+	return atomic.LoadUint64(&c.inputClips[channel])
+}
+
+// OutputClipCount returns how many output samples of a channel reached or
+// exceeded 0 dBFS since the last ResetClipCounts.
+func (c *SoftKneeCompressor) OutputClipCount(channel int) uint64 {
+	if channel < 0 || channel >= c.channels {
+		return 0
+	}
+
+	return atomic.LoadUint64(&c.outputClips[channel])
+}
+
+// ResetClipCounts clears the input and output clip counts of every channel.
+func (c *SoftKneeCompressor) ResetClipCounts() {
+	for ch := range c.channels {
+		atomic.StoreUint64(&c.inputClips[ch], 0)
+		atomic.StoreUint64(&c.outputClips[ch], 0)
+	}
+}
+
+// countClip counts a sample magnitude at or above full scale.
+func countClip(counts []uint64, channel int, magnitude float64) {
+	if magnitude >= 1.0 {
+		atomic.AddUint64(&counts[channel], 1)
+	}
+}
+
+// DCOffset returns the DC offset of a channel's raw input, measured as a very
+// slow average of the signal. A persistent non-zero value points at grounding
+// or gain-staging problems upstream.
+func (c *SoftKneeCompressor) DCOffset(channel int) float64 {
+	if channel < 0 || channel >= c.channels {
+		return 0.0
+	}
+
+	return math.Float64frombits(atomic.LoadUint64(&c.dcOffset[channel]))
+}
+
+// updateDCOffset runs the DC-offset one-pole over a channel's samples, taking
+// every stride-th sample so interleaved buffers can be passed directly
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateDCOffset(channel int, samples []float32, stride int) {
+	atomic.StoreUint64(&c.dcOffset[channel], math.Float64bits(followDCOffset(c, channel, samples, stride)))
+}
+
+// followDCOffset returns a channel's DC offset after running the one-pole over
+// every stride-th sample (internal, assumes lock held).
+func followDCOffset[S float32 | float64](c *SoftKneeCompressor, channel int, samples []S, stride int) float64 {
+	coeff := 1.0 - math.Exp(-1.0/(dcMeterTimeSec*c.sampleRate))
+	offset := math.Float64frombits(atomic.LoadUint64(&c.dcOffset[channel]))
+
+	for i := 0; i < len(samples); i += stride {
+		offset += (float64(samples[i]) - offset) * coeff
+	}
+
+	return offset
+}
+
+// SetGRMeterBallistics sets the attack and release times of the gain-reduction
+// meter in milliseconds. These only shape the displayed reading and are
+// independent of the detector's attack/release. An attack of 0 shows new
+// reduction peaks instantly.
+func (c *SoftKneeCompressor) SetGRMeterBallistics(attackMs, releaseMs float64) {
+	c.SetMeterBallistics(MeterGainReduction, attackMs, releaseMs)
+}
+
+// GetGRMeterBallistics returns the gain-reduction meter attack and release times in milliseconds.
+func (c *SoftKneeCompressor) GetGRMeterBallistics() (float64, float64) {
+	return c.GetMeterBallistics(MeterGainReduction)
+}
+
+// GainReductionMeterDB returns the gain reduction of a channel with meter
+// ballistics applied, in dB (positive values mean reduction).
+func (c *SoftKneeCompressor) GainReductionMeterDB(channel int) float64 {
+	if channel < 0 || channel >= c.channels {
+		return 0.0
+	}
+
+	return math.Float64frombits(atomic.LoadUint64(&c.grMeter[channel]))
+}
+
+// updateGainReductionMeter moves the ballistic gain-reduction reading towards
+// one block's reduction (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateGainReductionMeter(channel int, minGain float64, samples int) {
+	if samples == 0 {
+		return
+	}
+
+	target := math.Max(-LinearToDB(minGain), 0.0)
+	reading := math.Float64frombits(atomic.LoadUint64(&c.grMeter[channel]))
+
+	timeMs := c.meterBallistics[MeterGainReduction].releaseMs
+	if target > reading {
+		timeMs = c.meterBallistics[MeterGainReduction].attackMs
+	}
+
+	reading += (target - reading) * ballisticsCoeff(timeMs, samples, c.sampleRate)
+
+	// Bypass applies no gain at all, so the meter drops to 0 dB without a
+	// release tail that would suggest otherwise during an A/B comparison
+	if c.bypass {
+		reading = 0.0
+	}
+
+	atomic.StoreUint64(&c.grMeter[channel], math.Float64bits(reading))
+}
+
+// ballisticsCoeff returns the one-pole smoothing coefficient covering a block of
+// samples for the given time constant. A zero time constant jumps immediately.
+func ballisticsCoeff(timeMs float64, samples int, sampleRate float64) float64 {
+	if timeMs <= 0.0 {
+		return 1.0
+	}
+
+	return 1.0 - math.Exp(-float64(samples)/(timeMs*0.001*sampleRate))
+}
+
+// SetGainReductionSegments splits every block into n equal segments and keeps
+// the deepest gain reduction of each, so meters can show the dynamics inside
+// large buffers. n is clamped to 64; 0 disables the segment profile.
+func (c *SoftKneeCompressor) SetGainReductionSegments(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n = max(0, min(n, maxGRSegments))
+
+	for i := range c.grSegments {
+		atomic.StoreUint64(&c.grSegments[i], 0)
+	}
+
+	atomic.StoreUint32(&c.grSegmentCount, uint32(n))
+}
+
+// GainReductionSegments returns the gain reduction in dB of each segment of the
+// channel's last block, oldest first, or nil if segments are disabled.
+func (c *SoftKneeCompressor) GainReductionSegments(channel int) []float64 {
+	count := int(atomic.LoadUint32(&c.grSegmentCount))
+	if count == 0 || channel < 0 || channel >= c.channels {
+		return nil
+	}
+
+	segments := make([]float64, count)
+	for i := range segments {
+		segments[i] = math.Float64frombits(atomic.LoadUint64(&c.grSegments[channel*maxGRSegments+i]))
+	}
+
+	return segments
+}
+
+// resetSegmentGains prepares the channel's segment scratch for a new block
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) resetSegmentGains(channel int) {
+	segments := c.segmentMinGain[channel*maxGRSegments : (channel+1)*maxGRSegments]
+	for i := range segments {
+		segments[i] = 1.0
+	}
+}
+
+// trackSegmentGain folds the gain of sample index out of total into the
+// segments it covers: usually one, but several when the block is shorter than
+// the segment count, so small quanta leave no segment empty (internal, assumes
+// lock held).
+func (c *SoftKneeCompressor) trackSegmentGain(channel, index, total int, gain float64) {
+	count := int(c.grSegmentCount)
+	if count == 0 {
+		return
+	}
+
+	first := index * count / total
+	last := ((index+1)*count - 1) / total
+
+	for segment := first; segment <= last; segment++ {
+		idx := channel*maxGRSegments + segment
+		c.segmentMinGain[idx] = math.Min(c.segmentMinGain[idx], gain)
+	}
+}
+
+// publishGainReductionSegments stores the channel's segment profile for
+// lock-free reading (internal, assumes lock held).
+func (c *SoftKneeCompressor) publishGainReductionSegments(channel int) {
+	for i := range int(c.grSegmentCount) {
+		idx := channel*maxGRSegments + i
+		atomic.StoreUint64(&c.grSegments[idx], math.Float64bits(math.Max(-LinearToDB(c.segmentMinGain[idx]), 0.0)))
+	}
+}
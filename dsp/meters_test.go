@@ -0,0 +1,554 @@
+package dsp
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestGainReductionMeterBallistics verifies the GR meter rises instantly on a loud block
+// and decays over its own release time, independent of the detector's release.
+func TestGainReductionMeterBallistics(t *testing.T) {
+	t.Parallel()
+
+	const blockSize = 480 // 10 ms at 48 kHz
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(0.1)
+	comp.SetRelease(5.0) // Detector recovers within a couple of blocks
+	comp.SetGRMeterBallistics(0.0, 500.0)
+
+	loud := make([]float32, blockSize)
+	for i := range loud {
+		loud[i] = 0.9
+	}
+
+	silence := make([]float32, blockSize)
+	out := make([]float32, blockSize)
+
+	comp.ProcessBlock(loud, out, 0)
+
+	rawGR := -LinearToDB(comp.GetMeters().GainReductionL)
+	meterGR := comp.GainReductionMeterDB(0)
+
+	if rawGR <= 0.0 {
+		t.Fatalf("Loud block should produce gain reduction, got %f dB", rawGR)
+	}
+
+	if math.Abs(meterGR-rawGR) > 1e-9 {
+		t.Errorf("Meter should rise instantly: raw %f dB, meter %f dB", rawGR, meterGR)
+	}
+
+	// 100 ms of silence: the detector has long recovered, the meter only decays
+	for range 10 {
+		comp.ProcessBlock(silence, out, 0)
+	}
+
+	if comp.GetMeters().GainReductionL < 0.999 {
+		t.Errorf("Detector should have released: gain %f", comp.GetMeters().GainReductionL)
+	}
+
+	// One-pole decay over 100 ms with a 500 ms time constant
+	expected := meterGR * math.Exp(-100.0/500.0)
+	decayed := comp.GetMeters().GainReductionMeterL
+
+	if math.Abs(decayed-expected) > 0.05*meterGR {
+		t.Errorf("Meter should decay with its own release: expected %f dB, got %f dB", expected, decayed)
+	}
+
+	if attack, release := comp.GetGRMeterBallistics(); attack != 0.0 || release != 500.0 {
+		t.Errorf("Unexpected ballistics: attack %f, release %f", attack, release)
+	}
+}
+
+// TestBlockMeanGain verifies a block with one short transient and otherwise
+// unity gain reports a deep peak reduction but a small average reduction.
+func TestBlockMeanGain(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(20.0)
+	comp.SetAttack(0.1)
+	comp.SetRelease(5.0)
+
+	// 1 s block, quiet apart from a 2 ms burst at the start
+	in := make([]float32, 48000)
+	for i := range 96 {
+		in[i] = 0.9
+	}
+
+	comp.ProcessBlock(in, make([]float32, len(in)), 0)
+
+	meters := comp.GetMeters()
+	peakGR := -LinearToDB(meters.GainReductionL)
+	meanGR := -LinearToDB(meters.MeanGainL)
+
+	if peakGR < 10.0 {
+		t.Errorf("Peak reduction %.2f dB, want at least 10 dB for the transient", peakGR)
+	}
+
+	if meanGR <= 0.0 || meanGR > 0.5 {
+		t.Errorf("Average reduction %.3f dB, want a small positive reduction", meanGR)
+	}
+}
+
+// TestInputClipFlag verifies input above full scale sets the input clip flag even
+// when the compressed output stays below full scale.
+func TestInputClipFlag(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(0.1)
+	comp.SetMakeupGain(0.0)
+	comp.SetStartupFade(0.0)
+
+	hot := make([]float32, 4800)
+	for i := range hot {
+		hot[i] = 1.2
+	}
+
+	out := make([]float32, len(hot))
+	comp.ProcessBlock(hot, out, 0)
+
+	meters := comp.GetMeters()
+	if !meters.InputClipL || !comp.InputClipped(0) {
+		t.Error("Input of 1.2 should set the input clip flag")
+	}
+
+	if meters.InputClipR {
+		t.Error("Unprocessed right channel should not report input clipping")
+	}
+
+	if out[len(out)-1] >= 1.0 {
+		t.Errorf("Compressed output should be clean, got %f", out[len(out)-1])
+	}
+
+	// A clean block clears the flag
+	quiet := make([]float32, 480)
+	comp.ProcessBlock(quiet, make([]float32, len(quiet)), 0)
+
+	if comp.InputClipped(0) {
+		t.Error("Clean block should clear the input clip flag")
+	}
+}
+
+// TestClipCounts verifies clipped input samples are counted on the raw input
+// while the compressed output stays below full scale and counts nothing.
+func TestClipCounts(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetMakeupGain(0.0)
+	comp.SetStartupFade(0.0)
+
+	// Settle the envelope on a loud but unclipped signal first
+	loud := make([]float32, 480)
+	for i := range loud {
+		loud[i] = 0.9
+	}
+
+	out := make([]float32, len(loud))
+	for range 50 {
+		comp.ProcessBlock(loud, out, 0)
+	}
+
+	if comp.InputClipCount(0) != 0 || comp.OutputClipCount(0) != 0 {
+		t.Fatalf("Unclipped input counted %d input and %d output clips",
+			comp.InputClipCount(0), comp.OutputClipCount(0))
+	}
+
+	hot := make([]float32, 480)
+	for i := range hot {
+		hot[i] = 1.2
+	}
+
+	comp.ProcessBlock(hot, out, 0)
+
+	if got := comp.InputClipCount(0); got != uint64(len(hot)) {
+		t.Errorf("Input clip count %d, want %d", got, len(hot))
+	}
+
+	if got := comp.OutputClipCount(0); got != 0 {
+		t.Errorf("Output clip count %d, want 0 for compressed output", got)
+	}
+
+	meters, err := comp.GetChannelMeters(0)
+	if err != nil || meters.InputClipCount != uint64(len(hot)) {
+		t.Errorf("Channel meters report %d input clips (err %v)", meters.InputClipCount, err)
+	}
+
+	comp.ResetClipCounts()
+
+	if got := comp.InputClipCount(0); got != 0 {
+		t.Errorf("Input clip count %d after reset, want 0", got)
+	}
+}
+
+// TestGainReductionDeviation verifies the deviation stays low for a steady tone
+// and is high for a rapidly modulated signal.
+func TestGainReductionDeviation(t *testing.T) {
+	t.Parallel()
+
+	run := func(modulationHz float64, blocks int) MeterStats {
+		comp := NewSoftKneeCompressor(48000.0, 2)
+		comp.SetThreshold(-30.0)
+		comp.SetAttack(1.0)
+		comp.SetRelease(20.0)
+
+		in := make([]float32, 480)
+		out := make([]float32, len(in))
+
+		for block := range blocks {
+			for i := range in {
+				n := float64(block*len(in) + i)
+				envelope := 0.5
+				if modulationHz > 0 {
+					envelope = 0.05 + 0.45*(0.5+0.5*math.Sin(2.0*math.Pi*modulationHz*n/48000.0))
+				}
+
+				in[i] = float32(envelope * math.Sin(2.0*math.Pi*440.0*n/48000.0))
+			}
+
+			comp.ProcessBlock(in, out, 0)
+		}
+
+		return comp.GetMeters()
+	}
+
+	// The steady run lasts several averaging windows so the deviation caused by
+	// the initial jump into compression has died away
+	steady := run(0, 3000)   // 30 s
+	modulated := run(3, 600) // 6 s
+
+	if steady.AverageGainReductionL <= 0 {
+		t.Fatalf("Steady tone should be compressed, got %.2f dB", steady.AverageGainReductionL)
+	}
+
+	if steady.GainReductionDevL > 0.5 {
+		t.Errorf("Steady tone deviation %.2f dB, want below 0.5 dB", steady.GainReductionDevL)
+	}
+
+	if modulated.GainReductionDevL < 3.0 {
+		t.Errorf("Modulated signal deviation %.2f dB, want at least 3 dB", modulated.GainReductionDevL)
+	}
+}
+
+// TestDCOffsetMeter verifies the DC-offset meter converges to a known offset.
+func TestDCOffsetMeter(t *testing.T) {
+	t.Parallel()
+
+	const (
+		blockSize = 512
+		offset    = 0.1
+	)
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+
+	in := make([]float32, blockSize)
+	out := make([]float32, blockSize)
+	pos := 0
+
+	// Five seconds: five time constants of the meter
+	for range 5 * 48000 / blockSize {
+		for i := range in {
+			in[i] = float32(offset + 0.3*math.Sin(2.0*math.Pi*440.0*float64(pos)/48000.0))
+			pos++
+		}
+
+		comp.ProcessBlock(in, out, 0)
+	}
+
+	if dc := comp.GetMeters().DCOffsetL; math.Abs(dc-offset) > 0.005 {
+		t.Errorf("DC meter should converge to %f, got %f", offset, dc)
+	}
+
+	if dc := comp.DCOffset(1); dc != 0.0 {
+		t.Errorf("Unprocessed channel should report no DC offset, got %f", dc)
+	}
+}
+
+// TestChannelMetersBeyondStereo verifies channels 2 and 3 of a 4-channel
+// compressor report their own peak and gain-reduction meters.
+func TestChannelMetersBeyondStereo(t *testing.T) {
+	t.Parallel()
+
+	const (
+		channels = 4
+		frames   = 4800
+	)
+
+	comp := NewSoftKneeCompressor(48000.0, channels)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(1.0)
+	comp.SetMakeupGain(0.0)
+
+	levels := []float32{0.0, 0.0, 0.9, 0.05}
+
+	in := make([]float32, frames*channels)
+	for i := range frames {
+		copy(in[i*channels:], levels)
+	}
+
+	comp.ProcessFrames(in, make([]float32, len(in)))
+
+	loud, err := comp.GetChannelMeters(2)
+	if err != nil {
+		t.Fatalf("GetChannelMeters(2) failed: %v", err)
+	}
+
+	quiet, err := comp.GetChannelMeters(3)
+	if err != nil {
+		t.Fatalf("GetChannelMeters(3) failed: %v", err)
+	}
+
+	if math.Abs(loud.Input-0.9) > 1e-6 || math.Abs(quiet.Input-0.05) > 1e-6 {
+		t.Errorf("Input peaks should match the signal: ch2 %f, ch3 %f", loud.Input, quiet.Input)
+	}
+
+	if loud.GainReduction >= 0.5 || loud.Output >= 0.9*0.5 {
+		t.Errorf("Channel 2 should show deep gain reduction: gain %f, output %f", loud.GainReduction, loud.Output)
+	}
+
+	if quiet.GainReduction < 0.99 {
+		t.Errorf("Channel 3 is below threshold and should show no reduction, got gain %f", quiet.GainReduction)
+	}
+
+	if _, err := comp.GetChannelMeters(channels); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Expected ErrInvalidChannel for channel %d, got %v", channels, err)
+	}
+}
+
+// TestGainReductionSegments verifies a single transient only shows up in the
+// segment of the block that contains it.
+func TestGainReductionSegments(t *testing.T) {
+	t.Parallel()
+
+	const (
+		blockSize = 4800
+		segments  = 8
+		hitStart  = 3000 // Inside segment 5 (3000-3599)
+		hitLength = 60
+	)
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(0.1)
+	comp.SetRelease(1.0)
+
+	if comp.GainReductionSegments(0) != nil {
+		t.Fatal("Segments should be disabled by default")
+	}
+
+	comp.SetGainReductionSegments(segments)
+
+	in := make([]float32, blockSize)
+	for i := hitStart; i < hitStart+hitLength; i++ {
+		in[i] = 0.9
+	}
+
+	comp.ProcessBlock(in, make([]float32, blockSize), 0)
+
+	profile := comp.GainReductionSegments(0)
+	if len(profile) != segments {
+		t.Fatalf("Expected %d segments, got %d", segments, len(profile))
+	}
+
+	for i, grDB := range profile {
+		if i == hitStart*segments/blockSize {
+			if grDB < 6.0 {
+				t.Errorf("Segment %d holds the transient and should show deep reduction, got %.2f dB", i, grDB)
+			}
+		} else if grDB > 0.5 {
+			t.Errorf("Segment %d has no transient and should show no reduction, got %.2f dB", i, grDB)
+		}
+	}
+}
+
+// TestChannelBalance verifies a left channel 6 dB hotter than the right reads
+// as about +6 dB towards the left once the slow average has settled.
+func TestChannelBalance(t *testing.T) {
+	t.Parallel()
+
+	const (
+		blockFrames = 480
+		blocks      = 500 // 5 s at 48 kHz
+	)
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+
+	if balance := comp.GetMeters().ChannelBalance(); balance != 0.0 {
+		t.Errorf("Balance before any input should be centered, got %f dB", balance)
+	}
+
+	frame := make([]float32, blockFrames*2)
+	for i := range blockFrames {
+		sample := math.Sin(2.0 * math.Pi * 1000.0 * float64(i) / 48000.0)
+		frame[2*i] = float32(0.5 * sample)
+		frame[2*i+1] = float32(0.25 * sample)
+	}
+
+	out := make([]float32, len(frame))
+	for range blocks {
+		comp.ProcessFrames(frame, out)
+	}
+
+	if balance := comp.GetMeters().ChannelBalance(); math.Abs(balance-6.02) > 0.1 {
+		t.Errorf("Balance should be ~+6 dB towards the left, got %f dB", balance)
+	}
+}
+
+// TestBypassStillMeters verifies that bypass keeps the level meters live, with
+// equal input and output peaks and no gain reduction, even right after the
+// compressor was reducing gain.
+func TestBypassStillMeters(t *testing.T) {
+	t.Parallel()
+
+	const blockSize = 480
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetMakeupGain(0.0)
+
+	loud := make([]float32, blockSize)
+	for i := range loud {
+		loud[i] = 0.8
+	}
+
+	out := make([]float32, blockSize)
+	for range 10 {
+		comp.ProcessBlock(loud, out, 0)
+	}
+
+	if comp.GetMeters().GainReductionMeterL < 1.0 {
+		t.Fatal("Expected gain reduction before bypassing")
+	}
+
+	comp.SetBypass(true)
+
+	quiet := make([]float32, blockSize)
+	for i := range quiet {
+		quiet[i] = 0.5
+	}
+
+	comp.ProcessBlock(quiet, out, 0)
+
+	meters := comp.GetMeters()
+
+	if math.Abs(meters.InputL-0.5) > 1e-6 || math.Abs(meters.OutputL-0.5) > 1e-6 {
+		t.Errorf("Bypassed meters should show equal input/output peaks of 0.5, got in %f, out %f",
+			meters.InputL, meters.OutputL)
+	}
+
+	if meters.GainReductionL != 1.0 || meters.GainReductionMeterL != 0.0 {
+		t.Errorf("Bypassed meters should show no gain reduction, got gain %f, meter %f dB",
+			meters.GainReductionL, meters.GainReductionMeterL)
+	}
+}
+
+// TestCrestFactorMeter verifies a sine reports ~3 dB crest factor while an
+// impulse train reports a much higher one.
+func TestCrestFactorMeter(t *testing.T) {
+	t.Parallel()
+
+	const blockFrames = 4800
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+
+	frames := make([]float32, blockFrames*2)
+	for i := range blockFrames {
+		frames[2*i] = float32(0.5 * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+
+		if i%480 == 0 {
+			frames[2*i+1] = 0.5
+		}
+	}
+
+	comp.ProcessFrames(frames, make([]float32, len(frames)))
+
+	meters := comp.GetMeters()
+
+	if math.Abs(meters.CrestFactorL-3.01) > 0.1 {
+		t.Errorf("Sine crest factor should be ~3 dB, got %.2f dB", meters.CrestFactorL)
+	}
+
+	// One impulse every 480 samples: crest = sqrt(480) ~ 26.8 dB
+	if math.Abs(meters.CrestFactorR-26.8) > 0.2 {
+		t.Errorf("Impulse train crest factor should be ~26.8 dB, got %.2f dB", meters.CrestFactorR)
+	}
+}
+
+// TestGetMetersLockFree verifies GetMeters returns while the compressor lock is
+// held, and that polling it from several goroutines during ProcessBlock is
+// race free (run with -race).
+func TestGetMetersLockFree(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+
+	// With the lock held, as during a block, GetMeters must not wait for it
+	comp.mu.Lock()
+
+	done := make(chan MeterStats)
+
+	go func() { done <- comp.GetMeters() }()
+
+	select {
+	case stats := <-done:
+		if stats.SampleRate != 48000.0 {
+			t.Errorf("SampleRate = %v, want 48000", stats.SampleRate)
+		}
+	case <-time.After(time.Second):
+		t.Error("GetMeters blocked on the compressor lock")
+	}
+
+	comp.mu.Unlock()
+
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = comp.GetMeters()
+				}
+			}
+		}()
+	}
+
+	in := make([]float32, 256)
+	out := make([]float32, len(in))
+
+	for i := range in {
+		in[i] = float32(0.5 * math.Sin(2*math.Pi*float64(i)/64))
+	}
+
+	for block := range 200 {
+		comp.ProcessBlock(in, out, block%2)
+
+		if block == 100 {
+			comp.SetSampleRate(44100.0)
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+
+	if rate := comp.GetMeters().SampleRate; rate != 44100.0 {
+		t.Errorf("SampleRate after change = %v, want 44100", rate)
+	}
+}
@@ -0,0 +1,96 @@
+package dsp
+
+import "sync/atomic"
+
+const (
+	// Length of the window in which NaN/Inf input samples are counted, and of
+	// the clean stretch required to lift the mute, in milliseconds.
+	nanSafetyWindowMs = 100.0
+
+	// Default number of NaN/Inf samples within a window that trigger the mute.
+	defaultNaNSafetyLimit = 64
+)
+
+// SetNaNSafetyMute enables the safety mute for a broken upstream. NaN and
+// infinite input samples are always replaced by silence individually; with the
+// safety mute, once the limit set by SetNaNSafetyLimit is reached within
+// 100 ms the whole output is muted until the input has stayed clean for
+// 100 ms. NaNMuted reports the state so callers can log it.
+func (c *SoftKneeCompressor) SetNaNSafetyMute(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nanSafety = enabled
+	c.resetNaNSafety()
+}
+
+// GetNaNSafetyMute returns whether the NaN/Inf safety mute is enabled.
+func (c *SoftKneeCompressor) GetNaNSafetyMute() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.nanSafety
+}
+
+// SetNaNSafetyLimit sets the number of NaN/Inf input samples, counted over all
+// channels within the window, that trigger the safety mute (minimum 1).
+func (c *SoftKneeCompressor) SetNaNSafetyLimit(count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nanSafetyLimit = max(1, count)
+}
+
+// GetNaNSafetyLimit returns the number of NaN/Inf input samples that trigger the safety mute.
+func (c *SoftKneeCompressor) GetNaNSafetyLimit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.nanSafetyLimit
+}
+
+// NaNMuted reports whether the safety mute currently silences the output.
+func (c *SoftKneeCompressor) NaNMuted() bool {
+	return atomic.LoadUint32(&c.nanMuted) != 0
+}
+
+// resetNaNSafety clears the event count and lifts the mute (internal, assumes lock held).
+func (c *SoftKneeCompressor) resetNaNSafety() {
+	c.nanEvents = 0
+	c.nanWindowPos = 0
+	c.nanCleanSamples = 0
+	atomic.StoreUint32(&c.nanMuted, 0)
+}
+
+// trackNonFinite counts one input sample for the safety mute, muting once the
+// limit is reached within a window and unmuting after a clean window
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) trackNonFinite(bad bool) {
+	if !c.nanSafety {
+		return
+	}
+
+	// The window covers all channels, as the samples of every channel are counted
+	window := max(1, int(nanSafetyWindowMs*0.001*c.sampleRate)*c.channels)
+
+	if bad {
+		c.nanEvents++
+		c.nanCleanSamples = 0
+
+		if c.nanEvents >= c.nanSafetyLimit {
+			atomic.StoreUint32(&c.nanMuted, 1)
+		}
+	} else {
+		c.nanCleanSamples++
+
+		if c.nanCleanSamples >= window {
+			atomic.StoreUint32(&c.nanMuted, 0)
+		}
+	}
+
+	c.nanWindowPos++
+	if c.nanWindowPos >= window {
+		c.nanWindowPos = 0
+		c.nanEvents = 0
+	}
+}
@@ -0,0 +1,84 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestNaNSafetyMute verifies a NaN burst mutes the output, which then stays
+// muted until the input has been clean for the recovery window.
+func TestNaNSafetyMute(t *testing.T) {
+	t.Parallel()
+
+	const window = 4800 // 100 ms at 48 kHz, mono
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(0.0)
+	comp.SetMakeupGain(0.0)
+	comp.SetNaNSafetyMute(true)
+
+	clean := make([]float32, window)
+	for i := range clean {
+		clean[i] = 0.1
+	}
+
+	burst := make([]float32, 100)
+	for i := range burst {
+		burst[i] = float32(math.NaN())
+	}
+
+	out := make([]float32, window)
+	comp.ProcessBlock(clean, out, 0)
+
+	if comp.NaNMuted() || out[window-1] == 0 {
+		t.Fatal("Clean input should pass without muting")
+	}
+
+	comp.ProcessBlock(burst, out[:len(burst)], 0)
+
+	if !comp.NaNMuted() {
+		t.Fatal("A burst of 100 NaN samples should trigger the safety mute")
+	}
+
+	// Clean input right after the burst stays muted until a full clean window passed
+	short := append([]float32(nil), clean[:window/2]...)
+	comp.ProcessBlock(short, out[:len(short)], 0)
+
+	if !comp.NaNMuted() || maxAbs(out[:len(short)]) != 0 {
+		t.Error("Output should stay muted during the recovery window")
+	}
+
+	comp.ProcessBlock(append([]float32(nil), clean...), out, 0)
+
+	if comp.NaNMuted() || out[window-1] == 0 {
+		t.Error("Output should recover after a clean window")
+	}
+}
+
+// TestNaNSafetyMuteIsolated verifies isolated NaN samples below the limit, or
+// with the safety disabled, only zero those samples.
+func TestNaNSafetyMuteIsolated(t *testing.T) {
+	t.Parallel()
+
+	for _, enabled := range []bool{true, false} {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetThreshold(0.0)
+		comp.SetMakeupGain(0.0)
+		comp.SetNaNSafetyMute(enabled)
+
+		in := make([]float32, 4800)
+		for i := range in {
+			in[i] = 0.1
+			if i%100 == 0 {
+				in[i] = float32(math.Inf(1))
+			}
+		}
+
+		out := make([]float32, len(in))
+		comp.ProcessBlock(in, out, 0)
+
+		if comp.NaNMuted() || out[len(out)-1] == 0 {
+			t.Errorf("Safety %t: 48 isolated Inf samples should not mute the output", enabled)
+		}
+	}
+}
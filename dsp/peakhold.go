@@ -0,0 +1,65 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Time in seconds a held peak is kept before it falls back to the current peak.
+const peakHoldSec = 2.0
+
+// SetPeakHoldInfinite switches the peak-hold meters to infinity hold: held
+// peaks never fall back, so they show the maximum of the whole session until
+// ResetPeakHold is called. Otherwise a held peak is kept for two seconds.
+func (c *SoftKneeCompressor) SetPeakHoldInfinite(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.peakHoldInfinite = enabled
+}
+
+// GetPeakHoldInfinite returns whether the peak-hold meters hold indefinitely.
+func (c *SoftKneeCompressor) GetPeakHoldInfinite() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.peakHoldInfinite
+}
+
+// ResetPeakHold clears the held input and output peaks of every channel.
+func (c *SoftKneeCompressor) ResetPeakHold() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for ch := range c.channels {
+		atomic.StoreUint64(&c.peakHoldIn[ch], 0)
+		atomic.StoreUint64(&c.peakHoldOut[ch], 0)
+		c.peakHoldInAge[ch] = 0
+		c.peakHoldOutAge[ch] = 0
+	}
+}
+
+// updatePeakHold folds one block's input and output peaks into the held
+// peaks, which fall back to the block peaks once they are older than the hold
+// time unless infinity hold is enabled (internal, assumes lock held).
+func (c *SoftKneeCompressor) updatePeakHold(channel int, maxInput, maxOutput float64, samples int) {
+	c.holdPeak(&c.peakHoldIn[channel], &c.peakHoldInAge[channel], maxInput, samples)
+	c.holdPeak(&c.peakHoldOut[channel], &c.peakHoldOutAge[channel], maxOutput, samples)
+}
+
+// holdPeak updates one held peak and its age in samples (internal, assumes lock held).
+func (c *SoftKneeCompressor) holdPeak(held *uint64, age *int, peak float64, samples int) {
+	value := math.Float64frombits(atomic.LoadUint64(held))
+
+	*age += samples
+	if !c.peakHoldInfinite && float64(*age) >= peakHoldSec*c.sampleRate {
+		value = 0
+	}
+
+	if peak >= value {
+		value = peak
+		*age = 0
+	}
+
+	atomic.StoreUint64(held, math.Float64bits(value))
+}
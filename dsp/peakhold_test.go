@@ -0,0 +1,72 @@
+package dsp
+
+import (
+	"testing"
+)
+
+// processLevel runs blocks of 10 ms at a constant level through channel 0.
+func processLevel(comp *SoftKneeCompressor, level float32, blocks int) {
+	in := make([]float32, 480)
+	for i := range in {
+		in[i] = level
+	}
+
+	out := make([]float32, len(in))
+	for range blocks {
+		comp.ProcessBlock(in, out, 0)
+	}
+}
+
+// TestPeakHoldFallsBack verifies a normal held peak is kept for the hold time
+// and then falls back to the current level.
+func TestPeakHoldFallsBack(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+
+	processLevel(comp, 0.9, 1)
+	processLevel(comp, 0.1, 100) // 1 s
+
+	if held := comp.GetMeters().PeakHoldInputL; held != float64(float32(0.9)) {
+		t.Errorf("Peak should still be held after 1 s, got %f", held)
+	}
+
+	processLevel(comp, 0.1, 150) // 2.5 s in total
+
+	if held := comp.GetMeters().PeakHoldInputL; held != float64(float32(0.1)) {
+		t.Errorf("Held peak should fall back to the current level after the hold time, got %f", held)
+	}
+}
+
+// TestPeakHoldInfinite verifies infinity hold retains a one-time transient
+// over a long session until ResetPeakHold is called.
+func TestPeakHoldInfinite(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(0.0)
+	comp.SetMakeupGain(0.0)
+	comp.SetPeakHoldInfinite(true)
+
+	processLevel(comp, 0.1, 10)
+	processLevel(comp, 0.95, 1)
+	processLevel(comp, 0.1, 6000) // 60 s
+
+	meters := comp.GetMeters()
+	if meters.PeakHoldInputL != float64(float32(0.95)) || meters.PeakHoldOutputL < 0.9 {
+		t.Errorf("Infinity hold should retain the transient, got input %f, output %f",
+			meters.PeakHoldInputL, meters.PeakHoldOutputL)
+	}
+
+	comp.ResetPeakHold()
+
+	if held := comp.GetMeters().PeakHoldInputL; held != 0 {
+		t.Errorf("ResetPeakHold should clear the held peak, got %f", held)
+	}
+
+	processLevel(comp, 0.1, 1)
+
+	if held := comp.GetMeters().PeakHoldInputL; held != float64(float32(0.1)) {
+		t.Errorf("After reset the hold should restart from the current level, got %f", held)
+	}
+}
@@ -0,0 +1,116 @@
+package dsp
+
+import "math"
+
+// Precision selects the numeric type used for the envelope follower and gain curve.
+type Precision int
+
+const (
+	// Float64 runs the envelope and gain computation in float64 (default).
+	Float64 Precision = iota
+
+	// Float32 runs the envelope and gain computation in float32. It trades some
+	// accuracy for speed on targets where float64 math is expensive; on desktop
+	// CPUs float64 is usually just as fast (see BenchmarkPrecision).
+	Float32
+)
+
+// float32Params caches the parameters used by the float32 processing path.
+type float32Params struct {
+	attackFactor  float32
+	releaseFactor float32
+	threshold     float32
+	kneeLower     float32
+	kneeUpper     float32
+	kneeWidth     float32
+	slope         float32
+}
+
+// SetPrecision selects the internal envelope/gain precision. Unknown values are ignored.
+func (c *SoftKneeCompressor) SetPrecision(precision Precision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if precision != Float32 && precision != Float64 {
+		return
+	}
+
+	if precision == Float32 && c.precision != Float32 {
+		// Carry the running envelope over so switching doesn't cause a jump
+		for i := range c.peak {
+			c.peak32[i] = float32(c.peak[i])
+		}
+	}
+
+	c.precision = precision
+}
+
+// GetPrecision returns the internal envelope/gain precision.
+func (c *SoftKneeCompressor) GetPrecision() Precision {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.precision
+}
+
+// updateFloat32Params refreshes the float32 parameter cache (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateFloat32Params() {
+	c.params32 = float32Params{
+		attackFactor:  float32(c.attackFactor),
+		releaseFactor: float32(c.releaseFactor),
+		threshold:     float32(c.threshold),
+		kneeLower:     float32(c.kneeLower),
+		kneeUpper:     float32(c.kneeUpper),
+		kneeWidth:     float32(c.kneeWidth),
+		slope:         float32(1.0 - 1.0/c.ratio),
+	}
+}
+
+// updateEnvelope32 is the float32 variant of updateEnvelope. The float64 peak is
+// kept in sync so metering and custom gain computers see the same envelope.
+func (c *SoftKneeCompressor) updateEnvelope32(inputLevel float64, channel int) {
+	if math.IsNaN(inputLevel) {
+		inputLevel = 0 // Sanitize
+	}
+
+	level := float32(inputLevel)
+
+	attack, release := c.params32.attackFactor, c.params32.releaseFactor
+	if c.autoRelease || c.externalKey {
+		attack64, release64 := c.envelopeFactors(channel)
+		attack, release = float32(attack64), float32(release64)
+	}
+
+	peak := c.peak32[channel]
+	if level > peak {
+		peak += (level - peak) * attack
+	} else {
+		peak = level + (peak-level)*release
+	}
+
+	if math.IsNaN(float64(peak)) {
+		peak = 0 // Safety reset
+	}
+
+	c.peak32[channel] = peak
+	c.peak[channel] = float64(peak)
+}
+
+// calculateGain32 is the float32 variant of calculateGain.
+func (c *SoftKneeCompressor) calculateGain32(peakLevel float32) float32 {
+	params := &c.params32
+
+	if peakLevel <= params.kneeLower {
+		return 1.0
+	}
+
+	if peakLevel >= params.kneeUpper {
+		return float32(c.curvePow(float64(params.threshold/peakLevel), float64(params.slope)))
+	}
+
+	kneePos := (peakLevel - params.kneeLower) / params.kneeWidth
+	smoothFactor := kneePos * kneePos * (3.0 - 2.0*kneePos)
+	compressedGain := float32(c.curvePow(float64(params.threshold/params.kneeUpper), float64(params.slope)))
+
+	return 1.0 + (compressedGain-1.0)*smoothFactor
+}
@@ -0,0 +1,109 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// precisionTestSignal returns a tone with alternating loud and quiet sections
+// so that both attack and release are exercised.
+func precisionTestSignal(samples int) []float32 {
+	signal := make([]float32, samples)
+
+	for i := range signal {
+		amplitude := 0.8
+		if (i/3000)%2 == 1 {
+			amplitude = 0.05
+		}
+
+		signal[i] = float32(amplitude * math.Sin(2.0*math.Pi*440.0*float64(i)/48000.0))
+	}
+
+	return signal
+}
+
+// TestPrecisionOutputDifference verifies the float32 path stays close to the float64 path.
+func TestPrecisionOutputDifference(t *testing.T) {
+	t.Parallel()
+
+	in := precisionTestSignal(48000)
+
+	process := func(precision Precision) []float32 {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetThreshold(-20.0)
+		comp.SetRatio(4.0)
+		comp.SetPrecision(precision)
+
+		out := make([]float32, len(in))
+		comp.ProcessBlock(in, out, 0)
+
+		return out
+	}
+
+	reference := process(Float64)
+	single := process(Float32)
+
+	maxDiff := 0.0
+	for i := range reference {
+		maxDiff = math.Max(maxDiff, math.Abs(float64(reference[i]-single[i])))
+	}
+
+	t.Logf("max difference float32 vs float64: %g", maxDiff)
+
+	// Output peaks above 1.0 with auto makeup, so this is below -60 dB relative
+	if maxDiff > 5e-4 {
+		t.Errorf("Float32 output deviates too much from float64: max diff %g", maxDiff)
+	}
+
+	if maxDiff == 0 {
+		t.Error("Expected the float32 path to differ from the float64 path")
+	}
+}
+
+// TestSetPrecision verifies the getter and that invalid values are ignored.
+func TestSetPrecision(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+
+	if comp.GetPrecision() != Float64 {
+		t.Errorf("Expected Float64 by default, got %d", comp.GetPrecision())
+	}
+
+	comp.SetPrecision(Float32)
+
+	if comp.GetPrecision() != Float32 {
+		t.Errorf("Expected Float32, got %d", comp.GetPrecision())
+	}
+
+	comp.SetPrecision(Precision(42))
+
+	if comp.GetPrecision() != Float32 {
+		t.Errorf("Invalid precision should be ignored, got %d", comp.GetPrecision())
+	}
+}
+
+// BenchmarkPrecision compares the float64 and float32 processing paths.
+func BenchmarkPrecision(b *testing.B) {
+	for _, bench := range []struct {
+		name      string
+		precision Precision
+	}{
+		{"float64", Float64},
+		{"float32", Float32},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			comp := NewSoftKneeCompressor(48000.0, 1)
+			comp.SetPrecision(bench.precision)
+
+			in := precisionTestSignal(1024)
+			out := make([]float32, len(in))
+
+			b.ResetTimer()
+
+			for range b.N {
+				comp.ProcessBlock(in, out, 0)
+			}
+		})
+	}
+}
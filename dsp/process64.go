@@ -0,0 +1,16 @@
+package dsp
+
+// ProcessBlock64 processes a block of float64 samples for a specific channel.
+// It runs the same block loop as ProcessBlock, which remains the primary API,
+// but samples stay in float64 from input to output without a float32
+// round-trip.
+func (c *SoftKneeCompressor) ProcessBlock64(in []float64, out []float64, channel int) {
+	if channel < 0 || channel >= c.channels || len(in) != len(out) || len(in) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	processBlockLocked(c, in, nil, out, channel, nil)
+}
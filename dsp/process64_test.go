@@ -0,0 +1,115 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestProcessBlock64MatchesFloat32 verifies the float64 API runs the same
+// processing as the float32 one and keeps precision beyond float32 at the
+// I/O boundary.
+func TestProcessBlock64MatchesFloat32(t *testing.T) {
+	t.Parallel()
+
+	const length = 4800
+
+	comp32 := NewSoftKneeCompressor(48000.0, 1)
+	comp64 := NewSoftKneeCompressor(48000.0, 1)
+
+	for _, comp := range []*SoftKneeCompressor{comp32, comp64} {
+		comp.SetThreshold(-20.0)
+		comp.SetRatio(4.0)
+	}
+
+	in32 := make([]float32, length)
+	in64 := make([]float64, length)
+
+	for i := range in32 {
+		in32[i] = float32(0.8 * math.Sin(2.0*math.Pi*440.0*float64(i)/48000.0))
+		in64[i] = float64(in32[i])
+	}
+
+	out32 := make([]float32, length)
+	out64 := make([]float64, length)
+
+	comp32.ProcessBlock(in32, out32, 0)
+	comp64.ProcessBlock64(in64, out64, 0)
+
+	for i := range out32 {
+		if float32(out64[i]) != out32[i] {
+			t.Fatalf("Sample %d: float64 path %v rounds to %v, float32 path gave %v",
+				i, out64[i], float32(out64[i]), out32[i])
+		}
+	}
+
+	// In bypass the float64 path must hand back values float32 can't represent untouched
+	comp64.SetBypass(true)
+
+	fine := []float64{0.1, 1.0 / 3.0, 0.123456789012345}
+	fineOut := make([]float64, len(fine))
+	comp64.ProcessBlock64(fine, fineOut, 0)
+
+	for i := range fine {
+		if fineOut[i] != fine[i] {
+			t.Errorf("Bypassed float64 sample %d lost precision: got %v, want %v", i, fineOut[i], fine[i])
+		}
+	}
+
+	if meters := comp64.GetMeters(); meters.InputL != fine[1] {
+		t.Errorf("Input meter should see the float64 peak %v, got %v", fine[1], meters.InputL)
+	}
+}
+
+// TestProcessBlock64BlockGain verifies the float64 API honours control-rate
+// block gains like the float32 one.
+func TestProcessBlock64BlockGain(t *testing.T) {
+	t.Parallel()
+
+	const (
+		blockSize = 128
+		blocks    = 40
+	)
+
+	comp32 := NewSoftKneeCompressor(48000.0, 1)
+	comp64 := NewSoftKneeCompressor(48000.0, 1)
+	perSample := NewSoftKneeCompressor(48000.0, 1)
+
+	for _, comp := range []*SoftKneeCompressor{comp32, comp64, perSample} {
+		comp.SetThreshold(-30.0)
+		comp.SetRatio(8.0)
+		comp.SetAttack(1.0)
+	}
+
+	comp32.SetBlockGainInterpolation(true)
+	comp64.SetBlockGainInterpolation(true)
+
+	in32 := make([]float32, blockSize)
+	in64 := make([]float64, blockSize)
+	out32 := make([]float32, blockSize)
+	out64 := make([]float64, blockSize)
+	outPerSample := make([]float64, blockSize)
+	differs := false
+
+	for n := range blocks {
+		for i := range in32 {
+			in32[i] = float32(0.8 * math.Sin(2.0*math.Pi*440.0*float64(n*blockSize+i)/48000.0))
+			in64[i] = float64(in32[i])
+		}
+
+		comp32.ProcessBlock(in32, out32, 0)
+		comp64.ProcessBlock64(in64, out64, 0)
+		perSample.ProcessBlock64(in64, outPerSample, 0)
+
+		for i := range out32 {
+			if float32(out64[i]) != out32[i] {
+				t.Fatalf("Block %d sample %d: float64 path %v, float32 path %v", n, i, out64[i], out32[i])
+			}
+
+			differs = differs || out64[i] != outPerSample[i]
+		}
+	}
+
+	if !differs {
+		t.Error("Block gain interpolation had no effect on the float64 path")
+	}
+}
@@ -0,0 +1,31 @@
+package dsp
+
+import "math"
+
+// Largest settable range in dB.
+const maxRangeDB = 60.0
+
+// SetRange limits the gain reduction the compressor applies to rangeDB, so
+// material far above the threshold is turned down by at most that amount and
+// the compressor acts like a gentle leveler instead of squashing loud passages.
+// Auto makeup compensates for at most the range. A range of 0 or less removes
+// the limit; larger values are clamped to 60 dB.
+func (c *SoftKneeCompressor) SetRange(rangeDB float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(rangeDB) {
+		return
+	}
+
+	c.rangeDB = math.Max(0.0, math.Min(maxRangeDB, rangeDB))
+	c.updateParameters()
+}
+
+// GetRange returns the largest gain reduction in dB, or 0 if unlimited.
+func (c *SoftKneeCompressor) GetRange() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rangeDB
+}
@@ -0,0 +1,75 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestRangeLimitsGainReduction verifies the gain reduction saturates at the
+// configured range on a very loud input, and is unlimited without a range.
+func TestRangeLimitsGainReduction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		rangeDB float64
+		wantDB  float64
+	}{
+		{"6 dB range", 6.0, -6.0},
+		{"12 dB range", 12.0, -12.0},
+		{"unlimited", 0.0, -30.0},
+	}
+
+	for _, tt := range tests {
+		comp := NewSoftKneeCompressor(48000.0, 1)
+		comp.SetAutoMakeup(false)
+		comp.SetMakeupGain(0.0)
+		comp.SetThreshold(-40.0)
+		comp.SetRatio(20.0)
+		comp.SetKnee(0.0)
+		comp.SetRange(tt.rangeDB)
+
+		in := make([]float32, 480)
+		for i := range in {
+			in[i] = 0.9
+		}
+
+		out := make([]float32, len(in))
+		for range 100 {
+			comp.ProcessBlock(in, out, 0)
+		}
+
+		gotDB := 20.0 * math.Log10(float64(out[len(out)-1])/0.9)
+		if tt.rangeDB > 0.0 && math.Abs(gotDB-tt.wantDB) > 0.1 {
+			t.Errorf("%s: gain %.2f dB, want %.2f dB", tt.name, gotDB, tt.wantDB)
+		}
+
+		if tt.rangeDB == 0.0 && gotDB > tt.wantDB {
+			t.Errorf("%s: gain %.2f dB, want below %.2f dB", tt.name, gotDB, tt.wantDB)
+		}
+	}
+}
+
+// TestRangeAutoMakeup verifies auto makeup compensates for at most the range.
+func TestRangeAutoMakeup(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(-40.0)
+	comp.SetRatio(4.0)
+	comp.SetRange(10.0)
+
+	if got := comp.GetMakeupGain(); math.Abs(got-10.0) > 1e-9 {
+		t.Errorf("Auto makeup %.2f dB, want 10 dB", got)
+	}
+
+	comp.SetRange(-5.0)
+
+	if got := comp.GetRange(); got != 0.0 {
+		t.Errorf("Negative range stored as %.2f, want 0", got)
+	}
+
+	if got := comp.GetMakeupGain(); math.Abs(got-30.0) > 1e-9 {
+		t.Errorf("Auto makeup without range %.2f dB, want 30 dB", got)
+	}
+}
@@ -0,0 +1,91 @@
+package dsp
+
+import "math"
+
+// SetAttackSamples sets the attack time directly in samples (minimum 1), e.g.
+// to match a specific filter length. The time constant is exactly n samples,
+// without going through milliseconds; GetAttack reports the equivalent time.
+// SetAttack switches back to milliseconds. See SetSampleTimesFollowRate for
+// what happens on sample rate changes.
+func (c *SoftKneeCompressor) SetAttackSamples(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attackSamples = max(1, n)
+	c.updateEnvelopeConstants()
+}
+
+// GetAttackSamples returns the attack time in samples, or 0 if it is set in milliseconds.
+func (c *SoftKneeCompressor) GetAttackSamples() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.attackSamples
+}
+
+// SetReleaseSamples sets the release time directly in samples (minimum 1).
+// SetRelease switches back to milliseconds. See SetAttackSamples.
+func (c *SoftKneeCompressor) SetReleaseSamples(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.releaseSamples = max(1, n)
+	c.updateEnvelopeConstants()
+}
+
+// GetReleaseSamples returns the release time in samples, or 0 if it is set in milliseconds.
+func (c *SoftKneeCompressor) GetReleaseSamples() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.releaseSamples
+}
+
+// SetSampleTimesFollowRate chooses how attack and release times set in samples
+// react to sample rate changes. By default the sample counts are held fixed, so
+// the times in milliseconds change with the rate; when enabled the counts are
+// rescaled to the new rate, keeping the duration.
+func (c *SoftKneeCompressor) SetSampleTimesFollowRate(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sampleTimesFollow = enabled
+}
+
+// GetSampleTimesFollowRate returns whether sample times are rescaled on rate changes.
+func (c *SoftKneeCompressor) GetSampleTimesFollowRate() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sampleTimesFollow
+}
+
+// applySampleTimes derives the millisecond times from the times set in
+// samples (internal, assumes lock held).
+func (c *SoftKneeCompressor) applySampleTimes() {
+	if c.attackSamples > 0 {
+		c.attackMs = float64(c.attackSamples) * 1000.0 / c.sampleRate
+	}
+
+	if c.releaseSamples > 0 {
+		c.releaseMs = float64(c.releaseSamples) * 1000.0 / c.sampleRate
+	}
+}
+
+// rescaleSampleTimes scales the times set in samples to a new sample rate if
+// they follow the rate (internal, assumes lock held).
+func (c *SoftKneeCompressor) rescaleSampleTimes(rate float64) {
+	if !c.sampleTimesFollow {
+		return
+	}
+
+	scale := rate / c.sampleRate
+
+	if c.attackSamples > 0 {
+		c.attackSamples = max(1, int(math.Round(float64(c.attackSamples)*scale)))
+	}
+
+	if c.releaseSamples > 0 {
+		c.releaseSamples = max(1, int(math.Round(float64(c.releaseSamples)*scale)))
+	}
+}
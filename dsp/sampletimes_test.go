@@ -0,0 +1,63 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSampleTimesMatchMilliseconds verifies the coefficients derived from N
+// samples match those derived from the equivalent milliseconds.
+func TestSampleTimesMatchMilliseconds(t *testing.T) {
+	t.Parallel()
+
+	bySamples := NewSoftKneeCompressor(48000.0, 1)
+	bySamples.SetAttackSamples(480)
+	bySamples.SetReleaseSamples(9600)
+
+	byMs := NewSoftKneeCompressor(48000.0, 1)
+	byMs.SetAttack(10.0)
+	byMs.SetRelease(200.0)
+
+	got, want := bySamples.GetCoefficients(), byMs.GetCoefficients()
+
+	if math.Abs(got.AttackFactor-want.AttackFactor) > 1e-12 {
+		t.Errorf("Attack factor %g, want %g", got.AttackFactor, want.AttackFactor)
+	}
+
+	if math.Abs(got.ReleaseFactor-want.ReleaseFactor) > 1e-12 {
+		t.Errorf("Release factor %g, want %g", got.ReleaseFactor, want.ReleaseFactor)
+	}
+
+	if attack := bySamples.GetAttack(); math.Abs(attack-10.0) > 1e-9 {
+		t.Errorf("GetAttack = %f ms, want 10 ms", attack)
+	}
+
+	bySamples.SetAttack(5.0)
+
+	if n := bySamples.GetAttackSamples(); n != 0 {
+		t.Errorf("SetAttack should switch back to milliseconds, got %d samples", n)
+	}
+}
+
+// TestSampleTimesOnRateChange verifies sample times are held fixed by default
+// and rescaled to keep their duration when following the rate.
+func TestSampleTimesOnRateChange(t *testing.T) {
+	t.Parallel()
+
+	fixed := NewSoftKneeCompressor(48000.0, 1)
+	fixed.SetAttackSamples(480)
+	fixed.SetSampleRate(96000.0)
+
+	if n, attack := fixed.GetAttackSamples(), fixed.GetAttack(); n != 480 || math.Abs(attack-5.0) > 1e-9 {
+		t.Errorf("Fixed: %d samples / %f ms, want 480 samples / 5 ms", n, attack)
+	}
+
+	follow := NewSoftKneeCompressor(48000.0, 1)
+	follow.SetSampleTimesFollowRate(true)
+	follow.SetAttackSamples(480)
+	follow.SetSampleRate(96000.0)
+
+	if n, attack := follow.GetAttackSamples(), follow.GetAttack(); n != 960 || math.Abs(attack-10.0) > 1e-9 {
+		t.Errorf("Following: %d samples / %f ms, want 960 samples / 10 ms", n, attack)
+	}
+}
@@ -0,0 +1,70 @@
+package dsp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+)
+
+// sessionStats accumulates one channel's meter values over the whole session.
+type sessionStats struct {
+	peakInput  float64 // Highest input peak (linear)
+	peakOutput float64 // Highest output peak (linear)
+	maxGR      float64 // Largest block gain reduction in dB
+	grSum      float64 // Block gain reduction in dB weighted by block length
+	samples    uint64  // Samples processed
+}
+
+// updateSession folds one block into the channel's session statistics
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateSession(channel int, maxInput, maxOutput, minGain float64, samples int) {
+	stats := &c.sessions[channel]
+
+	blockGR := 0.0
+	if minGain > 0 && minGain < 1.0 {
+		blockGR = -20.0 * math.Log10(minGain)
+	}
+
+	stats.peakInput = math.Max(stats.peakInput, maxInput)
+	stats.peakOutput = math.Max(stats.peakOutput, maxOutput)
+	stats.maxGR = math.Max(stats.maxGR, blockGR)
+	stats.grSum += blockGR * float64(samples)
+	stats.samples += uint64(samples)
+}
+
+// SessionSummary reports what the compressor did since it was created: the
+// processed blocks and, per channel, the peak input and output levels, the
+// largest and average gain reduction and the clip counts. Reset leaves the
+// session statistics alone. The summary has one line per channel after a
+// header line.
+func (c *SoftKneeCompressor) SessionSummary() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "Processed %d blocks", atomic.LoadUint64(&c.processedBlocks))
+
+	for ch, stats := range c.sessions {
+		averageGR := 0.0
+		if stats.samples > 0 {
+			averageGR = stats.grSum / float64(stats.samples)
+		}
+
+		fmt.Fprintf(&builder, "\nch %d: peak in %s dBFS, peak out %s dBFS, GR max %.1f dB avg %.1f dB, clips in %d out %d",
+			ch, formatSessionDB(stats.peakInput), formatSessionDB(stats.peakOutput), stats.maxGR, averageGR,
+			atomic.LoadUint64(&c.inputClips[ch]), atomic.LoadUint64(&c.outputClips[ch]))
+	}
+
+	return builder.String()
+}
+
+// formatSessionDB formats a linear level in dB, showing silence as -inf.
+func formatSessionDB(level float64) string {
+	if level <= 0 {
+		return "-inf"
+	}
+
+	return fmt.Sprintf("%.1f", 20.0*math.Log10(level))
+}
@@ -0,0 +1,47 @@
+package dsp
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSessionSummary verifies the summary reflects a known processing history
+// and survives a Reset.
+func TestSessionSummary(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(4.0)
+	comp.SetKnee(0.0)
+	comp.SetAttack(0.1)
+	comp.SetAutoMakeup(false)
+	comp.SetMakeupGain(0.0)
+	comp.SetStartupFade(0.0)
+
+	processLevel(comp, 0.05, 10) // -26 dBFS, below the threshold
+	processLevel(comp, 1.0, 10)  // 0 dBFS: 20 dB over, 15 dB reduction when settled
+
+	comp.Reset()
+
+	summary := comp.SessionSummary()
+	lines := strings.Split(summary, "\n")
+
+	if len(lines) != 3 {
+		t.Fatalf("Summary has %d lines, want a header and 2 channels:\n%s", len(lines), summary)
+	}
+
+	if lines[0] != "Processed 20 blocks" {
+		t.Errorf("Header %q, want %q", lines[0], "Processed 20 blocks")
+	}
+
+	for _, want := range []string{"ch 0: peak in 0.0 dBFS", "GR max 15.0 dB avg 7.", "clips in 4800 out 0"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("Channel 0 line %q should contain %q", lines[1], want)
+		}
+	}
+
+	if want := "ch 1: peak in -inf dBFS, peak out -inf dBFS, GR max 0.0 dB avg 0.0 dB, clips in 0 out 0"; lines[2] != want {
+		t.Errorf("Channel 1 line %q, want %q", lines[2], want)
+	}
+}
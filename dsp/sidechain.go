@@ -0,0 +1,197 @@
+package dsp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+const (
+	// Pivot frequency of the sidechain tilt filter in Hz.
+	sidechainTiltPivotHz = 1000.0
+
+	// Octaves on each side of the pivot over which the tilt slope is approximated.
+	sidechainTiltSpanOctaves = 3.0
+
+	// Maximum sidechain tilt in dB per octave (the limit of a first-order slope).
+	maxSidechainTilt = 6.0
+)
+
+// ErrInvalidLinkWeights is returned when link weights don't match the channel
+// count or contain negative or non-finite values.
+var ErrInvalidLinkWeights = errors.New("invalid link weights")
+
+// SetSidechainSource selects which input channels drive the detector for all
+// processed channels. The detection level is the maximum absolute value across
+// the selected channels, so every channel ducks together in response to them.
+// An empty or nil slice restores independent per-channel detection.
+//
+// Cross-channel detection needs the whole frame and therefore only applies to
+// ProcessChannels and ProcessFrames; ProcessBlock handles a single channel and
+// keeps detecting it.
+func (c *SoftKneeCompressor) SetSidechainSource(channels []int) error {
+	for _, ch := range channels {
+		if ch < 0 || ch >= c.channels {
+			return fmt.Errorf("%w: %d", ErrInvalidChannel, ch)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Duplicates don't change the maximum but would run a channel's linked
+	// high-pass twice per frame
+	c.sidechainSource = c.sidechainSource[:0]
+	for _, ch := range channels {
+		if !slices.Contains(c.sidechainSource, ch) {
+			c.sidechainSource = append(c.sidechainSource, ch)
+		}
+	}
+
+	return nil
+}
+
+// GetSidechainSource returns a copy of the channels driving the shared detector.
+func (c *SoftKneeCompressor) GetSidechainSource() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]int(nil), c.sidechainSource...)
+}
+
+// sidechainKey returns the shared key for one interleaved frame, i.e. the
+// weighted source signal with the largest magnitude, and whether a sidechain
+// source is configured (internal, assumes lock held).
+func (c *SoftKneeCompressor) sidechainKey(frame []float32) (float64, bool) {
+	if len(c.sidechainSource) == 0 {
+		return 0, false
+	}
+
+	key := 0.0
+	for _, ch := range c.sidechainSource {
+		weighted := c.filterLinkedKey(c.linkWeights[ch]*c.detectorSignal(float64(frame[ch]), ch), ch)
+		if math.Abs(weighted) > math.Abs(key) {
+			key = weighted
+		}
+	}
+
+	return key, true
+}
+
+// SetLinkWeights scales each channel's contribution to the shared detector set
+// up with SetSidechainSource or SetLinkGroups. The linked level is the weighted
+// maximum over the linked channels, so a channel with weight 0.5 needs to be
+// 6 dB louder to drive the gain reduction as much as a channel with weight 1.
+// Weights must be non-negative, one per channel; nil resets every weight to 1.
+func (c *SoftKneeCompressor) SetLinkWeights(weights []float64) error {
+	if weights != nil && len(weights) != c.channels {
+		return fmt.Errorf("%w: got %d weights for %d channels", ErrInvalidLinkWeights, len(weights), c.channels)
+	}
+
+	for _, weight := range weights {
+		if weight < 0.0 || !isFinite(weight) {
+			return fmt.Errorf("%w: %f", ErrInvalidLinkWeights, weight)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for ch := range c.linkWeights {
+		c.linkWeights[ch] = 1.0
+		if weights != nil {
+			c.linkWeights[ch] = weights[ch]
+		}
+	}
+
+	return nil
+}
+
+// GetLinkWeights returns a copy of the per-channel link weights.
+func (c *SoftKneeCompressor) GetLinkWeights() []float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]float64(nil), c.linkWeights...)
+}
+
+// SetSidechainTilt tilts the detection signal around a 1 kHz pivot, making the
+// detector more (positive values) or less (negative values) sensitive to highs
+// than to lows. Only the detection path is filtered; the audio is unaffected.
+//
+// The tilt is realised per channel as complementary first-order shelves whose
+// gains match the requested slope three octaves either side of the pivot.
+func (c *SoftKneeCompressor) SetSidechainTilt(dBPerOctave float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(dBPerOctave) {
+		return
+	}
+
+	dBPerOctave = math.Max(-maxSidechainTilt, math.Min(maxSidechainTilt, dBPerOctave))
+
+	c.sidechainTilt = dBPerOctave
+	c.tiltHighGain = DBToLinear(dBPerOctave * sidechainTiltSpanOctaves)
+	c.tiltLowGain = DBToLinear(-dBPerOctave * sidechainTiltSpanOctaves)
+}
+
+// SetSidechainListen replaces the output with the key signal that drives the
+// detector, after the sidechain tilt: the external key in ProcessBlockSidechain,
+// the shared source in ProcessChannels and ProcessFrames, or the channel's own
+// input otherwise.
+// Gain reduction keeps running so meters stay live while listening.
+func (c *SoftKneeCompressor) SetSidechainListen(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sidechainListen = enabled
+}
+
+// GetSidechainListen returns whether the output is replaced by the detector key.
+func (c *SoftKneeCompressor) GetSidechainListen() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sidechainListen
+}
+
+// ProcessBlockSidechain processes a block of a channel like ProcessBlock, but
+// the detector is driven by the external key instead of the input itself, e.g.
+// to duck music under a voice. key must have the same length as in and out.
+// The envelope uses the SetSidechainEnvelope times where set.
+func (c *SoftKneeCompressor) ProcessBlockSidechain(in, key, out []float32, channel int) {
+	if channel < 0 || channel >= c.channels || len(in) != len(out) || len(key) != len(in) || len(in) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.externalKey = true
+	processBlockLocked(c, in, key, out, channel, nil)
+	c.externalKey = false
+}
+
+// GetSidechainTilt returns the sidechain tilt in dB per octave.
+func (c *SoftKneeCompressor) GetSidechainTilt() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sidechainTilt
+}
+
+// detectorSignal shapes one sample of a channel's detection signal and returns
+// the filtered, unrectified key (internal, assumes lock held).
+func (c *SoftKneeCompressor) detectorSignal(sample float64, channel int) float64 {
+	x := sample
+
+	if c.sidechainTilt != 0 {
+		c.tiltState[channel] += (x - c.tiltState[channel]) * c.tiltCoeff
+		low := c.tiltState[channel]
+		x = low*c.tiltLowGain + (x-low)*c.tiltHighGain
+	}
+
+	return x
+}
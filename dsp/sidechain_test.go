@@ -0,0 +1,358 @@
+package dsp
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestSidechainSourceDucksAllChannels verifies that a selected source channel drives
+// the detector for every channel in frame-based processing.
+func TestSidechainSourceDucksAllChannels(t *testing.T) {
+	t.Parallel()
+
+	const (
+		channels = 4
+		frames   = 4800
+	)
+
+	comp := NewSoftKneeCompressor(48000.0, channels)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(1.0)
+	comp.SetMakeupGain(0.0)
+
+	err := comp.SetSidechainSource([]int{3})
+	if err != nil {
+		t.Fatalf("SetSidechainSource failed: %v", err)
+	}
+
+	quiet := float32(0.05) // About -26 dBFS, below the knee on its own
+	loud := float32(0.9)
+
+	in := make([]float32, frames*channels)
+	for i := range frames {
+		for ch := range 3 {
+			in[i*channels+ch] = quiet
+		}
+
+		in[i*channels+3] = loud
+	}
+
+	out := make([]float32, len(in))
+	comp.ProcessFrames(in, out)
+
+	last := (frames - 1) * channels
+	for ch := range channels {
+		expected := quiet
+		if ch == 3 {
+			expected = loud
+		}
+
+		if out[last+ch] >= expected*0.9 {
+			t.Errorf("Channel %d should duck in response to channel 3: in %f, out %f",
+				ch, expected, out[last+ch])
+		}
+	}
+
+	// All channels share the detector, so the gain applied is identical
+	gain0 := float64(out[last]) / float64(quiet)
+	gain3 := float64(out[last+3]) / float64(loud)
+
+	if math.Abs(gain0-gain3) > 1e-4 {
+		t.Errorf("Shared detector should apply the same gain: ch0 %f, ch3 %f", gain0, gain3)
+	}
+}
+
+// TestSidechainSourceValidation verifies invalid channel indices are rejected.
+func TestSidechainSourceValidation(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+
+	err := comp.SetSidechainSource([]int{1})
+	if err != nil {
+		t.Fatalf("Valid source rejected: %v", err)
+	}
+
+	for _, invalid := range [][]int{{2}, {-1}, {0, 5}} {
+		err = comp.SetSidechainSource(invalid)
+		if !errors.Is(err, ErrInvalidChannel) {
+			t.Errorf("Source %v should be rejected with ErrInvalidChannel, got %v", invalid, err)
+		}
+	}
+
+	if src := comp.GetSidechainSource(); len(src) != 1 || src[0] != 1 {
+		t.Errorf("Rejected source should keep the previous selection, got %v", src)
+	}
+
+	if err = comp.SetSidechainSource([]int{1, 0, 1}); err != nil {
+		t.Fatalf("Source with a duplicate rejected: %v", err)
+	}
+
+	if src := comp.GetSidechainSource(); len(src) != 2 || src[0] != 1 || src[1] != 0 {
+		t.Errorf("Duplicate source channels should be dropped, got %v", src)
+	}
+
+	err = comp.SetSidechainSource(nil)
+	if err != nil || len(comp.GetSidechainSource()) != 0 {
+		t.Errorf("Nil source should clear the selection: err %v, src %v", err, comp.GetSidechainSource())
+	}
+}
+
+// tiltedGainReduction returns the steady-state block gain for a sine at the given
+// frequency with the given sidechain tilt.
+func tiltedGainReduction(freq, tilt float64) float64 {
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetSidechainTilt(tilt)
+
+	const blockSize = 480
+
+	in := make([]float32, blockSize)
+	out := make([]float32, blockSize)
+	pos := 0
+
+	for range 100 {
+		for i := range in {
+			in[i] = float32(0.2 * math.Sin(2.0*math.Pi*freq*float64(pos)/48000.0))
+			pos++
+		}
+
+		comp.ProcessBlock(in, out, 0)
+	}
+
+	return -LinearToDB(comp.GetMeters().GainReductionL)
+}
+
+// TestSidechainTilt verifies positive tilt makes bright signals trigger more reduction
+// and dark signals less, relative to a flat detector.
+func TestSidechainTilt(t *testing.T) {
+	t.Parallel()
+
+	brightFlat := tiltedGainReduction(8000.0, 0.0)
+	brightTilted := tiltedGainReduction(8000.0, 3.0)
+	darkFlat := tiltedGainReduction(100.0, 0.0)
+	darkTilted := tiltedGainReduction(100.0, 3.0)
+
+	if brightTilted <= brightFlat+1.0 {
+		t.Errorf("Positive tilt should increase reduction on bright signal: flat %.2f dB, tilted %.2f dB",
+			brightFlat, brightTilted)
+	}
+
+	if darkTilted >= darkFlat-1.0 {
+		t.Errorf("Positive tilt should decrease reduction on dark signal: flat %.2f dB, tilted %.2f dB",
+			darkFlat, darkTilted)
+	}
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetSidechainTilt(20.0)
+
+	if comp.GetSidechainTilt() != maxSidechainTilt {
+		t.Errorf("Tilt should clamp to %.1f dB/oct, got %f", maxSidechainTilt, comp.GetSidechainTilt())
+	}
+}
+
+// linkedEnvelope runs a silent channel 0 and a loud channel 1 through a linked
+// detector with the given weights and returns the shared envelope.
+func linkedEnvelope(t *testing.T, weights []float64) float64 {
+	t.Helper()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetAttack(1.0)
+
+	err := comp.SetSidechainSource([]int{0, 1})
+	if err != nil {
+		t.Fatalf("SetSidechainSource failed: %v", err)
+	}
+
+	err = comp.SetLinkWeights(weights)
+	if err != nil {
+		t.Fatalf("SetLinkWeights failed: %v", err)
+	}
+
+	in := make([]float32, 2*4800)
+	for i := 1; i < len(in); i += 2 {
+		in[i] = 0.8
+	}
+
+	comp.ProcessFrames(in, make([]float32, len(in)))
+
+	return comp.peak[0]
+}
+
+// TestLinkWeights verifies a down-weighted channel contributes proportionally
+// less to the shared detector.
+func TestLinkWeights(t *testing.T) {
+	t.Parallel()
+
+	full := linkedEnvelope(t, nil)
+	weighted := linkedEnvelope(t, []float64{1.0, 0.1})
+
+	if ratio := weighted / full; math.Abs(ratio-0.1) > 0.005 {
+		t.Errorf("Expected the linked level to scale with the weight (0.1), got ratio %f", ratio)
+	}
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+
+	for _, weights := range [][]float64{{1.0}, {1.0, -0.5}, {1.0, math.NaN()}} {
+		if err := comp.SetLinkWeights(weights); !errors.Is(err, ErrInvalidLinkWeights) {
+			t.Errorf("SetLinkWeights(%v) should fail with ErrInvalidLinkWeights, got %v", weights, err)
+		}
+	}
+
+	if got := comp.GetLinkWeights(); got[0] != 1.0 || got[1] != 1.0 {
+		t.Errorf("Rejected weights should leave the defaults in place, got %v", got)
+	}
+}
+
+// sidechainTestSignals returns a quiet 1 kHz main signal and a loud 200 Hz key.
+func sidechainTestSignals(samples int) ([]float32, []float32) {
+	main := make([]float32, samples)
+	key := make([]float32, samples)
+
+	for i := range samples {
+		main[i] = float32(0.05 * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+		key[i] = float32(0.8 * math.Sin(2.0*math.Pi*200.0*float64(i)/48000.0))
+	}
+
+	return main, key
+}
+
+// TestExternalSidechainDucks verifies an external key drives the gain
+// reduction of a main signal that is below threshold on its own.
+func TestExternalSidechainDucks(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(-20.0)
+	comp.SetAttack(1.0)
+	comp.SetMakeupGain(0.0)
+
+	main, key := sidechainTestSignals(9600)
+	out := make([]float32, len(main))
+	comp.ProcessBlockSidechain(main, key, out, 0)
+
+	// Compare the peak of the last 10 ms with the main signal's amplitude
+	peak := 0.0
+	for _, sample := range out[len(out)-480:] {
+		peak = math.Max(peak, math.Abs(float64(sample)))
+	}
+
+	if peak > 0.05*0.5 {
+		t.Errorf("External key should duck the main signal by more than 6 dB, got peak %f", peak)
+	}
+}
+
+// TestSidechainListenExternalKey verifies listen mode outputs the external key
+// that drives the detector rather than the main audio.
+func TestSidechainListenExternalKey(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetSidechainListen(true)
+
+	main, key := sidechainTestSignals(4800)
+	out := make([]float32, len(main))
+	comp.ProcessBlockSidechain(main, key, out, 0)
+
+	for i := range out {
+		if math.Abs(float64(out[i]-key[i])) > 1e-6 {
+			t.Fatalf("Sample %d: listen output should be the external key %f, got %f", i, key[i], out[i])
+		}
+	}
+
+	// Internal detection listens to the channel's own (tilted) input
+	comp.SetSidechainTilt(0.0)
+	comp.ProcessBlock(main, out, 0)
+
+	for i := range out {
+		if math.Abs(float64(out[i]-main[i])) > 1e-6 {
+			t.Fatalf("Sample %d: internal listen output should be the input %f, got %f", i, main[i], out[i])
+		}
+	}
+}
+
+// TestProcessChannelsSidechainSource verifies that planar processing applies the
+// sidechain source exactly like interleaved frame processing.
+func TestProcessChannelsSidechainSource(t *testing.T) {
+	t.Parallel()
+
+	const (
+		channels = 2
+		block    = 256
+		frames   = 20 * block
+	)
+
+	planar := NewSoftKneeCompressor(48000.0, channels)
+	interleaved := NewSoftKneeCompressor(48000.0, channels)
+
+	for _, comp := range []*SoftKneeCompressor{planar, interleaved} {
+		comp.SetThreshold(-20.0)
+		comp.SetAttack(1.0)
+		comp.SetMakeupGain(0.0)
+
+		if err := comp.SetSidechainSource([]int{1}); err != nil {
+			t.Fatalf("SetSidechainSource failed: %v", err)
+		}
+	}
+
+	in := [][]float32{make([]float32, frames), make([]float32, frames)}
+	for i := range frames {
+		in[0][i] = 0.05 * float32(math.Sin(2*math.Pi*440*float64(i)/48000))
+		in[1][i] = 0.9 * float32(math.Sin(2*math.Pi*110*float64(i)/48000))
+	}
+
+	out := [][]float32{make([]float32, frames), make([]float32, frames)}
+	frameIn := make([]float32, block*channels)
+	frameOut := make([]float32, block*channels)
+
+	for start := 0; start < frames; start += block {
+		end := start + block
+		planar.ProcessChannels(
+			[][]float32{in[0][start:end], in[1][start:end]},
+			[][]float32{out[0][start:end], out[1][start:end]},
+		)
+
+		for i := range block {
+			for ch := range channels {
+				frameIn[i*channels+ch] = in[ch][start+i]
+			}
+		}
+
+		interleaved.ProcessFrames(frameIn, frameOut)
+
+		for i := range block {
+			for ch := range channels {
+				if out[ch][start+i] != frameOut[i*channels+ch] {
+					t.Fatalf("Frame %d channel %d: planar %f, interleaved %f",
+						start+i, ch, out[ch][start+i], frameOut[i*channels+ch])
+				}
+			}
+		}
+	}
+
+	// The quiet channel ducks with the loud source
+	meters, err := planar.GetChannelMeters(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gr := LinearToDB(meters.GainReduction); gr > -3.0 {
+		t.Errorf("Expected channel 0 to duck with channel 1, got %.2f dB", gr)
+	}
+}
+
+// TestProcessChannelsValidation verifies mismatched planar buffers are ignored.
+func TestProcessChannelsValidation(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+
+	out := []float32{7, 7}
+	comp.ProcessChannels([][]float32{{1, 1}}, [][]float32{out})
+	comp.ProcessChannels([][]float32{{1, 1}, {1}}, [][]float32{out, {7}})
+
+	if out[0] != 7 || out[1] != 7 {
+		t.Errorf("Expected mismatched buffers to be ignored, got %v", out)
+	}
+}
@@ -0,0 +1,70 @@
+package dsp
+
+// SetSidechainEnvelope sets separate attack and release times in milliseconds
+// for blocks driven by an external key through ProcessBlockSidechain, e.g. a
+// fast duck under a voice on a compressor whose own timing suits the mix bus.
+// A time of 0 falls back to the main attack or release, which also keeps the
+// program-dependent release. Speed and stable mode only scale the main times.
+// Non-finite or negative values are ignored; non-zero times are clamped to the
+// main minimums of 0.1 ms attack and 1 ms release.
+func (c *SoftKneeCompressor) SetSidechainEnvelope(attackMs, releaseMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(attackMs) || !isFinite(releaseMs) || attackMs < 0 || releaseMs < 0 {
+		return
+	}
+
+	if attackMs > 0 && attackMs < 0.1 {
+		attackMs = 0.1
+	}
+
+	if releaseMs > 0 && releaseMs < 1.0 {
+		releaseMs = 1.0
+	}
+
+	c.sidechainAttackMs = attackMs
+	c.sidechainReleaseMs = releaseMs
+	c.updateSidechainEnvelope()
+}
+
+// GetSidechainEnvelope returns the external-key attack and release times in
+// milliseconds; 0 means the main time is used.
+func (c *SoftKneeCompressor) GetSidechainEnvelope() (attackMs, releaseMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sidechainAttackMs, c.sidechainReleaseMs
+}
+
+// updateSidechainEnvelope recalculates the external-key envelope coefficients
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateSidechainEnvelope() {
+	if c.sidechainAttackMs > 0 {
+		c.sidechainAttackFactor = 1.0 - c.halfLives.decay(c.sidechainAttackMs*0.001*c.sampleRate)
+	}
+
+	if c.sidechainReleaseMs > 0 {
+		c.sidechainReleaseFactor = c.halfLives.decay(c.sidechainReleaseMs * 0.001 * c.sampleRate)
+	}
+}
+
+// envelopeFactors returns the attack and release coefficients of a channel's
+// envelope follower, using the external-key times while an external key
+// drives the detector (internal, assumes lock held).
+func (c *SoftKneeCompressor) envelopeFactors(channel int) (attack, release float64) {
+	attack, release = c.attackFactor, c.releaseFactorFor(channel)
+	if !c.externalKey {
+		return attack, release
+	}
+
+	if c.sidechainAttackMs > 0 {
+		attack = c.sidechainAttackFactor
+	}
+
+	if c.sidechainReleaseMs > 0 {
+		release = c.sidechainReleaseFactor
+	}
+
+	return attack, release
+}
@@ -0,0 +1,92 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSidechainEnvelope verifies music ducked under an external voice key
+// follows the sidechain attack and release instead of the slower main timing,
+// while the main timing still drives ProcessBlock.
+func TestSidechainEnvelope(t *testing.T) {
+	t.Parallel()
+
+	const sampleRate = 48000.0
+
+	newComp := func(attackMs, releaseMs float64) *SoftKneeCompressor {
+		comp := NewSoftKneeCompressor(sampleRate, 1)
+		comp.SetThreshold(-30.0)
+		comp.SetRatio(10.0)
+		comp.SetMakeupGain(0.0)
+		comp.SetStartupFade(0.0)
+		comp.SetAttack(attackMs)
+		comp.SetRelease(releaseMs)
+
+		return comp
+	}
+
+	// Mix-bus timing with a fast duck, the same fast timing as the main
+	// envelope, and mix-bus timing alone
+	ducker := newComp(50.0, 500.0)
+	ducker.SetSidechainEnvelope(1.0, 50.0)
+
+	fast := newComp(1.0, 50.0)
+	slow := newComp(50.0, 500.0)
+
+	if attackMs, releaseMs := ducker.GetSidechainEnvelope(); attackMs != 1.0 || releaseMs != 50.0 {
+		t.Fatalf("GetSidechainEnvelope = %v, %v, want 1, 50", attackMs, releaseMs)
+	}
+
+	// 200 ms of voice, then 400 ms of pause, over steady music
+	frames := int(0.6 * sampleRate)
+	music := make([]float32, frames)
+	voice := make([]float32, frames)
+
+	for i := range frames {
+		music[i] = float32(0.1 * math.Sin(2*math.Pi*100*float64(i)/sampleRate))
+		if i < frames/3 {
+			voice[i] = float32(0.5 * math.Sin(2*math.Pi*300*float64(i)/sampleRate))
+		}
+	}
+
+	duck := func(comp *SoftKneeCompressor) []float32 {
+		in := append([]float32(nil), music...)
+		out := make([]float32, frames)
+		comp.ProcessBlockSidechain(in, append([]float32(nil), voice...), out, 0)
+
+		return out
+	}
+
+	duckerOut, fastOut, slowOut := duck(ducker), duck(fast), duck(slow)
+
+	maxFastDiff, maxSlowDiff := 0.0, 0.0
+	for i := range duckerOut {
+		maxFastDiff = math.Max(maxFastDiff, math.Abs(float64(duckerOut[i]-fastOut[i])))
+		maxSlowDiff = math.Max(maxSlowDiff, math.Abs(float64(duckerOut[i]-slowOut[i])))
+	}
+
+	if maxFastDiff > 1e-6 {
+		t.Errorf("ducking differs by up to %g from the sidechain timing", maxFastDiff)
+	}
+
+	if maxSlowDiff < 0.01 {
+		t.Errorf("ducking differs by only %g from the main timing", maxSlowDiff)
+	}
+
+	// Without an external key the main timing applies
+	mainIn := append([]float32(nil), voice...)
+	slowIn := append([]float32(nil), voice...)
+	mainOut := make([]float32, frames)
+	slowMainOut := make([]float32, frames)
+
+	ducker.Reset()
+	slow.Reset()
+	ducker.ProcessBlock(mainIn, mainOut, 0)
+	slow.ProcessBlock(slowIn, slowMainOut, 0)
+
+	for i := range mainOut {
+		if mainOut[i] != slowMainOut[i] {
+			t.Fatalf("ProcessBlock sample %d = %v, want the main timing's %v", i, mainOut[i], slowMainOut[i])
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package dsp
+
+import "math"
+
+// Gain the slew limiter starts from when the tracked gain is (near) zero, so
+// a gain rising from silence isn't stuck at zero (-100 dB).
+const slewFloorGain = 1e-5
+
+// SetMaxSlewRate limits how fast the total applied gain (gain reduction,
+// makeup, output gain and startup fade, or unity while bypassed) may change,
+// in dB per millisecond. It is a final safety net against clicks whichever
+// parameter changed: a bypass toggle, an instant makeup or output gain jump or
+// an auto makeup toggle. Set it above the fastest intended gain change, as
+// attacks faster than the limit are slowed down as well. 0 (the default)
+// disables the limit; negative values are treated as 0.
+func (c *SoftKneeCompressor) SetMaxSlewRate(dBPerMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(dBPerMs) {
+		return
+	}
+
+	c.maxSlewDBPerMs = math.Max(dBPerMs, 0.0)
+	c.updateSlewStep()
+}
+
+// GetMaxSlewRate returns the slew limit of the total gain in dB per millisecond.
+func (c *SoftKneeCompressor) GetMaxSlewRate() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.maxSlewDBPerMs
+}
+
+// updateSlewStep recalculates the largest gain ratio between two samples
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateSlewStep() {
+	c.slewStep = DBToLinear(c.maxSlewDBPerMs * 1000.0 / c.sampleRate)
+}
+
+// slewGain moves a channel's total gain towards target by at most the slew
+// limit and returns it. Without a limit it follows the target directly, so
+// enabling the limit later starts from the current gain (internal, assumes
+// lock held).
+func (c *SoftKneeCompressor) slewGain(target float64, channel int) float64 {
+	if c.maxSlewDBPerMs == 0.0 {
+		c.slewedGain[channel] = target
+
+		return target
+	}
+
+	current := math.Max(c.slewedGain[channel], slewFloorGain)
+	limited := math.Max(current/c.slewStep, math.Min(current*c.slewStep, target))
+	c.slewedGain[channel] = limited
+
+	return limited
+}
+
+// applyBypass runs a bypassed sample through the gain slew towards unity and
+// the output stages that stay active in bypass (internal, assumes lock held).
+func (c *SoftKneeCompressor) applyBypass(sample float64, channel int) float64 {
+	sample *= c.slewGain(1.0, channel)
+
+	return c.applyMutes(c.applyDim(c.delayLimiter(sample, channel), channel), channel)
+}
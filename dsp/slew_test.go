@@ -0,0 +1,103 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestMaxSlewRate verifies an instant 40 dB output gain jump and a bypass
+// toggle never change the total gain faster than the slew limit, and that the
+// gain still arrives at its target.
+func TestMaxSlewRate(t *testing.T) {
+	t.Parallel()
+
+	const (
+		sampleRate  = 48000.0
+		slewDBPerMs = 0.5
+		level       = 0.25 // Below the threshold, so only the output gain acts
+	)
+
+	comp := NewSoftKneeCompressor(sampleRate, 1)
+	comp.SetMakeupGain(0.0)
+	comp.SetStartupFade(0.0)
+	comp.SetThreshold(0.0)
+
+	if err := comp.SetSmoothingTimes(map[SmoothedParameter]float64{SmoothMakeupGain: 0.0}); err != nil {
+		t.Fatal(err)
+	}
+
+	comp.SetMaxSlewRate(slewDBPerMs)
+
+	in := make([]float32, 4800)
+	out := make([]float32, len(in))
+
+	maxStepDB := 0.0
+	previousDB := 0.0
+
+	run := func() {
+		for i := range in {
+			in[i] = level
+		}
+
+		comp.ProcessBlock(in, out, 0)
+
+		for _, sample := range out {
+			gainDB := 20.0 * math.Log10(float64(sample)/level)
+			maxStepDB = math.Max(maxStepDB, math.Abs(gainDB-previousDB))
+			previousDB = gainDB
+		}
+	}
+
+	run()
+	comp.SetOutputGain(-40.0)
+	run()
+
+	if math.Abs(previousDB+40.0) > 0.01 {
+		t.Errorf("Gain after 100 ms at %.2f dB, want -40 dB", previousDB)
+	}
+
+	comp.SetBypass(true)
+	run()
+
+	if math.Abs(previousDB) > 0.01 {
+		t.Errorf("Bypassed gain after 100 ms at %.2f dB, want 0 dB", previousDB)
+	}
+
+	limit := slewDBPerMs * 1000.0 / sampleRate
+	if maxStepDB > limit*1.001 {
+		t.Errorf("Gain changed by %.4f dB in one sample, limit %.4f dB", maxStepDB, limit)
+	}
+
+	if maxStepDB < limit*0.5 {
+		t.Errorf("Gain changed by at most %.4f dB per sample, expected the limit %.4f dB to be reached", maxStepDB, limit)
+	}
+}
+
+// TestMaxSlewRateDisabled verifies the default leaves instant changes alone.
+func TestMaxSlewRateDisabled(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetMakeupGain(0.0)
+	comp.SetStartupFade(0.0)
+	comp.SetThreshold(0.0)
+
+	if err := comp.SetSmoothingTimes(map[SmoothedParameter]float64{SmoothMakeupGain: 0.0}); err != nil {
+		t.Fatal(err)
+	}
+
+	in := []float32{0.25, 0.25}
+	out := make([]float32, len(in))
+
+	comp.ProcessBlock(in, out, 0)
+	comp.SetOutputGain(-40.0)
+	comp.ProcessBlock(in, out, 0)
+
+	if got := 20.0 * math.Log10(float64(out[0])/0.25); math.Abs(got+40.0) > 0.01 {
+		t.Errorf("Gain without a slew limit at %.2f dB, want -40 dB right away", got)
+	}
+
+	if comp.GetMaxSlewRate() != 0.0 {
+		t.Errorf("Default slew limit %f, want 0", comp.GetMaxSlewRate())
+	}
+}
@@ -0,0 +1,173 @@
+package dsp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// SmoothedParameter identifies a parameter whose changes are ramped.
+type SmoothedParameter int
+
+const (
+	// SmoothThreshold ramps threshold changes (default 5 ms).
+	SmoothThreshold SmoothedParameter = iota
+
+	// SmoothMakeupGain ramps makeup and output gain changes, including
+	// auto-makeup toggles and headroom back-off (default 20 ms).
+	SmoothMakeupGain
+)
+
+// Threshold difference in dB below which a threshold ramp snaps to its target.
+const thresholdSnapDB = 1e-6
+
+// ErrInvalidSmoothing is returned for unknown parameters or invalid smoothing times.
+var ErrInvalidSmoothing = errors.New("invalid smoothing time")
+
+// SetSmoothingTimes sets the smoothing time constant in milliseconds of each
+// given parameter; parameters not in the map keep their current time. 0
+// applies changes instantly. Changes made before the first processed sample,
+// or right after Reset, always apply instantly. Nothing is changed if any
+// entry is invalid.
+func (c *SoftKneeCompressor) SetSmoothingTimes(times map[SmoothedParameter]float64) error {
+	for param, timeMs := range times {
+		if param != SmoothThreshold && param != SmoothMakeupGain {
+			return fmt.Errorf("%w: unknown parameter %d", ErrInvalidSmoothing, param)
+		}
+
+		if timeMs < 0.0 || !isFinite(timeMs) {
+			return fmt.Errorf("%w: %f ms", ErrInvalidSmoothing, timeMs)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if timeMs, ok := times[SmoothThreshold]; ok {
+		c.thresholdSmoothingMs = timeMs
+	}
+
+	if timeMs, ok := times[SmoothMakeupGain]; ok {
+		c.makeupSmoothingMs = timeMs
+	}
+
+	c.updateTimeConstants()
+
+	return nil
+}
+
+// GetSmoothingTimes returns the smoothing time of every parameter in milliseconds.
+func (c *SoftKneeCompressor) GetSmoothingTimes() map[SmoothedParameter]float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return map[SmoothedParameter]float64{
+		SmoothThreshold:  c.thresholdSmoothingMs,
+		SmoothMakeupGain: c.makeupSmoothingMs,
+	}
+}
+
+// SetParameterSmoothing sets the same smoothing time in milliseconds for every
+// smoothed parameter. See SetSmoothingTimes.
+func (c *SoftKneeCompressor) SetParameterSmoothing(timeMs float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(timeMs) {
+		return
+	}
+
+	timeMs = math.Max(timeMs, 0.0)
+	c.thresholdSmoothingMs = timeMs
+	c.makeupSmoothingMs = timeMs
+	c.updateTimeConstants()
+}
+
+// smoothingCoeff returns the one-pole coefficient for a smoothing time; times
+// of zero or less disable smoothing.
+func smoothingCoeff(timeMs, sampleRate float64) float64 {
+	if timeMs <= 0.0 {
+		return 1.0
+	}
+
+	return 1.0 - math.Exp(-1.0/(timeMs*0.001*sampleRate))
+}
+
+// hasProcessed reports whether any channel processed audio since the last
+// reset (internal, assumes lock held).
+func (c *SoftKneeCompressor) hasProcessed() bool {
+	for _, count := range c.samplesProcessed {
+		if count > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// advanceThresholdSmoothing sets the active threshold to the ramp's value at
+// the channel's current sample. The ramp is a function of the sample position
+// rather than of the number of calls, so every channel sees the same threshold
+// at the same sample whether channels are processed frame by frame or block
+// by block (internal, assumes lock held).
+func (c *SoftKneeCompressor) advanceThresholdSmoothing(channel int) {
+	position := c.samplesProcessed[channel]
+
+	// A new threshold restarts the ramp from the current value
+	if c.thresholdDB != c.thresholdRampToDB {
+		c.thresholdRampFromDB = c.activeThresholdDB
+		c.thresholdRampStart = position
+		c.thresholdRampToDB = c.thresholdDB
+	}
+
+	if c.thresholdRampFromDB == c.thresholdRampToDB {
+		return
+	}
+
+	// Samples the ramp has advanced by the end of this one; a channel lagging
+	// behind the restart keeps the start value
+	active := c.thresholdRampToDB
+	if position > 0 {
+		steps := 0.0
+		if position >= c.thresholdRampStart {
+			steps = float64(position-c.thresholdRampStart) + 1.0
+		}
+
+		active += (c.thresholdRampFromDB - c.thresholdRampToDB) * math.Pow(1.0-c.thresholdSmoothingCoeff, steps)
+	}
+
+	if math.Abs(active-c.thresholdRampToDB) < thresholdSnapDB {
+		active = c.thresholdRampToDB
+		c.thresholdRampFromDB = active
+	}
+
+	if active != c.activeThresholdDB {
+		c.activeThresholdDB = active
+		c.updateThresholdCache()
+	}
+}
+
+// snapThreshold ends any threshold ramp at the current threshold (internal,
+// assumes lock held).
+func (c *SoftKneeCompressor) snapThreshold() {
+	c.activeThresholdDB = c.thresholdDB
+	c.thresholdRampFromDB = c.thresholdDB
+	c.thresholdRampToDB = c.thresholdDB
+	c.thresholdRampStart = 0
+}
+
+// updateThresholdCache recalculates the values derived from the active
+// threshold, the auto threshold offset and the knee (internal, assumes lock
+// held). The offset bypasses the ramp since it already moves slowly.
+func (c *SoftKneeCompressor) updateThresholdCache() {
+	thresholdDB := c.activeThresholdDB + c.autoThresholdOffsetDB
+	c.threshold = DBToLinear(thresholdDB)
+	c.thresholdRecip = 1.0 / c.threshold
+
+	kneeHalfDB := c.effectiveKneeDB() / 2.0
+	c.kneeLower = DBToLinear(thresholdDB - kneeHalfDB)
+	c.kneeUpper = DBToLinear(thresholdDB + kneeHalfDB)
+	c.kneeWidth = c.kneeUpper - c.kneeLower
+
+	c.updateFloat32Params()
+}
@@ -0,0 +1,218 @@
+package dsp
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestAutoMakeupToggleIsSmooth toggles auto-makeup mid-signal and verifies the
+// level ramps to the new makeup gain instead of stepping.
+func TestAutoMakeupToggleIsSmooth(t *testing.T) {
+	t.Parallel()
+
+	const level = 0.01 // Far below threshold, so only makeup changes the output
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(4.0)
+	comp.SetMakeupGain(0.0)
+
+	for range 4800 {
+		comp.ProcessSample(level, 0)
+	}
+
+	comp.SetAutoMakeup(true)
+
+	target := level * DBToLinear(comp.GetMakeupGain())
+	previous := float64(comp.ProcessSample(level, 0))
+	maxStep := math.Abs(previous - level)
+
+	for range 9600 {
+		out := float64(comp.ProcessSample(level, 0))
+		maxStep = math.Max(maxStep, math.Abs(out-previous))
+		previous = out
+	}
+
+	// A 20 ms ramp moves less than 1% of the total change per sample
+	if totalChange := target - level; maxStep > 0.01*totalChange {
+		t.Errorf("Output should ramp: largest per-sample step %f of total change %f", maxStep, totalChange)
+	}
+
+	if math.Abs(previous-target) > 1e-3*target {
+		t.Errorf("Output should settle at the new makeup level %f, got %f", target, previous)
+	}
+}
+
+// TestParameterSmoothingDisabled verifies a smoothing time of 0 applies makeup changes instantly.
+func TestParameterSmoothingDisabled(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetParameterSmoothing(0.0)
+	comp.SetMakeupGain(0.0)
+
+	for range 480 {
+		comp.ProcessSample(0.01, 0)
+	}
+
+	comp.SetMakeupGain(6.0)
+
+	if out := comp.ProcessSample(0.01, 0); math.Abs(float64(out)-0.01*DBToLinear(6.0)) > 1e-6 {
+		t.Errorf("Makeup should apply instantly without smoothing, got %f", out)
+	}
+}
+
+// TestPerParameterSmoothingTimes verifies a threshold change with a short
+// smoothing time settles faster than a makeup change with a long one.
+func TestPerParameterSmoothingTimes(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetMakeupGain(0.0)
+
+	err := comp.SetSmoothingTimes(map[SmoothedParameter]float64{
+		SmoothThreshold:  2.0,
+		SmoothMakeupGain: 50.0,
+	})
+	if err != nil {
+		t.Fatalf("SetSmoothingTimes failed: %v", err)
+	}
+
+	for range 480 {
+		comp.ProcessSample(0.1, 0)
+	}
+
+	comp.SetThreshold(-30.0)
+	comp.SetMakeupGain(6.0)
+
+	targetMakeup := DBToLinear(6.0)
+	thresholdSettled, makeupSettled := -1, -1
+
+	for i := range 48000 {
+		comp.ProcessSample(0.1, 0)
+
+		if thresholdSettled < 0 && math.Abs(comp.activeThresholdDB+30.0) < 0.01 {
+			thresholdSettled = i
+		}
+
+		if makeupSettled < 0 && math.Abs(comp.smoothedMakeup[0]-targetMakeup) < 0.001*targetMakeup {
+			makeupSettled = i
+		}
+	}
+
+	if thresholdSettled < 0 || makeupSettled < 0 {
+		t.Fatalf("Both parameters should settle within 1 s: threshold %d, makeup %d", thresholdSettled, makeupSettled)
+	}
+
+	if thresholdSettled == 0 {
+		t.Error("Threshold should ramp rather than jump")
+	}
+
+	if thresholdSettled >= makeupSettled {
+		t.Errorf("Threshold (2 ms) should settle before makeup (50 ms): %d vs %d samples",
+			thresholdSettled, makeupSettled)
+	}
+
+	times := comp.GetSmoothingTimes()
+	if times[SmoothThreshold] != 2.0 || times[SmoothMakeupGain] != 50.0 {
+		t.Errorf("Unexpected smoothing times: %v", times)
+	}
+}
+
+// TestSetSmoothingTimesValidation verifies invalid entries are rejected as a whole.
+func TestSetSmoothingTimesValidation(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+
+	for _, times := range []map[SmoothedParameter]float64{
+		{SmoothedParameter(99): 10.0},
+		{SmoothThreshold: 10.0, SmoothMakeupGain: -1.0},
+		{SmoothThreshold: math.NaN()},
+	} {
+		if err := comp.SetSmoothingTimes(times); !errors.Is(err, ErrInvalidSmoothing) {
+			t.Errorf("SetSmoothingTimes(%v) should fail with ErrInvalidSmoothing, got %v", times, err)
+		}
+	}
+
+	if got := comp.GetSmoothingTimes()[SmoothThreshold]; got != defaultThresholdSmoothingMs {
+		t.Errorf("Rejected times should not be applied, threshold smoothing is %f", got)
+	}
+}
+
+// TestThresholdRampSharedAcrossChannels verifies a threshold ramp gives every
+// channel the same threshold at the same sample when channels are processed
+// block by block, and that it lasts as long as on a mono compressor.
+func TestThresholdRampSharedAcrossChannels(t *testing.T) {
+	t.Parallel()
+
+	const blockSize = 512
+
+	in := make([]float32, blockSize)
+	for i := range in {
+		in[i] = float32(0.5 * math.Sin(2.0*math.Pi*440.0*float64(i)/48000.0))
+	}
+
+	stereo := NewSoftKneeCompressor(48000.0, 2)
+	mono := NewSoftKneeCompressor(48000.0, 1)
+
+	for _, comp := range []*SoftKneeCompressor{stereo, mono} {
+		comp.SetMakeupGain(0.0)
+		comp.SetStartupFade(0.0)
+
+		for ch := range comp.Channels() {
+			comp.ProcessBlock(in, make([]float32, blockSize), ch)
+		}
+
+		comp.SetThreshold(-40.0)
+	}
+
+	left := make([]float32, blockSize)
+	right := make([]float32, blockSize)
+	single := make([]float32, blockSize)
+
+	stereo.ProcessBlock(in, left, 0)
+	stereo.ProcessBlock(in, right, 1)
+	mono.ProcessBlock(in, single, 0)
+
+	for i := range left {
+		if left[i] != right[i] {
+			t.Fatalf("Sample %d: left %f, right %f; channels should share the ramp", i, left[i], right[i])
+		}
+
+		if left[i] != single[i] {
+			t.Fatalf("Sample %d: stereo %f, mono %f; the ramp should not depend on the channel count",
+				i, left[i], single[i])
+		}
+	}
+}
+
+// TestAutoThresholdBypassesRamp verifies the auto threshold offset moves the
+// threshold without restarting the smoothing ramp every block.
+func TestAutoThresholdBypassesRamp(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetAutoThreshold(3.0)
+
+	block := make([]float32, 2*480)
+	for i := range 480 {
+		block[2*i] = float32(0.9 * math.Sin(2.0*math.Pi*1000.0*float64(i)/48000.0))
+		block[2*i+1] = block[2*i]
+	}
+
+	for range 100 {
+		comp.ProcessFrames(block, make([]float32, len(block)))
+
+		if comp.thresholdRampFromDB != comp.thresholdRampToDB || comp.activeThresholdDB != -20.0 {
+			t.Fatalf("Auto threshold restarted the ramp: active %.4f dB, ramp %.4f -> %.4f dB",
+				comp.activeThresholdDB, comp.thresholdRampFromDB, comp.thresholdRampToDB)
+		}
+	}
+
+	if comp.GetAutoThresholdOffset() == 0.0 {
+		t.Error("Expected the auto threshold offset to move on a hot signal")
+	}
+}
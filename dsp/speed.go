@@ -0,0 +1,34 @@
+package dsp
+
+import "math"
+
+// Settable range of the speed macro.
+const (
+	minSpeed = 0.1
+	maxSpeed = 10.0
+)
+
+// SetSpeed scales the attack and release times together by factor, to dial
+// the overall responsiveness with one control: 0.5 makes both twice as fast,
+// 2.0 twice as slow. The set attack and release times are kept as the base, so
+// GetAttack and GetRelease don't change and a speed of 1 restores them. The
+// factor is clamped to 0.1..10.
+func (c *SoftKneeCompressor) SetSpeed(factor float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(factor) {
+		return
+	}
+
+	c.speed = math.Max(minSpeed, math.Min(maxSpeed, factor))
+	c.updateEnvelopeConstants()
+}
+
+// GetSpeed returns the attack/release speed factor.
+func (c *SoftKneeCompressor) GetSpeed() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.speed
+}
@@ -0,0 +1,48 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSpeedScalesAttackAndRelease verifies speed 0.5 gives the coefficients of
+// half the attack and release times, keeps the base times and is reversible.
+func TestSpeedScalesAttackAndRelease(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	comp.SetAttack(10.0)
+	comp.SetRelease(200.0)
+
+	base := comp.GetCoefficients()
+
+	halved := NewSoftKneeCompressor(48000.0, 1)
+	halved.SetAttack(5.0)
+	halved.SetRelease(100.0)
+
+	want := halved.GetCoefficients()
+
+	comp.SetSpeed(0.5)
+
+	got := comp.GetCoefficients()
+	if math.Abs(got.AttackFactor-want.AttackFactor) > 1e-12 || math.Abs(got.ReleaseFactor-want.ReleaseFactor) > 1e-12 {
+		t.Errorf("Speed 0.5: attack/release factors %f/%f, want %f/%f",
+			got.AttackFactor, got.ReleaseFactor, want.AttackFactor, want.ReleaseFactor)
+	}
+
+	if comp.GetAttack() != 10.0 || comp.GetRelease() != 200.0 {
+		t.Errorf("Base times changed to %.1f/%.1f ms", comp.GetAttack(), comp.GetRelease())
+	}
+
+	comp.SetSpeed(1.0)
+
+	if got := comp.GetCoefficients(); got.AttackFactor != base.AttackFactor || got.ReleaseFactor != base.ReleaseFactor {
+		t.Errorf("Speed 1 should restore the base coefficients, got %+v", got)
+	}
+
+	comp.SetSpeed(100.0)
+
+	if got := comp.GetSpeed(); got != maxSpeed {
+		t.Errorf("Speed %.1f, want clamped to %.1f", got, maxSpeed)
+	}
+}
@@ -0,0 +1,168 @@
+package dsp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidTransferCurve is returned when a transfer curve can't be parsed or
+// has fewer than two distinct points.
+var ErrInvalidTransferCurve = errors.New("invalid transfer curve")
+
+// transferPoint maps an input level to an output level, both in dB.
+type transferPoint struct {
+	inDB  float64
+	outDB float64
+}
+
+// transferCurve is a piecewise linear input dB to output dB table, sorted by input.
+type transferCurve []transferPoint
+
+// LoadTransferCurve reads a static transfer curve, e.g. measured from a
+// hardware unit, and uses it instead of the threshold/ratio/knee curve. Each
+// line holds an input and an output level in dB, separated by whitespace or a
+// comma; blank lines and lines starting with '#' are skipped. Levels between
+// points are interpolated linearly in dB. Below the first point its gain is
+// kept, above the last point the slope of the last segment continues. The
+// curve needs at least two points with distinct input levels; on error the
+// current curve is kept. Range and makeup gain still apply on top, while auto
+// makeup keeps following the threshold and ratio settings.
+func (c *SoftKneeCompressor) LoadTransferCurve(r io.Reader) error {
+	curve, err := parseTransferCurve(r)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.transferCurve = curve
+
+	return nil
+}
+
+// ClearTransferCurve restores the parametric threshold/ratio/knee curve.
+func (c *SoftKneeCompressor) ClearTransferCurve() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.transferCurve = nil
+}
+
+// HasTransferCurve reports whether a loaded transfer curve replaces the
+// parametric curve.
+func (c *SoftKneeCompressor) HasTransferCurve() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.transferCurve != nil
+}
+
+// parseTransferCurve parses and validates the points of a transfer curve.
+func parseTransferCurve(r io.Reader) (transferCurve, error) {
+	var curve transferCurve
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%w: line %d: expected input and output level, got %q", ErrInvalidTransferCurve, line, text)
+		}
+
+		var point [2]float64
+
+		for i, field := range fields {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil || !isFinite(value) {
+				return nil, fmt.Errorf("%w: line %d: invalid level %q", ErrInvalidTransferCurve, line, field)
+			}
+
+			point[i] = value
+		}
+
+		curve = append(curve, transferPoint{inDB: point[0], outDB: point[1]})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading transfer curve: %w", err)
+	}
+
+	slices.SortFunc(curve, func(a, b transferPoint) int {
+		switch {
+		case a.inDB < b.inDB:
+			return -1
+		case a.inDB > b.inDB:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	for i := 1; i < len(curve); i++ {
+		if curve[i].inDB == curve[i-1].inDB {
+			return nil, fmt.Errorf("%w: input level %.2f dB given twice", ErrInvalidTransferCurve, curve[i].inDB)
+		}
+	}
+
+	if len(curve) < 2 {
+		return nil, fmt.Errorf("%w: need at least two points, got %d", ErrInvalidTransferCurve, len(curve))
+	}
+
+	return curve, nil
+}
+
+// outputDB returns the output level in dB for an input level in dB.
+func (tc transferCurve) outputDB(inDB float64) float64 {
+	first := tc[0]
+
+	if inDB <= first.inDB {
+		return inDB + first.outDB - first.inDB
+	}
+
+	// Index of the first point above the input, clamped so the last segment
+	// is extrapolated
+	i, _ := slices.BinarySearchFunc(tc, inDB, func(p transferPoint, level float64) int {
+		switch {
+		case p.inDB < level:
+			return -1
+		case p.inDB > level:
+			return 1
+		default:
+			return 0
+		}
+	})
+	i = max(1, min(i, len(tc)-1))
+
+	lo, hi := tc[i-1], tc[i]
+	t := (inDB - lo.inDB) / (hi.inDB - lo.inDB)
+
+	return lo.outDB + t*(hi.outDB-lo.outDB)
+}
+
+// gain returns the linear gain the curve applies at a detected peak level.
+func (tc transferCurve) gain(peakLevel float64) float64 {
+	if peakLevel <= 0.0 {
+		return 1.0
+	}
+
+	inDB := 20.0 * math.Log10(peakLevel)
+
+	return math.Pow(10.0, (tc.outputDB(inDB)-inDB)/20.0)
+}
@@ -0,0 +1,91 @@
+package dsp
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// 6 dB of boost at -60 dB easing to unity at -20 dB (upward compression), and
+// 2:1 above -20 dB; the points are out of order to exercise the sorting.
+const testTransferCurve = `# input dB, output dB
+0, -10
+-60, -54
+
+-20 -20
+`
+
+// TestTransferCurveGain verifies the gain interpolates between the points and
+// is extrapolated outside the defined range.
+func TestTransferCurveGain(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	if err := comp.LoadTransferCurve(strings.NewReader(testTransferCurve)); err != nil {
+		t.Fatalf("LoadTransferCurve failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		inDB   float64
+		gainDB float64
+	}{
+		{"first point", -60.0, 6.0},
+		{"between first points", -40.0, 3.0},
+		{"knee point", -20.0, 0.0},
+		{"between last points", -10.0, -5.0},
+		{"last point", 0.0, -10.0},
+		{"below the curve keeps the first gain", -80.0, 6.0},
+		{"above the curve continues the last slope", 6.0, -13.0},
+	}
+
+	for _, tt := range tests {
+		got := 20.0 * math.Log10(comp.computeGain(DBToLinear(tt.inDB)))
+		if math.Abs(got-tt.gainDB) > 1e-6 {
+			t.Errorf("%s: gain at %.1f dB is %.4f dB, want %.1f dB", tt.name, tt.inDB, got, tt.gainDB)
+		}
+	}
+
+	if got := comp.computeGain(0.0); got != 1.0 {
+		t.Errorf("Gain for silence %f, want 1", got)
+	}
+
+	comp.ClearTransferCurve()
+
+	if comp.HasTransferCurve() {
+		t.Error("Curve should be cleared")
+	}
+
+	if got := comp.computeGain(DBToLinear(-10.0)); got != comp.calculateGain(DBToLinear(-10.0)) {
+		t.Errorf("Gain after clearing %f, want the parametric curve", got)
+	}
+}
+
+// TestLoadTransferCurveInvalid verifies malformed curves are rejected and
+// keep the previously loaded curve.
+func TestLoadTransferCurveInvalid(t *testing.T) {
+	t.Parallel()
+
+	comp := NewSoftKneeCompressor(48000.0, 1)
+	if err := comp.LoadTransferCurve(strings.NewReader(testTransferCurve)); err != nil {
+		t.Fatalf("LoadTransferCurve failed: %v", err)
+	}
+
+	for _, input := range []string{
+		"",
+		"-20 -20\n",
+		"-20 -20\n-20 -10\n",
+		"-20 -20\n0\n",
+		"-20 -20\n0 loud\n",
+		"-20 -20\n0 NaN\n",
+	} {
+		if err := comp.LoadTransferCurve(strings.NewReader(input)); !errors.Is(err, ErrInvalidTransferCurve) {
+			t.Errorf("Curve %q: expected ErrInvalidTransferCurve, got %v", input, err)
+		}
+	}
+
+	if got := 20.0 * math.Log10(comp.computeGain(1.0)); math.Abs(got+10.0) > 1e-6 {
+		t.Errorf("Gain at 0 dB is %.4f dB after rejected loads, want the previous curve's -10 dB", got)
+	}
+}
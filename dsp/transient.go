@@ -0,0 +1,92 @@
+package dsp
+
+import "math"
+
+// Transient detector time constants in milliseconds. The fast envelope jumps to
+// every peak, the slow one catches up over the attack time and releases more
+// slowly, so the fast envelope only exceeds it right after an onset.
+const (
+	transientFastReleaseMs = 50.0
+	transientSlowAttackMs  = 20.0
+	transientSlowReleaseMs = 100.0
+)
+
+// SetTransientPreserve keeps the punch of transients by briefly relaxing the
+// gain reduction at detected onsets before clamping down. A fast and a slow
+// envelope of the detector signal track the key; while the fast one runs ahead
+// of the slow one the gain is moved towards unity by up to amount, so the
+// attack of a drum hit passes through while its sustain is compressed. The
+// amount is clamped to 0..1; 0 (the default) disables it.
+func (c *SoftKneeCompressor) SetTransientPreserve(amount float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !isFinite(amount) {
+		return
+	}
+
+	c.transientPreserve = math.Max(0.0, math.Min(1.0, amount))
+
+	if c.transientPreserve == 0.0 {
+		clear(c.transientFast)
+		clear(c.transientSlow)
+	}
+}
+
+// GetTransientPreserve returns the transient preservation amount.
+func (c *SoftKneeCompressor) GetTransientPreserve() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.transientPreserve
+}
+
+// updateTransientConstants recalculates the transient detector coefficients
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) updateTransientConstants() {
+	c.transientFastRelease = math.Exp(-1.0 / (transientFastReleaseMs * 0.001 * c.sampleRate))
+	c.transientSlowAttack = math.Exp(-1.0 / (transientSlowAttackMs * 0.001 * c.sampleRate))
+	c.transientSlowRelease = math.Exp(-1.0 / (transientSlowReleaseMs * 0.001 * c.sampleRate))
+}
+
+// detectTransient updates the fast and slow envelopes with one detector level
+// (internal, assumes lock held).
+func (c *SoftKneeCompressor) detectTransient(inputLevel float64, channel int) {
+	if c.transientPreserve == 0.0 {
+		return
+	}
+
+	fast := c.transientFast[channel]
+	if inputLevel > fast {
+		fast = inputLevel
+	} else {
+		fast = inputLevel + (fast-inputLevel)*c.transientFastRelease
+	}
+
+	// The slow envelope follows the fast one rather than the raw level, so the
+	// waveform's ripple doesn't read as a transient
+	slowCoeff := c.transientSlowRelease
+	if fast > c.transientSlow[channel] {
+		slowCoeff = c.transientSlowAttack
+	}
+
+	c.transientFast[channel] = fast
+	c.transientSlow[channel] = fast + (c.transientSlow[channel]-fast)*slowCoeff
+}
+
+// preserveTransient moves a gain towards unity by how far the fast envelope
+// runs ahead of the slow one (internal, assumes lock held).
+func (c *SoftKneeCompressor) preserveTransient(gain float64, channel int) float64 {
+	if c.transientPreserve == 0.0 {
+		return gain
+	}
+
+	fast := c.transientFast[channel]
+	if fast <= 0.0 {
+		return gain
+	}
+
+	transient := math.Max(0.0, 1.0-c.transientSlow[channel]/fast)
+
+	return gain + (1.0-gain)*transient*c.transientPreserve
+}
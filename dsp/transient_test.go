@@ -0,0 +1,59 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestTransientPreserve verifies the onset of a drum-like hit passes with less
+// gain reduction than its sustain, and less than without transient preservation.
+func TestTransientPreserve(t *testing.T) {
+	t.Parallel()
+
+	const sampleRate = 48000.0
+
+	// Deepest gain of the onset (first 5 ms) and of the sustain (150-200 ms)
+	// of a tone that starts abruptly after silence
+	hit := func(amount float64) (float64, float64) {
+		comp := NewSoftKneeCompressor(sampleRate, 1)
+		comp.SetThreshold(-30.0)
+		comp.SetRatio(8.0)
+		comp.SetAttack(0.1)
+		comp.SetRelease(100.0)
+		comp.SetTransientPreserve(amount)
+
+		onset, sustain := 1.0, 1.0
+
+		for i := range int(0.2 * sampleRate) {
+			sample := float32(0.9 * math.Sin(2.0*math.Pi*200.0*float64(i)/sampleRate))
+			_, gain := comp.processSampleInternal(sample, 0)
+
+			switch {
+			case i < int(0.005*sampleRate):
+				onset = math.Min(onset, gain)
+			case i >= int(0.15*sampleRate):
+				sustain = math.Min(sustain, gain)
+			}
+		}
+
+		return onset, sustain
+	}
+
+	onset, sustain := hit(1.0)
+	onsetGR, sustainGR := -LinearToDB(onset), -LinearToDB(sustain)
+
+	if onsetGR > 0.5*sustainGR {
+		t.Errorf("Onset reduced by %.2f dB, want well below the sustain's %.2f dB", onsetGR, sustainGR)
+	}
+
+	plainOnset, plainSustain := hit(0.0)
+
+	if onset <= plainOnset {
+		t.Errorf("Onset gain %.4f should exceed the gain without preservation %.4f", onset, plainOnset)
+	}
+
+	if math.Abs(LinearToDB(sustain)-LinearToDB(plainSustain)) > 0.5 {
+		t.Errorf("Sustain gain %.2f dB should match the gain without preservation %.2f dB",
+			LinearToDB(sustain), LinearToDB(plainSustain))
+	}
+}
@@ -0,0 +1,80 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+// TestTruePeakPerChannel verifies that in a 6-channel stream only the channel
+// carrying inter-sample overs reports a true peak above its sample peak.
+func TestTruePeakPerChannel(t *testing.T) {
+	t.Parallel()
+
+	const (
+		sampleRate = 48000.0
+		channels   = 6
+		overs      = 4
+		amplitude  = 0.5
+	)
+
+	comp := NewSoftKneeCompressor(sampleRate, channels)
+	comp.SetMakeupGain(0.0)
+	comp.SetStartupFade(0.0)
+	comp.SetThreshold(0.0)
+	comp.SetTruePeakMetering(true)
+
+	frames := 4800
+	in := make([]float32, frames*channels)
+	out := make([]float32, len(in))
+	samplePeaks := make([]float64, channels)
+
+	for block := range 4 {
+		for frame := range frames {
+			n := float64(block*frames + frame)
+
+			for ch := range channels {
+				// An fs/4 sine at 45° is only ever sampled at ±0.707 of its
+				// peak; the other channels carry a 1 kHz sine of the same
+				// sample peak whose true peak lies on the samples.
+				sample := amplitude * math.Sin(2*math.Pi*1000*n/sampleRate) / math.Sqrt2
+				if ch == overs {
+					sample = amplitude * math.Sin(math.Pi/2*n+math.Pi/4)
+				}
+
+				in[frame*channels+ch] = float32(sample)
+			}
+		}
+
+		comp.ProcessFrames(in, out)
+
+		for i, sample := range out {
+			samplePeaks[i%channels] = math.Max(samplePeaks[i%channels], math.Abs(float64(sample)))
+		}
+	}
+
+	for ch := range channels {
+		overDB := 20 * math.Log10(comp.TruePeak(ch)/samplePeaks[ch])
+
+		if ch == overs {
+			if overDB < 2.5 {
+				t.Errorf("channel %d true peak is %.2f dB over its sample peak, want about 3 dB", ch, overDB)
+			}
+
+			continue
+		}
+
+		if overDB > 0.1 {
+			t.Errorf("channel %d true peak is %.2f dB over its sample peak, want none", ch, overDB)
+		}
+	}
+
+	comp.ResetTruePeaks()
+
+	if peak := comp.TruePeak(overs); peak != 0 {
+		t.Errorf("true peak after reset = %f, want 0", peak)
+	}
+
+	if peak := comp.TruePeak(channels); peak != 0 {
+		t.Errorf("true peak of invalid channel = %f, want 0", peak)
+	}
+}
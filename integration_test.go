@@ -79,6 +79,28 @@ func TestIntegration_ValidBufferSizes(t *testing.T) {
 	}
 }
 
+//nolint:paralleltest // integration tests use shared global compressor state
+func TestIntegration_SubFrameBuffers(t *testing.T) {
+	setupTestCompressor()
+
+	for _, size := range []int{0, 1, 3} {
+		buffer := GenerateDC(0.5, size)
+
+		// Should not panic
+		processAudioBuffer(buffer)
+
+		for i, sample := range buffer {
+			if sample != 0.5 {
+				t.Errorf("Buffer of %d samples should be left untouched: sample %d is %.6f", size, i, sample)
+			}
+		}
+	}
+
+	if blocks := compressor.GetMeters().Blocks; blocks != 0 {
+		t.Errorf("Sub-frame buffers should not be processed, got %d blocks", blocks)
+	}
+}
+
 // B. Compression Behavior Tests
 
 //nolint:paralleltest // integration tests use shared global compressor state
@@ -163,16 +185,14 @@ func TestIntegration_CompressionRatio_Verification(t *testing.T) {
 	// Threshold: -20 dBFS, Input: -8 dBFS, Excess: 12 dB
 	amplitude := DBFSToLinear(-8.0)
 
-	// Process multiple buffers to let attack envelope reach steady state
-	// Each buffer is a fresh copy of the signal
-	var buffer []float32
-	for range 20 {
-		buffer = GenerateInterleavedStereoSine(SineWaveConfig{
-			Frequency:  testFreq1kHz,
-			Amplitude:  amplitude,
-			SampleRate: testSampleRate,
-		}, testBufferLarge, 0.0)
-		processAudioBuffer(buffer)
+	// Process fresh copies of the signal until the attack envelope reaches steady state
+	buffer, iterations := ProcessUntilSteady(GenerateInterleavedStereoSine(SineWaveConfig{
+		Frequency:  testFreq1kHz,
+		Amplitude:  amplitude,
+		SampleRate: testSampleRate,
+	}, testBufferLarge, 0.0), 0, 50)
+	if iterations == 50 {
+		t.Fatal("Compressor did not reach steady state")
 	}
 
 	// Measure output from the last buffer
@@ -202,15 +222,14 @@ func TestIntegration_MakeupGain(t *testing.T) {
 	// Generate signal above threshold
 	amplitude := DBFSToLinear(-10.0)
 
-	// Process multiple buffers to let attack reach steady state
-	var buffer []float32
-	for range 20 {
-		buffer = GenerateInterleavedStereoSine(SineWaveConfig{
-			Frequency:  testFreq1kHz,
-			Amplitude:  amplitude,
-			SampleRate: testSampleRate,
-		}, testBufferLarge, 0.0)
-		processAudioBuffer(buffer)
+	// Process fresh copies of the signal until the attack reaches steady state
+	buffer, iterations := ProcessUntilSteady(GenerateInterleavedStereoSine(SineWaveConfig{
+		Frequency:  testFreq1kHz,
+		Amplitude:  amplitude,
+		SampleRate: testSampleRate,
+	}, testBufferLarge, 0.0), 0, 50)
+	if iterations == 50 {
+		t.Fatal("Compressor did not reach steady state")
 	}
 
 	// Measure output
@@ -534,3 +553,126 @@ func TestIntegration_RealisticBufferSizes(t *testing.T) {
 		}
 	}
 }
+
+//nolint:paralleltest // integration tests use shared global compressor state
+func TestIntegration_StreamRestartResetsState(t *testing.T) {
+	setupTestCompressor()
+	t.Cleanup(func() { sampleRate = testSampleRate })
+
+	probe := GenerateInterleavedStereoSine(SineWaveConfig{
+		Frequency:  testFreq1kHz,
+		Amplitude:  DBFSToLinear(-6.0),
+		SampleRate: testSampleRate,
+	}, testBufferMedium, 0.0)
+
+	// Reference: the probe through a freshly reset compressor.
+	reference := append([]float32(nil), probe...)
+	processAudioBuffer(reference)
+
+	// Charge the envelope with a loud signal before the restart.
+	setupTestCompressor()
+
+	loud := GenerateDC(0.9, testBufferLarge*2)
+	processAudioBuffer(loud)
+
+	handleStreamRestart(testSampleRate)
+
+	restarted := append([]float32(nil), probe...)
+	processAudioBuffer(restarted)
+
+	for i := range reference {
+		if math.Abs(float64(reference[i]-restarted[i])) > 1e-6 {
+			t.Fatalf("Sample %d differs after restart: got %.6f, want %.6f", i, restarted[i], reference[i])
+		}
+	}
+
+	// A restart with a new negotiated rate re-applies it.
+	handleStreamRestart(44100)
+
+	if got := compressor.GetMeters().SampleRate; got != 44100 {
+		t.Errorf("Expected sample rate 44100 after restart, got %.0f", got)
+	}
+
+	// Rate 0 keeps the current rate.
+	handleStreamRestart(0)
+
+	if got := compressor.GetMeters().SampleRate; got != 44100 {
+		t.Errorf("Expected sample rate to stay 44100, got %.0f", got)
+	}
+}
+
+//nolint:paralleltest // integration tests use shared global compressor state
+func TestIntegration_ProcessUntilSteady(t *testing.T) {
+	setupTestCompressor()
+
+	input := GenerateInterleavedStereoSine(SineWaveConfig{
+		Frequency:  testFreq1kHz,
+		Amplitude:  DBFSToLinear(-8.0),
+		SampleRate: testSampleRate,
+	}, testBufferLarge, 0.0)
+
+	output, iterations := ProcessUntilSteady(input, 0, 50)
+
+	// A 10 ms attack over ~21 ms buffers needs a few iterations, but far from 50
+	if iterations < 2 || iterations > 20 {
+		t.Errorf("Expected convergence within 2-20 iterations, got %d", iterations)
+	}
+
+	// Once steady, another pass must produce the same gain
+	again := append([]float32(nil), input...)
+	processAudioBuffer(again)
+
+	steadyDB := LinearToDBFS(CalculateRMS(channelSamples(output, 0)))
+	againDB := LinearToDBFS(CalculateRMS(channelSamples(again, 0)))
+
+	if math.Abs(steadyDB-againDB) > steadyToleranceDB {
+		t.Errorf("Output not steady: %.4f dBFS then %.4f dBFS", steadyDB, againDB)
+	}
+
+	// Without the limit being reachable it reports maxIterations
+	setupTestCompressor()
+
+	if _, iterations := ProcessUntilSteady(input, 0, 1); iterations != 1 {
+		t.Errorf("A single allowed iteration should report 1, got %d", iterations)
+	}
+}
+
+// TestIntegration_WarmthAddsEvenHarmonics verifies the warmth stage raises the
+// even-harmonic content of a clean tone while leaving the odd harmonics alone.
+func TestIntegration_WarmthAddsEvenHarmonics(t *testing.T) {
+	t.Parallel()
+
+	input := GenerateSine(SineWaveConfig{
+		Frequency:  testFreq1kHz,
+		Amplitude:  0.5,
+		SampleRate: testSampleRate,
+	}, int(testSampleRate))
+
+	render := func(warmth float64) []float32 {
+		comp := dsp.NewSoftKneeCompressor(testSampleRate, 1)
+		comp.SetThreshold(0.0) // Keep the tone below the threshold
+		comp.SetMakeupGain(0.0)
+		comp.SetWarmth(warmth)
+
+		output := make([]float32, len(input))
+		comp.ProcessBlock(input, output, 0)
+
+		// Analyze the second half, after the DC blocker settled
+		return output[len(output)/2:]
+	}
+
+	dry, warm := render(0.0), render(1.0)
+
+	dryEven := MeasureEvenTHD(dry, testFreq1kHz, testSampleRate)
+	warmEven := MeasureEvenTHD(warm, testFreq1kHz, testSampleRate)
+	warmOdd := MeasureOddTHD(warm, testFreq1kHz, testSampleRate)
+
+	// 0.25 * 0.5² / 2 = 0.03125 second harmonic on a 0.5 fundamental
+	if warmEven < 0.05 || warmEven < 100*dryEven {
+		t.Errorf("Even THD with warmth %.5f (dry %.5f), expected about 0.0625", warmEven, dryEven)
+	}
+
+	if warmOdd > 0.001 {
+		t.Errorf("Odd THD with warmth %.5f, expected the shaper to leave odd harmonics alone", warmOdd)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// resolveLogPath derives an instance-specific log path by inserting the PID
+// before the extension, e.g. pw-comp.log becomes pw-comp.1234.log.
+func resolveLogPath(path string, pid int) string {
+	ext := filepath.Ext(path)
+
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(path, ext), pid, ext)
+}
+
+// openLogFile opens the log file and takes an exclusive lock on it. If another
+// instance already holds the lock, it switches to a per-PID path instead of
+// clobbering that instance's log. Without appendLog the file is truncated once
+// the lock is held; with it a session header separates the runs. The path that
+// was actually opened is returned.
+func openLogFile(path string, appendLog bool) (*os.File, string, error) {
+	file, err := lockLogFile(path)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		path = resolveLogPath(path, os.Getpid())
+		file, err = lockLogFile(path)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !appendLog {
+		err = file.Truncate(0)
+	} else {
+		_, err = fmt.Fprintf(file, "=== pw-comp session started %s (pid %d) ===\n",
+			time.Now().Format(time.RFC3339), os.Getpid())
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, "", fmt.Errorf("failed to prepare log file %s: %w", path, err)
+	}
+
+	return file, path, nil
+}
+
+// lockLogFile opens a log file for appending without truncating it and takes a
+// non-blocking exclusive lock, returning EWOULDBLOCK if another instance holds it.
+func lockLogFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o666)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock log file %s: %w", path, err)
+	}
+
+	return file, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestResolveLogPath verifies instance paths are unique per PID and keep the
+// directory and extension.
+func TestResolveLogPath(t *testing.T) {
+	t.Parallel()
+
+	first := resolveLogPath("/var/log/pw-comp.log", 100)
+	second := resolveLogPath("/var/log/pw-comp.log", 200)
+
+	if first != "/var/log/pw-comp.100.log" {
+		t.Errorf("Unexpected instance path %q", first)
+	}
+
+	if first == second {
+		t.Errorf("Different instances should get different paths, both got %q", first)
+	}
+
+	if got := resolveLogPath("pw-comp", 7); got != "pw-comp.7" {
+		t.Errorf("Path without extension should get a PID suffix, got %q", got)
+	}
+}
+
+// TestOpenLogFileCollision verifies a second instance opening the same log
+// file gets its own path and doesn't truncate the first instance's log.
+func TestOpenLogFileCollision(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "pw-comp.log")
+
+	first, firstPath, err := openLogFile(path, false)
+	if err != nil {
+		t.Fatalf("openLogFile failed: %v", err)
+	}
+	defer first.Close()
+
+	if _, err := first.WriteString("first instance\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	second, secondPath, err := openLogFile(path, false)
+	if err != nil {
+		t.Fatalf("Second openLogFile failed: %v", err)
+	}
+	defer second.Close()
+
+	if firstPath != path || secondPath != resolveLogPath(path, os.Getpid()) {
+		t.Errorf("Expected paths %q and %q, got %q and %q",
+			path, resolveLogPath(path, os.Getpid()), firstPath, secondPath)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	if string(content) != "first instance\n" {
+		t.Errorf("First instance's log was clobbered: %q", content)
+	}
+}
+
+// TestOpenLogFileAppend verifies append mode keeps previous runs and adds a
+// session header.
+func TestOpenLogFileAppend(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "pw-comp.log")
+	if err := os.WriteFile(path, []byte("previous run\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	file, _, err := openLogFile(path, true)
+	if err != nil {
+		t.Fatalf("openLogFile failed: %v", err)
+	}
+	file.Close()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 || lines[0] != "previous run" || !strings.HasPrefix(lines[1], "=== pw-comp session started") {
+		t.Errorf("Expected the previous run followed by a session header, got %q", content)
+	}
+}
@@ -1,50 +1,37 @@
-//go:generate sh -c "gcc -shared -o libpw_wrapper.so -fPIC csrc/pw_wrapper.c -I/usr/include/pipewire-0.3 -I/usr/include/spa-0.2 -lpipewire-0.3"
-
 package main
 
-/*
-#cgo CFLAGS: -I./csrc -I/usr/include/pipewire-0.3 -I/usr/include/spa-0.2
-#cgo LDFLAGS: -L${SRCDIR} -Wl,-rpath,${SRCDIR} -lpw_wrapper -lpipewire-0.3
-
-#include <pipewire/pipewire.h>
-#include <spa/param/audio/format-utils.h>
-#include <spa/param/audio/format.h>
-#include <spa/param/format-utils.h>
-#include <spa/utils/type.h>
-#include <spa/pod/builder.h>
-#include <spa/pod/pod.h>
-#include <spa/pod/parser.h>
-#include <spa/pod/vararg.h>
-#include "pw_wrapper.h"
-*/
-import "C"
-
 import (
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
-	"sync"
-	"time"
-	"unsafe"
+	"strings"
 
 	"pw-comp/dsp"
 )
 
 // Audio configuration.
 var (
-	channels   = 2     // Stereo (modify for 5.1, etc.)
+	channels   = 2     // Stereo unless -surround-layout selects another layout
 	sampleRate = 48000 // Default sample rate, will be updated by PipeWire
 )
 
+// resetOnRestart controls whether a PipeWire node restart clears the envelopes.
+var resetOnRestart = true
+
+// dimLevel is the attenuation in dB the TUI dim key applies.
+var dimLevel = -20.0
+
 // Compressor instance.
 var compressor *dsp.SoftKneeCompressor
 
-// export log_from_c
-//
-//export log_from_c
-func log_from_c(msg *C.char) {
-	slog.Info("C-Side", "msg", C.GoString(msg))
+// audioOptions configures how runPipeWire runs the filter.
+type audioOptions struct {
+	Debug           bool   // Verbose PipeWire debug logging
+	GainReductionCV bool   // Add the gain reduction CV output port
+	NoTUI           bool   // Run headless until Ctrl+C instead of showing the TUI
+	LogPath         string // Log file shown in headless mode
+	LatencySamples  int    // Lookahead delay reported to PipeWire as process latency
 }
 
 // processAudioBuffer processes an INTERLEAVED audio buffer through the compressor (Go wrapper for tests).
@@ -53,51 +40,79 @@ func processAudioBuffer(audio []float32) {
 		return
 	}
 
-	if len(audio)%channels != 0 {
+	if len(audio) == 0 {
 		return
 	}
 
-	samplesPerChannel := len(audio) / channels
+	// A buffer that doesn't hold whole frames would shift every following
+	// sample onto the wrong channel, so it's dropped as-is.
+	if len(audio) < channels || len(audio)%channels != 0 {
+		slog.Warn("Ignoring buffer with partial frame", "samples", len(audio), "channels", channels)
+		return
+	}
 
-	for i := range samplesPerChannel {
-		for ch := range channels {
-			index := i*channels + ch
-			audio[index] = compressor.ProcessSample(audio[index], ch)
-		}
+	compressor.ProcessFrames(audio, audio)
+}
+
+// logSessionSummary logs the compressor's session statistics, one log line
+// per summary line.
+func logSessionSummary(comp *dsp.SoftKneeCompressor) {
+	for _, line := range strings.Split(comp.SessionSummary(), "\n") {
+		slog.Info("Session summary", "stats", line)
 	}
 }
 
-//export process_channel_go
-func process_channel_go(in *C.float, out *C.float, samples C.int, rate C.int, channelIndex C.int) {
+// handleStreamRestart resets the compressor state after PipeWire restarted the
+// node and re-applies the negotiated sample rate (0 keeps the current one).
+func handleStreamRestart(rate int) {
 	if compressor == nil {
 		return
 	}
 
-	// Update sample rate if changed
 	if rate > 0 {
-		compressor.SetSampleRate(float64(rate))
+		sampleRate = rate
 	}
 
-	// Convert C arrays to Go slices
-	inBuf := unsafe.Slice((*float32)(unsafe.Pointer(in)), int(samples))
-	outBuf := unsafe.Slice((*float32)(unsafe.Pointer(out)), int(samples))
+	slog.Info("Stream restarted", "sampleRate", sampleRate, "reset", resetOnRestart)
+
+	if resetOnRestart {
+		compressor.Reset()
+	}
 
-	// Process the block for this specific channel
-	compressor.ProcessBlock(inBuf, outBuf, int(channelIndex))
+	compressor.SetSampleRate(float64(sampleRate))
 }
 
 func main() {
-	// Command-line flags for compressor parameters
-	threshold := flag.Float64("threshold", -20.0, "Compression threshold in dB")
-	ratio := flag.Float64("ratio", 4.0, "Compression ratio (e.g., 4.0 for 4:1)")
-	knee := flag.Float64("knee", 6.0, "Soft knee width in dB")
-	attack := flag.Float64("attack", 10.0, "Attack time in milliseconds")
-	release := flag.Float64("release", 100.0, "Release time in milliseconds")
-	makeupGain := flag.Float64("makeup", 0.0, "Manual makeup gain in dB (0 = auto)")
-	autoMakeup := flag.Bool("auto-makeup", true, "Enable automatic makeup gain")
+	// Compressor parameters: the config file first, overridden by environment
+	// variables and then by flags
+	params := defaultParams()
+	configErr := params.loadConfigFile(configPath(os.LookupEnv))
+	envErr := params.loadEnv(os.LookupEnv)
+	params.registerFlags(flag.CommandLine)
+
 	noTUI := flag.Bool("no-tui", false, "Disable interactive TUI")
 	debug := flag.Bool("debug", false, "Enable verbose PipeWire debug logging")
 	logFile := flag.String("log", "pw-comp.log", "Log file path")
+	logAppend := flag.Bool("log-append", false, "Append to the log file with a session header instead of truncating it")
+	dimLevelFlag := flag.Float64("dim-level", -20.0, "Output attenuation in dB applied by the TUI dim key")
+	resetOnRestartFlag := flag.Bool("reset-on-restart", true, "Reset envelopes when PipeWire restarts the node")
+	nanSafetyMute := flag.Bool("nan-safety-mute", true, "Mute the output while the input delivers sustained NaN/Inf samples")
+	lookahead := flag.Float64("lookahead", 0.0, "Delay the audio by this many ms (0-20) so gain reduction is in place before transients; adds latency")
+	hardClip := flag.Bool("hard-clip", false, "Clamp the output to the hard clip ceiling after all gain stages")
+	hardClipCeiling := flag.Float64("hard-clip-ceiling", 0.0, "Hard clipper ceiling in dBFS")
+	limiterLookahead := flag.Float64("limiter-lookahead", 0.0, "Lookahead in ms (0-10) turning the hard clipper into a brick-wall limiter; adds latency")
+	grCVRange := flag.Float64("gr-cv-range", 0.0, "Add a gain reduction CV output port reaching 1.0 at this reduction in dB (0 = no port)")
+	surroundLayout := flag.String("surround-layout", "", "Process a surround layout (quad, 5.1 or 7.1) with its L/R pairs linked instead of stereo")
+	linkMode := flag.String("link-mode", "detector", "How channels share gain reduction: detector (shared key) or max-reduction (deepest gain on all)")
+	linkHighPass := flag.Float64("link-high-pass", 0.0, "High-pass corner in Hz of each linked channel's detection signal (0 = off)")
+	linkWeights := flag.String("link-weights", "", "Comma-separated per-channel weights of the linked detector, e.g. 1,0.5 (default: all 1)")
+	sidechainSource := flag.String("sidechain-source", "", "Comma-separated channel indices (from 0) whose level drives every channel's detector")
+	transferCurve := flag.String("transfer-curve", "", "File with input/output dB points replacing the threshold/ratio/knee curve")
+	controlSocket := flag.String("control-socket", "", "Stream meters and accept parameter commands as JSON lines on this Unix socket")
+	oscTarget := flag.String("osc-target", "", "Send the meters as OSC messages over UDP to this host:port")
+	oscRate := flag.Float64("osc-rate", 30.0, "OSC meter messages per second")
+	metricsPort := flag.Int("metrics-port", 0, "Serve meter statistics over HTTP on this port (0 = disabled)")
+	metricsAddr := flag.String("metrics-addr", "127.0.0.1", "Address the metrics endpoint listens on, e.g. 0.0.0.0 for all interfaces")
 	showHelp := flag.Bool("help", false, "Show this help message")
 
 	flag.Parse()
@@ -117,8 +132,23 @@ func main() {
 		os.Exit(0)
 	}
 
+	if configErr != nil {
+		//nolint:forbidigo // error output before logging is initialized
+		fmt.Printf("Invalid configuration file: %v\n", configErr)
+		os.Exit(1)
+	}
+
+	if envErr != nil {
+		//nolint:forbidigo // error output before logging is initialized
+		fmt.Printf("Invalid environment configuration: %v\n", envErr)
+		os.Exit(1)
+	}
+
+	resetOnRestart = *resetOnRestartFlag
+	dimLevel = *dimLevelFlag
+
 	// Setup logging
-	file, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o666)
+	file, logPath, err := openLogFile(*logFile, *logAppend)
 	if err != nil {
 		//nolint:forbidigo // error output before logging is initialized
 		fmt.Printf("Failed to open log file: %v\n", err)
@@ -128,94 +158,122 @@ func main() {
 
 	logger := slog.New(slog.NewTextHandler(file, nil))
 	slog.SetDefault(logger)
-	slog.Info("Starting pw-comp", "args", os.Args)
+	slog.Info("Starting pw-comp", "args", os.Args, "log", logPath)
+
+	if *surroundLayout != "" {
+		layoutChannels, err := dsp.SurroundChannels(*surroundLayout)
+		if err != nil {
+			//nolint:forbidigo // critical error output to user
+			fmt.Printf("ERROR: %v\n", err)
+			return
+		}
 
-	if *debug {
-		C.pw_debug = 1
+		channels = layoutChannels
 	}
 
 	// Initialize compressor with default settings
 	compressor = dsp.NewSoftKneeCompressor(float64(sampleRate), channels)
 	slog.Info("Compressor initialized", "defaultSampleRate", sampleRate, "channels", channels)
 
-	// Configure compressor parameters from command-line flags
-	compressor.SetThreshold(*threshold)
-	compressor.SetRatio(*ratio)
-	compressor.SetKnee(*knee)
-	compressor.SetAttack(*attack)
-	compressor.SetRelease(*release)
+	// Configure compressor parameters from the environment and command-line flags
+	params.apply(compressor)
+	compressor.SetNaNSafetyMute(*nanSafetyMute)
+	compressor.SetGainReductionCV(*grCVRange)
+	compressor.SetLookahead(*lookahead)
+	compressor.SetHardClip(*hardClip)
+	compressor.SetHardClipCeiling(*hardClipCeiling)
+	compressor.SetLimiterLookahead(*limiterLookahead)
+	compressor.SetLinkHighPass(*linkHighPass)
+
+	if *surroundLayout != "" {
+		if err := compressor.SetSurroundPairs(*surroundLayout); err != nil {
+			slog.Error("Failed to link the surround pairs", "err", err)
+		}
 
-	if *makeupGain != 0.0 {
-		compressor.SetMakeupGain(*makeupGain)
-	} else {
-		compressor.SetAutoMakeup(*autoMakeup)
+		slog.Info("Surround layout configured", "layout", *surroundLayout)
 	}
-	slog.Info("Parameters configured")
 
-	// Initialize PipeWire
-	C.pw_init(nil, nil)
-	slog.Info("PipeWire initialized")
+	if err := applyLinkMode(compressor, *linkMode); err != nil {
+		//nolint:forbidigo // critical error output to user
+		fmt.Printf("ERROR: %v\n", err)
+		return
+	}
+
+	if err := applyLinkWeights(compressor, *linkWeights); err != nil {
+		//nolint:forbidigo // critical error output to user
+		fmt.Printf("ERROR: Invalid link weights: %v\n", err)
+		return
+	}
 
-	// Create main loop
-	loop := C.pw_main_loop_new(nil)
-	if loop == nil {
-		slog.Error("Failed to create PipeWire main loop")
+	if err := applySidechainSource(compressor, *sidechainSource); err != nil {
+		slog.Error("Invalid sidechain source", "err", err)
 		//nolint:forbidigo // critical error output to user
-		fmt.Println("ERROR: Failed to create PipeWire main loop")
+		fmt.Printf("ERROR: Invalid sidechain source: %v\n", err)
 		return
 	}
 
-	// Create a new PipeWire filter with separate ports for each channel
-	filterData := C.create_pipewire_filter(loop, C.int(channels))
-	if filterData == nil {
-		slog.Error("Failed to create PipeWire filter")
+	if *transferCurve != "" {
+		if err := loadTransferCurve(compressor, *transferCurve); err != nil {
+			slog.Error("Failed to load transfer curve", "err", err)
+			//nolint:forbidigo // critical error output to user
+			fmt.Printf("ERROR: Failed to load transfer curve: %v\n", err)
+			return
+		}
+
+		slog.Info("Transfer curve loaded", "path", *transferCurve)
+	}
+
+	if compressor.AttackExceedsRelease() {
+		slog.Warn("Attack is longer than release; the envelope may not settle on sustained tones",
+			"attackMs", params.Attack, "releaseMs", params.Release)
+	}
+
+	slog.Info("Parameters configured", "params", params, "latencySamples", compressor.LatencySamples())
+
+	stopDiagnostics := startDiagnosticsMonitor(compressor, diagnosticsInterval)
+	defer stopDiagnostics()
+
+	if *metricsPort > 0 {
+		stopMetrics, err := startMetricsServer(*metricsAddr, *metricsPort, compressor)
+		if err != nil {
+			slog.Error("Failed to start metrics endpoint", "err", err)
+		} else {
+			defer stopMetrics()
+		}
+	}
+
+	if *oscTarget != "" {
+		stopOSC, err := startOSCSender(*oscTarget, *oscRate, compressor)
+		if err != nil {
+			slog.Error("Failed to start OSC meter export", "err", err)
+		} else {
+			defer stopOSC()
+		}
+	}
+
+	if *controlSocket != "" {
+		stopControl, err := startControlServer(*controlSocket, compressor)
+		if err != nil {
+			slog.Error("Failed to start control socket", "err", err)
+		} else {
+			defer stopControl()
+		}
+	}
+
+	err = runPipeWire(audioOptions{
+		Debug:           *debug,
+		GainReductionCV: compressor.GetGainReductionCV() > 0,
+		NoTUI:           *noTUI,
+		LogPath:         logPath,
+		LatencySamples:  compressor.LatencySamples(),
+	})
+	if err != nil {
+		slog.Error("Failed to run the PipeWire filter", "err", err)
 		//nolint:forbidigo // critical error output to user
-		fmt.Println("ERROR: Failed to create PipeWire filter")
-		C.pw_main_loop_destroy(loop)
+		fmt.Printf("ERROR: %v\n", err)
 		return
 	}
-	slog.Info("PipeWire filter created")
-
-	if *noTUI {
-		//nolint:forbidigo // headless mode startup message
-		fmt.Println("Starting PipeWire Audio Compressor (pw-comp)...")
-		//nolint:forbidigo // headless mode startup message
-		fmt.Println("TUI disabled. Running in headless mode.")
-		//nolint:forbidigo // headless mode startup message
-		fmt.Println("Log file:", *logFile)
-		//nolint:forbidigo // headless mode startup message
-		fmt.Println("Press Ctrl+C to exit.")
-
-		// Run in main thread
-		C.pw_main_loop_run(loop)
-	} else {
-		var waitGroup sync.WaitGroup
-		waitGroup.Add(1)
-
-		// Run PipeWire loop in background
-		go func() {
-			defer waitGroup.Done()
-			slog.Info("Starting PipeWire main loop")
-			C.pw_main_loop_run(loop)
-			slog.Info("PipeWire main loop exited")
-		}()
-
-		// Give PipeWire a moment to start (optional)
-		time.Sleep(100 * time.Millisecond)
-
-		// Run TUI in main thread
-		runTUI(compressor)
-
-		// When TUI returns, quit PipeWire loop
-		slog.Info("TUI exited, stopping PipeWire loop")
-		C.pw_main_loop_quit(loop)
-
-		// Wait for PipeWire loop to finish cleaning up its internal state
-		waitGroup.Wait()
-	}
-
-	// Cleanup
-	C.destroy_pipewire_filter(filterData)
-	C.pw_main_loop_destroy(loop)
+
+	logSessionSummary(compressor)
 	slog.Info("Shutdown complete")
 }
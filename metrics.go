@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"pw-comp/dsp"
+)
+
+const (
+	metricsReadHeaderTimeout = 5 * time.Second
+	metricsShutdownTimeout   = 2 * time.Second
+
+	// Time constant of the smoothed processing load in seconds.
+	cpuLoadTimeSec = 1.0
+)
+
+// cpuLoad holds the smoothed processing load as float64 bits: the time spent
+// processing a quantum relative to the quantum's duration.
+var cpuLoad atomic.Uint64
+
+// recordCPULoad folds the time spent processing one quantum of samples at
+// rate into the smoothed processing load. Only the audio thread calls it.
+func recordCPULoad(elapsed time.Duration, samples, rate int) {
+	if samples <= 0 || rate <= 0 {
+		return
+	}
+
+	quantumSec := float64(samples) / float64(rate)
+	load := elapsed.Seconds() / quantumSec
+	coeff := 1.0 - math.Exp(-quantumSec/cpuLoadTimeSec)
+
+	smoothed := math.Float64frombits(cpuLoad.Load())
+	cpuLoad.Store(math.Float64bits(smoothed + (load-smoothed)*coeff))
+}
+
+// currentCPULoad returns the smoothed processing load, 1.0 meaning a quantum
+// took as long to process as it lasts.
+func currentCPULoad() float64 {
+	return math.Float64frombits(cpuLoad.Load())
+}
+
+// formatPrometheusMetrics renders meter statistics and the processing load in
+// the Prometheus text exposition format.
+func formatPrometheusMetrics(stats dsp.MeterStats, load float64) string {
+	var builder strings.Builder
+
+	writeChannels := func(name, kind, help string, values map[string]float64) {
+		fmt.Fprintf(&builder, "# HELP pwcomp_%s %s\n", name, help)
+		fmt.Fprintf(&builder, "# TYPE pwcomp_%s %s\n", name, kind)
+
+		for _, channel := range []string{"left", "right"} {
+			value, ok := values[channel]
+			if !ok {
+				continue
+			}
+
+			fmt.Fprintf(&builder, "pwcomp_%s{channel=%q} %s\n", name, channel, formatMetricValue(value))
+		}
+	}
+
+	writeChannels("input_peak", "gauge", "Input peak level of the last block (linear).",
+		map[string]float64{"left": stats.InputL, "right": stats.InputR})
+	writeChannels("output_peak", "gauge", "Output peak level of the last block (linear).",
+		map[string]float64{"left": stats.OutputL, "right": stats.OutputR})
+	writeChannels("gain_reduction_db", "gauge", "Gain reduction with meter ballistics in dB.",
+		map[string]float64{"left": stats.GainReductionMeterL, "right": stats.GainReductionMeterR})
+	writeChannels("average_gain_reduction_db", "gauge", "Slow average of the gain reduction in dB.",
+		map[string]float64{"left": stats.AverageGainReductionL, "right": stats.AverageGainReductionR})
+	writeChannels("input_clip", "gauge", "Whether the last input block reached 0 dBFS (1) or not (0).",
+		map[string]float64{"left": boolToMetric(stats.InputClipL), "right": boolToMetric(stats.InputClipR)})
+	writeChannels("dc_offset", "gauge", "DC offset of the raw input.",
+		map[string]float64{"left": stats.DCOffsetL, "right": stats.DCOffsetR})
+	writeChannels("input_clips_total", "counter", "Raw input samples at or above 0 dBFS since the last clip reset.",
+		map[string]float64{"left": float64(stats.InputClipCountL), "right": float64(stats.InputClipCountR)})
+	writeChannels("output_clips_total", "counter", "Output samples at or above 0 dBFS since the last clip reset.",
+		map[string]float64{"left": float64(stats.OutputClipCountL), "right": float64(stats.OutputClipCountR)})
+
+	builder.WriteString("# HELP pwcomp_blocks_total Number of processed blocks.\n")
+	builder.WriteString("# TYPE pwcomp_blocks_total counter\n")
+	fmt.Fprintf(&builder, "pwcomp_blocks_total %d\n", stats.Blocks)
+
+	builder.WriteString("# HELP pwcomp_sample_rate_hz Current sample rate in Hz.\n")
+	builder.WriteString("# TYPE pwcomp_sample_rate_hz gauge\n")
+	fmt.Fprintf(&builder, "pwcomp_sample_rate_hz %s\n", formatMetricValue(stats.SampleRate))
+
+	builder.WriteString("# HELP pwcomp_cpu_load Smoothed processing time relative to the quantum duration (1 = realtime limit).\n")
+	builder.WriteString("# TYPE pwcomp_cpu_load gauge\n")
+	fmt.Fprintf(&builder, "pwcomp_cpu_load %s\n", formatMetricValue(load))
+
+	return builder.String()
+}
+
+func formatMetricValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func boolToMetric(value bool) float64 {
+	if value {
+		return 1.0
+	}
+
+	return 0.0
+}
+
+// newMetricsHandler serves Prometheus text on /metrics and JSON on /metrics.json.
+func newMetricsHandler(comp *dsp.SoftKneeCompressor) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte(formatPrometheusMetrics(comp.GetMeters(), currentCPULoad())))
+	})
+
+	mux.HandleFunc("/metrics.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(comp.GetMeters())
+	})
+
+	return mux
+}
+
+// startMetricsServer starts the metrics endpoint on host and port in a
+// goroutine and returns a function that shuts it down.
+func startMetricsServer(host string, port int, comp *dsp.SoftKneeCompressor) (func(), error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen on metrics address %s: %w", addr, err)
+	}
+
+	server := &http.Server{
+		Handler:           newMetricsHandler(comp),
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Metrics server failed", "err", err)
+		}
+	}()
+
+	slog.Info("Metrics endpoint started", "addr", listener.Addr().String())
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+
+		err := server.Shutdown(ctx)
+		if err != nil {
+			slog.Error("Metrics server shutdown failed", "err", err)
+		}
+	}, nil
+}
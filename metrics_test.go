@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"pw-comp/dsp"
+)
+
+// TestFormatPrometheusMetrics verifies the serialization of a known MeterStats.
+func TestFormatPrometheusMetrics(t *testing.T) {
+	t.Parallel()
+
+	stats := dsp.MeterStats{
+		InputL:                0.5,
+		InputR:                0.25,
+		OutputL:               0.4,
+		OutputR:               0.2,
+		GainReductionMeterL:   3.5,
+		GainReductionMeterR:   0,
+		AverageGainReductionL: 1.25,
+		AverageGainReductionR: 0.5,
+		InputClipL:            true,
+		InputClipCountL:       7,
+		OutputClipCountR:      2,
+		Blocks:                1234,
+		SampleRate:            48000,
+	}
+
+	text := formatPrometheusMetrics(stats, 0.25)
+
+	expected := []string{
+		"# TYPE pwcomp_input_peak gauge",
+		`pwcomp_input_peak{channel="left"} 0.5`,
+		`pwcomp_input_peak{channel="right"} 0.25`,
+		`pwcomp_output_peak{channel="left"} 0.4`,
+		`pwcomp_gain_reduction_db{channel="left"} 3.5`,
+		`pwcomp_gain_reduction_db{channel="right"} 0`,
+		`pwcomp_average_gain_reduction_db{channel="left"} 1.25`,
+		`pwcomp_input_clip{channel="left"} 1`,
+		`pwcomp_input_clip{channel="right"} 0`,
+		"# TYPE pwcomp_blocks_total counter",
+		"pwcomp_blocks_total 1234",
+		"pwcomp_sample_rate_hz 48000",
+		"# TYPE pwcomp_input_clips_total counter",
+		`pwcomp_input_clips_total{channel="left"} 7`,
+		`pwcomp_output_clips_total{channel="right"} 2`,
+		"# TYPE pwcomp_cpu_load gauge",
+		"pwcomp_cpu_load 0.25",
+	}
+
+	lines := strings.Split(text, "\n")
+
+	for _, want := range expected {
+		found := false
+
+		for _, line := range lines {
+			if line == want {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Errorf("Missing line %q in metrics output:\n%s", want, text)
+		}
+	}
+}
+
+// TestRecordCPULoad verifies the load settles at the processing time relative
+// to the quantum duration and ignores quanta without a length or rate.
+func TestRecordCPULoad(t *testing.T) {
+	t.Parallel()
+
+	// 256 samples at 48 kHz last 5.33 ms; 1.33 ms of processing is a quarter
+	quantum := 256.0 / 48000.0
+	elapsed := time.Duration(quantum / 4.0 * float64(time.Second))
+
+	for range 2000 {
+		recordCPULoad(elapsed, 256, 48000)
+	}
+
+	if load := currentCPULoad(); math.Abs(load-0.25) > 0.01 {
+		t.Errorf("CPU load %.3f, want 0.25", load)
+	}
+
+	recordCPULoad(time.Second, 0, 48000)
+	recordCPULoad(time.Second, 256, 0)
+
+	if load := currentCPULoad(); math.Abs(load-0.25) > 0.01 {
+		t.Errorf("Empty quanta changed the CPU load to %.3f", load)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// nodeInfo identifies the filter's PipeWire node, so scripts running several
+// instances can address the right one with pw-cli or wpctl.
+type nodeInfo struct {
+	ID     uint32 // Node id, valid while the node exists
+	Serial uint64 // Object serial, unique for the session; 0 if unknown
+}
+
+// String formats the node for the log and the TUI header.
+func (node nodeInfo) String() string {
+	if node.Serial == 0 {
+		return fmt.Sprintf("Node %d", node.ID)
+	}
+
+	return fmt.Sprintf("Node %d (serial %d)", node.ID, node.Serial)
+}
+
+// currentNode holds the registered node, nil until PipeWire reported it.
+var currentNode atomic.Pointer[nodeInfo]
+
+// handleNodeReady records and logs the node id and serial reported by the C
+// wrapper once the filter is registered.
+func handleNodeReady(id uint32, serial uint64) {
+	node := nodeInfo{ID: id, Serial: serial}
+	currentNode.Store(&node)
+
+	slog.Info("PipeWire node ready", "id", id, "serial", serial)
+}
+
+// nodeLabel returns the node for the TUI header, or a placeholder while
+// PipeWire hasn't registered it yet.
+func nodeLabel() string {
+	node := currentNode.Load()
+	if node == nil {
+		return "Node: pending"
+	}
+
+	return node.String()
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+// TestNodeInfoString verifies the node formatting with and without a serial.
+func TestNodeInfoString(t *testing.T) {
+	t.Parallel()
+
+	if got, want := (nodeInfo{ID: 42, Serial: 1234}).String(), "Node 42 (serial 1234)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	if got, want := (nodeInfo{ID: 42}).String(), "Node 42"; got != want {
+		t.Errorf("String() without serial = %q, want %q", got, want)
+	}
+}
+
+// TestHandleNodeReady verifies the id and serial reported by the wrapper are
+// captured and shown in the TUI header label.
+//
+//nolint:paralleltest // modifies the global node state
+func TestHandleNodeReady(t *testing.T) {
+	currentNode.Store(nil)
+	t.Cleanup(func() { currentNode.Store(nil) })
+
+	if got, want := nodeLabel(), "Node: pending"; got != want {
+		t.Errorf("Label before registration %q, want %q", got, want)
+	}
+
+	handleNodeReady(57, 3012)
+
+	node := currentNode.Load()
+	if node == nil || node.ID != 57 || node.Serial != 3012 {
+		t.Fatalf("Captured node %+v, want id 57 serial 3012", node)
+	}
+
+	if got, want := nodeLabel(), "Node 57 (serial 3012)"; got != want {
+		t.Errorf("Label %q, want %q", got, want)
+	}
+}
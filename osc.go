@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"time"
+
+	"pw-comp/dsp"
+)
+
+// oscRateLimit is the highest OSC send rate in messages per second.
+const oscRateLimit = 200.0
+
+// encodeOSCMessage encodes an OSC 1.0 message with float32 arguments: the
+// address and the type tag string are NUL-terminated and padded to four bytes,
+// the floats follow big-endian.
+func encodeOSCMessage(address string, values ...float32) []byte {
+	tags := make([]byte, 0, len(values)+1)
+	tags = append(tags, ',')
+
+	for range values {
+		tags = append(tags, 'f')
+	}
+
+	msg := appendOSCString(nil, address)
+	msg = appendOSCString(msg, string(tags))
+
+	for _, value := range values {
+		msg = binary.BigEndian.AppendUint32(msg, math.Float32bits(value))
+	}
+
+	return msg
+}
+
+// appendOSCString appends a NUL-terminated string padded to a multiple of four bytes.
+func appendOSCString(buf []byte, s string) []byte {
+	buf = append(buf, s...)
+
+	return append(buf, make([]byte, 4-len(s)%4)...)
+}
+
+// meterOSCMessages encodes the meters as one OSC message per value: the gain
+// reduction with meter ballistics in dB (positive values mean reduction) and
+// the input and output peaks with meter ballistics (linear), per channel.
+func meterOSCMessages(stats dsp.MeterStats) [][]byte {
+	values := []struct {
+		address string
+		value   float64
+	}{
+		{"/pwcomp/gr/left", stats.GainReductionMeterL},
+		{"/pwcomp/gr/right", stats.GainReductionMeterR},
+		{"/pwcomp/input/left", stats.InputMeterL},
+		{"/pwcomp/input/right", stats.InputMeterR},
+		{"/pwcomp/output/left", stats.OutputMeterL},
+		{"/pwcomp/output/right", stats.OutputMeterR},
+	}
+
+	msgs := make([][]byte, 0, len(values))
+	for _, v := range values {
+		msgs = append(msgs, encodeOSCMessage(v.address, float32(v.value)))
+	}
+
+	return msgs
+}
+
+// startOSCSender sends the meters as OSC messages over UDP to target
+// ("host:port") rate times per second and returns a function that stops it.
+func startOSCSender(target string, rate float64, comp *dsp.SoftKneeCompressor) (func(), error) {
+	if rate <= 0 || rate > oscRateLimit || math.IsNaN(rate) {
+		return nil, fmt.Errorf("OSC rate %g out of range (0, %g]", rate, oscRateLimit)
+	}
+
+	conn, err := net.Dial("udp", target)
+	if err != nil {
+		return nil, fmt.Errorf("open OSC target %s: %w", target, err)
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// A receiver that isn't listening yet shouldn't stop the stream
+				for _, msg := range meterOSCMessages(comp.GetMeters()) {
+					_, _ = conn.Write(msg)
+				}
+			}
+		}
+	}()
+
+	slog.Info("OSC meter export started", "target", target, "rate", rate)
+
+	return func() {
+		close(done)
+		<-stopped
+		conn.Close()
+	}, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"pw-comp/dsp"
+)
+
+// TestEncodeOSCMessage verifies the padding of the address and type tags and
+// the big-endian float argument.
+func TestEncodeOSCMessage(t *testing.T) {
+	t.Parallel()
+
+	got := encodeOSCMessage("/gr", 1.0)
+	want := []byte{
+		'/', 'g', 'r', 0,
+		',', 'f', 0, 0,
+		0x3f, 0x80, 0x00, 0x00,
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("encodeOSCMessage = % x, want % x", got, want)
+	}
+
+	// An address filling four bytes gets a full word of padding
+	if got := encodeOSCMessage("/abc"); len(got) != 12 || got[4] != 0 {
+		t.Errorf("encodeOSCMessage without arguments = % x", got)
+	}
+}
+
+// TestMeterOSCMessages verifies the meters map to one message per channel value.
+func TestMeterOSCMessages(t *testing.T) {
+	t.Parallel()
+
+	stats := dsp.MeterStats{
+		GainReductionMeterL: 6.0,
+		GainReductionMeterR: 3.0,
+		InputMeterL:         0.5,
+		InputMeterR:         0.25,
+		OutputMeterL:        0.125,
+		OutputMeterR:        2.0,
+	}
+
+	want := [][]byte{
+		encodeOSCMessage("/pwcomp/gr/left", 6.0),
+		encodeOSCMessage("/pwcomp/gr/right", 3.0),
+		encodeOSCMessage("/pwcomp/input/left", 0.5),
+		encodeOSCMessage("/pwcomp/input/right", 0.25),
+		encodeOSCMessage("/pwcomp/output/left", 0.125),
+		encodeOSCMessage("/pwcomp/output/right", 2.0),
+	}
+
+	got := meterOSCMessages(stats)
+	if len(got) != len(want) {
+		t.Fatalf("Got %d messages, want %d", len(got), len(want))
+	}
+
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("Message %d = % x, want % x", i, got[i], want[i])
+		}
+	}
+}
+
+// TestOSCSender verifies the sender streams the meter messages over UDP.
+func TestOSCSender(t *testing.T) {
+	t.Parallel()
+
+	receiver, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer receiver.Close()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+
+	stop, err := startOSCSender(receiver.LocalAddr().String(), 100.0, comp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	_ = receiver.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	buf := make([]byte, 256)
+
+	n, _, err := receiver.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := meterOSCMessages(comp.GetMeters())[0]; !bytes.Equal(buf[:n], want) {
+		t.Errorf("Received % x, want % x", buf[:n], want)
+	}
+
+	if _, err := startOSCSender(receiver.LocalAddr().String(), 0.0, comp); err == nil {
+		t.Error("A zero rate should be rejected")
+	}
+}
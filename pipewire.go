@@ -0,0 +1,200 @@
+//go:build !nopipewire && cgo
+
+//go:generate sh -c "gcc -shared -o libpw_wrapper.so -fPIC csrc/pw_wrapper.c -I/usr/include/pipewire-0.3 -I/usr/include/spa-0.2 -lpipewire-0.3"
+
+package main
+
+/*
+#cgo CFLAGS: -I./csrc -I/usr/include/pipewire-0.3 -I/usr/include/spa-0.2
+#cgo LDFLAGS: -L${SRCDIR} -Wl,-rpath,${SRCDIR} -lpw_wrapper -lpipewire-0.3
+
+#include <pipewire/pipewire.h>
+#include <spa/param/audio/format-utils.h>
+#include <spa/param/audio/format.h>
+#include <spa/param/format-utils.h>
+#include <spa/utils/type.h>
+#include <spa/pod/builder.h>
+#include <spa/pod/pod.h>
+#include <spa/pod/parser.h>
+#include <spa/pod/vararg.h>
+#include "pw_wrapper.h"
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	// errMainLoop is returned when PipeWire can't create its main loop.
+	errMainLoop = errors.New("failed to create PipeWire main loop")
+
+	// errFilter is returned when PipeWire can't create the filter node.
+	errFilter = errors.New("failed to create PipeWire filter")
+)
+
+// export log_from_c
+//
+//export log_from_c
+func log_from_c(msg *C.char) {
+	slog.Info("C-Side", "msg", C.GoString(msg))
+}
+
+// Per-channel views of the current quantum's port buffers, reused across
+// quanta so the audio thread doesn't allocate.
+var quantumIn, quantumOut [][]float32
+
+//export process_quantum_go
+func process_quantum_go(in **C.float, out **C.float, channelCount C.int, samples C.int, rate C.int) {
+	if compressor == nil || in == nil || out == nil || samples <= 0 || int(channelCount) != channels {
+		return
+	}
+
+	// Update sample rate if changed
+	if rate > 0 {
+		compressor.SetSampleRate(float64(rate))
+	}
+
+	// Convert the C buffer arrays to Go slices; C passes a buffer for every
+	// channel, so a port without a buffer can't shift the channel order
+	inPtrs := unsafe.Slice(in, int(channelCount))
+	outPtrs := unsafe.Slice(out, int(channelCount))
+
+	if len(quantumIn) != channels {
+		quantumIn = make([][]float32, channels)
+		quantumOut = make([][]float32, channels)
+	}
+
+	for ch := range channels {
+		quantumIn[ch] = unsafe.Slice((*float32)(unsafe.Pointer(inPtrs[ch])), int(samples))
+		quantumOut[ch] = unsafe.Slice((*float32)(unsafe.Pointer(outPtrs[ch])), int(samples))
+	}
+
+	// Process every channel of the quantum at once, so cross-channel
+	// detection (sidechain source, link groups, link mode) applies
+	start := time.Now()
+	compressor.ProcessChannels(quantumIn, quantumOut)
+	recordCPULoad(time.Since(start), int(samples), int(rate))
+}
+
+//export fill_gr_cv_go
+func fill_gr_cv_go(out *C.float, samples C.int) {
+	if compressor == nil || out == nil || samples <= 0 {
+		return
+	}
+
+	compressor.GainReductionCV(unsafe.Slice((*float32)(unsafe.Pointer(out)), int(samples)))
+}
+
+//export on_node_ready_go
+func on_node_ready_go(nodeID C.uint32_t, serial C.uint64_t) {
+	handleNodeReady(uint32(nodeID), uint64(serial))
+}
+
+//export on_loop_started_go
+func on_loop_started_go() {
+	signalLoopStarted()
+}
+
+//export on_stream_restart_go
+func on_stream_restart_go(rate C.int) {
+	handleStreamRestart(int(rate))
+}
+
+// runPipeWire runs the compressor as a PipeWire filter until Ctrl+C in
+// headless mode, or until the TUI exits.
+func runPipeWire(opts audioOptions) error {
+	if opts.Debug {
+		C.pw_debug = 1
+	}
+
+	if opts.GainReductionCV {
+		C.pw_gr_cv = 1
+	}
+
+	C.pw_latency_samples = C.int(opts.LatencySamples)
+
+	// Initialize PipeWire
+	C.pw_init(nil, nil)
+	slog.Info("PipeWire initialized")
+
+	// Create main loop
+	loop := C.pw_main_loop_new(nil)
+	if loop == nil {
+		return errMainLoop
+	}
+
+	// Create a new PipeWire filter with separate ports for each channel
+	filterData := C.create_pipewire_filter(loop, C.int(channels))
+	if filterData == nil {
+		C.pw_main_loop_destroy(loop)
+		return errFilter
+	}
+	slog.Info("PipeWire filter created")
+
+	if opts.NoTUI {
+		//nolint:forbidigo // headless mode startup message
+		fmt.Println("Starting PipeWire Audio Compressor (pw-comp)...")
+		//nolint:forbidigo // headless mode startup message
+		fmt.Println("TUI disabled. Running in headless mode.")
+		//nolint:forbidigo // headless mode startup message
+		fmt.Println("Log file:", opts.LogPath)
+		//nolint:forbidigo // headless mode startup message
+		fmt.Println("Press Ctrl+C to exit.")
+
+		// Quit the loop on Ctrl+C or SIGTERM so the shutdown below still runs
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+		go func() {
+			sig := <-signals
+			slog.Info("Received signal, stopping PipeWire loop", "signal", sig.String())
+			C.pw_main_loop_quit(loop)
+		}()
+
+		// Run in main thread
+		C.pw_main_loop_run(loop)
+		signal.Stop(signals)
+	} else {
+		var waitGroup sync.WaitGroup
+		waitGroup.Add(1)
+
+		// Run PipeWire loop in background
+		go func() {
+			defer waitGroup.Done()
+			slog.Info("Starting PipeWire main loop")
+			C.pw_main_loop_run(loop)
+			slog.Info("PipeWire main loop exited")
+		}()
+
+		// Start the TUI once the loop runs; carry on after the timeout, as the
+		// TUI is still useful while PipeWire is slow to come up
+		if !waitForLoopStart(loopStarted, loopStartTimeout) {
+			slog.Warn("PipeWire main loop did not start in time", "timeout", loopStartTimeout)
+		}
+
+		// Run TUI in main thread
+		runTUI(compressor)
+
+		// When TUI returns, quit PipeWire loop
+		slog.Info("TUI exited, stopping PipeWire loop")
+		C.pw_main_loop_quit(loop)
+
+		// Wait for PipeWire loop to finish cleaning up its internal state
+		waitGroup.Wait()
+	}
+
+	// Cleanup
+	C.destroy_pipewire_filter(filterData)
+	C.pw_main_loop_destroy(loop)
+
+	return nil
+}
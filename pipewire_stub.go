@@ -0,0 +1,14 @@
+//go:build nopipewire || !cgo
+
+package main
+
+import "errors"
+
+// errNoPipeWire is returned when audio is requested from a binary built with
+// the nopipewire tag or without cgo, which leaves out the C wrapper.
+var errNoPipeWire = errors.New("built without PipeWire support (nopipewire tag or cgo disabled), rebuild with cgo and without the tag to run the filter")
+
+// runPipeWire reports that this build can't run the PipeWire filter.
+func runPipeWire(_ audioOptions) error {
+	return errNoPipeWire
+}
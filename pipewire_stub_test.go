@@ -0,0 +1,37 @@
+//go:build nopipewire || !cgo
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"pw-comp/dsp"
+)
+
+// TestRunPipeWireWithoutSupport verifies a build without PipeWire reports the
+// missing support instead of failing to start.
+func TestRunPipeWireWithoutSupport(t *testing.T) {
+	t.Parallel()
+
+	if err := runPipeWire(audioOptions{NoTUI: true}); !errors.Is(err, errNoPipeWire) {
+		t.Errorf("runPipeWire returned %v, want %v", err, errNoPipeWire)
+	}
+}
+
+// TestOfflineProcessingWithoutPipeWire verifies the compressor processes
+// buffers in a build without cgo.
+func TestOfflineProcessingWithoutPipeWire(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(8.0)
+
+	buffer := GenerateInterleavedStereoSine(SineWaveConfig{Frequency: 1000.0, Amplitude: 0.9, SampleRate: 48000.0}, 4800, 0.0)
+	comp.ProcessFrames(buffer, buffer)
+
+	if gr := comp.GetMeters().GainReductionL; gr >= 1.0 {
+		t.Errorf("Loud input should be compressed, gain %f", gr)
+	}
+}
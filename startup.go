@@ -0,0 +1,42 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// loopStartTimeout bounds how long the TUI waits for the PipeWire loop to start.
+const loopStartTimeout = 2 * time.Second
+
+var (
+	// loopStarted is closed once the PipeWire main loop runs its first iteration.
+	loopStarted = make(chan struct{})
+
+	// loopStartedOnce guards closing loopStarted.
+	loopStartedOnce sync.Once
+)
+
+// signalLoopStarted marks the PipeWire main loop as running. Later calls are no-ops.
+func signalLoopStarted() {
+	loopStartedOnce.Do(func() { close(loopStarted) })
+}
+
+// waitForLoopStart blocks until started is closed or the timeout expires and
+// reports whether the loop started in time.
+func waitForLoopStart(started <-chan struct{}, timeout time.Duration) bool {
+	select {
+	case <-started:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-started:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWaitForLoopStart verifies the TUI launch proceeds as soon as the loop
+// signals its start and gives up after the timeout otherwise.
+func TestWaitForLoopStart(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(started)
+	}()
+
+	if !waitForLoopStart(started, 5*time.Second) {
+		t.Error("Expected the start signal to be seen before the timeout")
+	}
+
+	// An already started loop doesn't block
+	if !waitForLoopStart(started, 0) {
+		t.Error("Expected an earlier start signal to be seen")
+	}
+
+	begin := time.Now()
+
+	if waitForLoopStart(make(chan struct{}), 20*time.Millisecond) {
+		t.Error("Expected a timeout without a start signal")
+	}
+
+	if elapsed := time.Since(begin); elapsed < 20*time.Millisecond {
+		t.Errorf("Returned after %v, before the timeout", elapsed)
+	}
+}
@@ -68,3 +68,129 @@ func MeasureGainReduction(input, output []float32) (float64, float64, float64) {
 
 	return inputRMS, outputRMS, gainReductionDB
 }
+
+// CompareToReference measures how far an output deviates from a reference
+// rendering of the same input, returning the maximum absolute sample error and
+// the RMS error.
+func CompareToReference(output, reference []float32) (float64, float64) {
+	if len(output) != len(reference) {
+		panic("output and reference buffers must have same length")
+	}
+
+	if len(output) == 0 {
+		return 0.0, 0.0
+	}
+
+	var maxErr, sumSquares float64
+
+	for i := range output {
+		diff := math.Abs(float64(output[i]) - float64(reference[i]))
+		maxErr = math.Max(maxErr, diff)
+		sumSquares += diff * diff
+	}
+
+	return maxErr, math.Sqrt(sumSquares / float64(len(output)))
+}
+
+// steadyToleranceDB is the change in block gain below which ProcessUntilSteady
+// considers the compressor settled.
+const steadyToleranceDB = 0.01
+
+// ProcessUntilSteady repeatedly processes a fresh copy of the interleaved input
+// through the compressor until the gain of the given channel changes by less
+// than steadyToleranceDB between iterations, or maxIterations is reached. It
+// returns the output of the last iteration and the number of iterations run.
+func ProcessUntilSteady(input []float32, channel int, maxIterations int) ([]float32, int) {
+	inputRMS := CalculateRMS(channelSamples(input, channel))
+
+	var output []float32
+
+	previousGainDB := math.Inf(1)
+
+	for iteration := 1; iteration <= maxIterations; iteration++ {
+		output = append(output[:0], input...)
+		processAudioBuffer(output)
+
+		gainDB := LinearToDBFS(CalculateRMS(channelSamples(output, channel))) - LinearToDBFS(inputRMS)
+		if math.Abs(gainDB-previousGainDB) < steadyToleranceDB {
+			return output, iteration
+		}
+
+		previousGainDB = gainDB
+	}
+
+	return output, maxIterations
+}
+
+// channelSamples extracts one channel from an interleaved buffer.
+func channelSamples(interleaved []float32, channel int) []float32 {
+	samples := make([]float32, 0, len(interleaved)/channels)
+	for i := channel; i < len(interleaved); i += channels {
+		samples = append(samples, interleaved[i])
+	}
+
+	return samples
+}
+
+// MeasureHarmonics returns the amplitudes of the first count harmonics of a
+// tone, index 0 being the fundamental, by correlating the signal with each
+// harmonic frequency. The buffer should span a whole number of periods.
+func MeasureHarmonics(samples []float32, fundamentalHz, sampleRate float64, count int) []float64 {
+	amplitudes := make([]float64, count)
+	if len(samples) == 0 {
+		return amplitudes
+	}
+
+	for h := range count {
+		omega := 2.0 * math.Pi * fundamentalHz * float64(h+1) / sampleRate
+
+		var re, im float64
+		for i, sample := range samples {
+			re += float64(sample) * math.Cos(omega*float64(i))
+			im -= float64(sample) * math.Sin(omega*float64(i))
+		}
+
+		amplitudes[h] = 2.0 * math.Hypot(re, im) / float64(len(samples))
+	}
+
+	return amplitudes
+}
+
+// thdHarmonics is the number of harmonics, including the fundamental, the
+// distortion measurements take into account.
+const thdHarmonics = 10
+
+// MeasureTHD returns the total harmonic distortion of a tone: the combined
+// amplitude of harmonics 2-10 relative to the fundamental.
+func MeasureTHD(samples []float32, fundamentalHz, sampleRate float64) float64 {
+	return measureHarmonicDistortion(samples, fundamentalHz, sampleRate, func(int) bool { return true })
+}
+
+// MeasureEvenTHD is MeasureTHD restricted to the even harmonics (2, 4, ...).
+func MeasureEvenTHD(samples []float32, fundamentalHz, sampleRate float64) float64 {
+	return measureHarmonicDistortion(samples, fundamentalHz, sampleRate, func(n int) bool { return n%2 == 0 })
+}
+
+// MeasureOddTHD is MeasureTHD restricted to the odd harmonics (3, 5, ...).
+func MeasureOddTHD(samples []float32, fundamentalHz, sampleRate float64) float64 {
+	return measureHarmonicDistortion(samples, fundamentalHz, sampleRate, func(n int) bool { return n%2 == 1 })
+}
+
+// measureHarmonicDistortion relates the harmonics selected by their number to
+// the fundamental.
+func measureHarmonicDistortion(samples []float32, fundamentalHz, sampleRate float64, include func(n int) bool) float64 {
+	amplitudes := MeasureHarmonics(samples, fundamentalHz, sampleRate, thdHarmonics)
+	if amplitudes[0] == 0 {
+		return 0.0
+	}
+
+	var sum float64
+
+	for h := 1; h < thdHarmonics; h++ {
+		if include(h + 1) {
+			sum += amplitudes[h] * amplitudes[h]
+		}
+	}
+
+	return math.Sqrt(sum) / amplitudes[0]
+}
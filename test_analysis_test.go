@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"pw-comp/dsp"
+)
+
+// renderReference processes a buffer through a fresh compressor with the
+// default test settings and returns the output.
+func renderReference(input []float32, precision dsp.Precision) []float32 {
+	comp := dsp.NewSoftKneeCompressor(testSampleRate, 1)
+	comp.SetThreshold(-20.0)
+	comp.SetRatio(4.0)
+	comp.SetPrecision(precision)
+
+	output := make([]float32, len(input))
+	comp.ProcessBlock(input, output, 0)
+
+	return output
+}
+
+// TestCompareToReference verifies the error metrics against a reference
+// rendering: identical processing matches exactly, a known offset is measured
+// exactly, and float32 processing stays within tolerance of float64.
+func TestCompareToReference(t *testing.T) {
+	t.Parallel()
+
+	input := GenerateCalibrationTone(-6.0, testSampleRate, int(testSampleRate))
+	reference := renderReference(input, dsp.Float64)
+
+	maxErr, rmsErr := CompareToReference(renderReference(input, dsp.Float64), reference)
+	if maxErr != 0.0 || rmsErr != 0.0 {
+		t.Errorf("Identical processing should match exactly, got max %g, RMS %g", maxErr, rmsErr)
+	}
+
+	const offset = 0.001
+
+	shifted := make([]float32, len(reference))
+	for i, sample := range reference {
+		shifted[i] = sample + offset
+	}
+
+	maxErr, rmsErr = CompareToReference(shifted, reference)
+	if math.Abs(maxErr-offset) > 1e-6 || math.Abs(rmsErr-offset) > 1e-6 {
+		t.Errorf("A constant offset of %g should be measured exactly, got max %g, RMS %g", offset, maxErr, rmsErr)
+	}
+
+	maxErr, rmsErr = CompareToReference(renderReference(input, dsp.Float32), reference)
+	if maxErr > 1e-3 || rmsErr > 1e-4 {
+		t.Errorf("Float32 output deviates too far from the reference: max %g, RMS %g", maxErr, rmsErr)
+	}
+}
+
+// TestMeasureTHD verifies the harmonic measurements on a tone with known even
+// and odd harmonics.
+func TestMeasureTHD(t *testing.T) {
+	t.Parallel()
+
+	const frames = 4800 // 100 periods of 1 kHz
+
+	samples := make([]float32, frames)
+
+	for i := range samples {
+		phase := 2.0 * math.Pi * testFreq1kHz * float64(i) / testSampleRate
+		samples[i] = float32(0.5*math.Sin(phase) + 0.03*math.Sin(2*phase) + 0.04*math.Sin(3*phase))
+	}
+
+	harmonics := MeasureHarmonics(samples, testFreq1kHz, testSampleRate, 4)
+	for h, want := range []float64{0.5, 0.03, 0.04, 0.0} {
+		if math.Abs(harmonics[h]-want) > 1e-5 {
+			t.Errorf("Harmonic %d amplitude %.6f, want %.6f", h+1, harmonics[h], want)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		measure func([]float32, float64, float64) float64
+		want    float64
+	}{
+		{"THD", MeasureTHD, 0.1},
+		{"even THD", MeasureEvenTHD, 0.06},
+		{"odd THD", MeasureOddTHD, 0.08},
+	}
+
+	for _, tt := range tests {
+		if got := tt.measure(samples, testFreq1kHz, testSampleRate); math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("%s %.5f, want %.5f", tt.name, got, tt.want)
+		}
+	}
+}
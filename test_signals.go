@@ -23,6 +23,21 @@ func GenerateSine(config SineWaveConfig, frames int) []float32 {
 	return buffer
 }
 
+// calibrationToneFreq is the standard 997 Hz calibration frequency, chosen so the
+// tone does not share a common period with typical sample rates.
+const calibrationToneFreq = 997.0
+
+// GenerateCalibrationTone creates a mono sine whose RMS level (not peak) equals
+// the requested dBFS, as used for meter calibration. A sine's peak is sqrt(2)
+// times its RMS, so a -20 dBFS RMS tone peaks at about -17 dBFS.
+func GenerateCalibrationTone(dbfs float64, sampleRate float64, frames int) []float32 {
+	return GenerateSine(SineWaveConfig{
+		Frequency:  calibrationToneFreq,
+		Amplitude:  DBFSToLinear(dbfs) * math.Sqrt2,
+		SampleRate: sampleRate,
+	}, frames)
+}
+
 // GenerateInterleavedStereoSine creates a stereo sine wave with interleaved L/R samples
 // rightPhase allows phase offset between left and right channels (in radians).
 func GenerateInterleavedStereoSine(config SineWaveConfig, frames int, rightPhase float64) []float32 {
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestGenerateCalibrationTone verifies the calibration tone's measured RMS matches
+// the requested dBFS level.
+func TestGenerateCalibrationTone(t *testing.T) {
+	t.Parallel()
+
+	for _, dbfs := range []float64{-3.0, -18.0, -20.0, -40.0} {
+		tone := GenerateCalibrationTone(dbfs, testSampleRate, int(testSampleRate))
+
+		measured := LinearToDBFS(CalculateRMS(tone))
+		if math.Abs(measured-dbfs) > 0.01 {
+			t.Errorf("Calibration tone at %.1f dBFS measured %.3f dBFS RMS", dbfs, measured)
+		}
+
+		// The peak sits 3 dB above the RMS level for a sine
+		peak := LinearToDBFS(float64(FindPeak(tone)))
+		if math.Abs(peak-(dbfs+3.01)) > 0.05 {
+			t.Errorf("Calibration tone at %.1f dBFS has unexpected peak %.3f dBFS", dbfs, peak)
+		}
+	}
+}
@@ -4,12 +4,56 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/nsf/termbox-go"
 	"pw-comp/dsp"
 )
 
+// Gain reduction in dB at which the activity indicator turns yellow and red.
+const (
+	activityModerateDB = 3.0
+	activityHeavyDB    = 12.0
+)
+
+// Gain reduction history display: entries shown, capture interval and the
+// reduction in dB that fills a full character cell.
+const (
+	gainHistoryWidth      = 60
+	gainHistoryIntervalMs = 50.0
+	gainHistoryRangeDB    = 24.0
+)
+
+// Meter bar ranges: gain reduction from 0 dB (empty) to grMeterRangeDB (full),
+// levels from levelMeterMinDB to levelMeterMaxDB.
+const (
+	grMeterRangeDB  = 24.0
+	levelMeterMinDB = -96.0
+	levelMeterMaxDB = 6.0
+)
+
+// grScale selects how the gain reduction bars map dB to bar length.
+type grScale int
+
+const (
+	// grScaleLinear fills the bar evenly across the range.
+	grScaleLinear grScale = iota
+
+	// grScaleLog fills the bar logarithmically, so the first few dB of
+	// reduction, which matter most, take up more of it.
+	grScaleLog
+)
+
+// Seconds of recent input the auto-tune key analyzes.
+const autoTuneCaptureSec = 5.0
+
+// Balance indicator scale: characters and dB on each side of center.
+const (
+	balanceHalfWidth = 10
+	balanceRangeDB   = 12.0
+)
+
 const (
 	colDef    = termbox.ColorDefault
 	colWhite  = termbox.ColorWhite
@@ -24,17 +68,31 @@ type TUIState struct {
 	selectedParam int
 	comp          *dsp.SoftKneeCompressor
 	exit          bool
+	showDebug     bool                           // Show the internal coefficient panel
+	grScale       grScale                        // Fill mapping of the gain reduction bars
+	status        string                         // Result of the last auto-tune or slot action
+	slots         [presetSlots]*compressorParams // Quick-recall parameter snapshots, nil = empty
 }
 
+// Number of quick-recall preset slots, on keys 1-4.
+const presetSlots = 4
+
+// slotStoreKeys are the characters Shift+1..4 produce on a US layout.
+var slotStoreKeys = [presetSlots]rune{'!', '@', '#', '$'}
+
 var paramNames = []string{
 	"Threshold (dB)",
 	"Ratio (1:x)",
 	"Knee (dB)",
 	"Attack (ms)",
 	"Release (ms)",
+	"Speed (x)",
+	"Range (dB)",
 	"Makeup Gain (dB)",
 	"Auto Makeup",
+	"Makeup Bypass",
 	"Bypass",
+	"Output Gain (dB)",
 }
 
 func runTUI(comp *dsp.SoftKneeCompressor) {
@@ -48,17 +106,16 @@ func runTUI(comp *dsp.SoftKneeCompressor) {
 
 	termbox.SetInputMode(termbox.InputEsc)
 
+	comp.SetGainHistory(gainHistoryWidth, gainHistoryIntervalMs)
+	comp.SetInputCapture(autoTuneCaptureSec)
+
 	state := &TUIState{
 		comp: comp,
 	}
 
-	eventQueue := make(chan termbox.Event)
-
-	go func() {
-		for {
-			eventQueue <- termbox.PollEvent()
-		}
-	}()
+	// Stopped before termbox.Close, so no poll or send outlives the TUI
+	poller := startEventPoller(termbox.PollEvent, termbox.Interrupt)
+	defer poller.stop()
 
 	ticker := time.NewTicker(50 * time.Millisecond)
 	defer ticker.Stop()
@@ -67,10 +124,16 @@ func runTUI(comp *dsp.SoftKneeCompressor) {
 
 	for !state.exit {
 		select {
-		case ev := <-eventQueue:
+		case ev := <-poller.events:
 			switch ev.Type {
 			case termbox.EventKey:
 				handleKey(ev, state)
+
+				// Redraw right away so changed values, and the auto makeup
+				// derived from them, don't wait for the next tick
+				if !state.exit {
+					draw(state)
+				}
 			case termbox.EventResize:
 				draw(state)
 			}
@@ -80,6 +143,55 @@ func runTUI(comp *dsp.SoftKneeCompressor) {
 	}
 }
 
+// eventPoller forwards terminal events from a blocking poll function to its
+// events channel in a goroutine until stopped.
+type eventPoller struct {
+	events    chan termbox.Event
+	done      chan struct{}
+	stopped   chan struct{}
+	interrupt func()
+}
+
+// startEventPoller starts forwarding the events returned by poll. interrupt
+// must make a pending poll return an EventInterrupt, as termbox.Interrupt does.
+func startEventPoller(poll func() termbox.Event, interrupt func()) *eventPoller {
+	poller := &eventPoller{
+		events:    make(chan termbox.Event),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		interrupt: interrupt,
+	}
+
+	go func() {
+		defer close(poller.stopped)
+
+		for {
+			ev := poll()
+			if ev.Type == termbox.EventInterrupt {
+				return
+			}
+
+			// Once stopping, events are dropped and the loop returns to the
+			// poll, where the interrupt is waiting
+			select {
+			case poller.events <- ev:
+			case <-poller.done:
+			}
+		}
+	}()
+
+	return poller
+}
+
+// stop ends the polling goroutine and waits for it to exit. The goroutine only
+// exits from the poll, so the interrupt, which blocks until a poll takes it,
+// can't be left pending.
+func (p *eventPoller) stop() {
+	close(p.done)
+	p.interrupt()
+	<-p.stopped
+}
+
 //nolint:gocyclo,cyclop,funlen // UI event handler with multiple parameter cases
 func handleKey(ev termbox.Event, s *TUIState) {
 	if ev.Key == termbox.KeyEsc || ev.Ch == 'q' {
@@ -87,6 +199,64 @@ func handleKey(ev termbox.Event, s *TUIState) {
 		return
 	}
 
+	if ev.Ch == 'd' {
+		s.showDebug = !s.showDebug
+		return
+	}
+
+	if ev.Ch == 'a' {
+		s.status = autoTune(s.comp)
+		return
+	}
+
+	if ev.Ch == 'g' {
+		s.grScale = nextGRScale(s.grScale)
+		return
+	}
+
+	if ev.Ch == 'h' {
+		s.comp.SetPeakHoldInfinite(!s.comp.GetPeakHoldInfinite())
+		return
+	}
+
+	if ev.Ch == 'r' {
+		s.comp.ResetPeakHold()
+		s.comp.ResetClipCounts()
+		return
+	}
+
+	if ev.Ch >= '1' && ev.Ch < '1'+presetSlots {
+		s.status = recallSlot(s, int(ev.Ch-'1'))
+		return
+	}
+
+	for slot, key := range slotStoreKeys {
+		if ev.Ch == key {
+			s.status = storeSlot(s, slot)
+			return
+		}
+	}
+
+	if ev.Ch == 'm' {
+		s.comp.SetDim(nextDim(s.comp.GetDim(), dimLevel))
+		return
+	}
+
+	if ev.Ch == 'l' {
+		s.comp.SetLinkMakeupToThreshold(!s.comp.GetLinkMakeupToThreshold())
+		return
+	}
+
+	if ev.Ch == 'x' {
+		s.comp.SetDeltaMonitor(!s.comp.GetDeltaMonitor())
+		return
+	}
+
+	if ev.Ch == 's' {
+		_ = s.comp.SetSolo(nextSolo(s.comp.GetSolo(), s.comp.Channels()))
+		return
+	}
+
 	// Navigation
 	switch ev.Key {
 	case termbox.KeyArrowUp:
@@ -168,7 +338,33 @@ func handleKey(ev termbox.Event, s *TUIState) {
 		if change != 0 {
 			s.comp.SetRelease(s.comp.GetRelease() + change)
 		}
-	case 5: // Makeup
+	case 5: // Speed
+		change := 0.0
+		if ev.Key == termbox.KeyArrowRight {
+			change = 0.1
+		}
+
+		if ev.Key == termbox.KeyArrowLeft {
+			change = -0.1
+		}
+
+		if change != 0 {
+			s.comp.SetSpeed(s.comp.GetSpeed() + change)
+		}
+	case 6: // Range
+		change := 0.0
+		if ev.Key == termbox.KeyArrowRight {
+			change = 1.0
+		}
+
+		if ev.Key == termbox.KeyArrowLeft {
+			change = -1.0
+		}
+
+		if change != 0 {
+			s.comp.SetRange(s.comp.GetRange() + change)
+		}
+	case 7: // Makeup
 		change := 0.0
 		if ev.Key == termbox.KeyArrowRight {
 			change = 0.5
@@ -181,14 +377,31 @@ func handleKey(ev termbox.Event, s *TUIState) {
 		if change != 0 {
 			s.comp.SetMakeupGain(s.comp.GetMakeupGain() + change)
 		}
-	case 6: // Auto Makeup
+	case 8: // Auto Makeup
 		if ev.Key == termbox.KeyArrowRight || ev.Key == termbox.KeyArrowLeft || ev.Key == termbox.KeyEnter {
 			s.comp.SetAutoMakeup(!s.comp.GetAutoMakeup())
 		}
-	case 7: // Bypass
+	case 9: // Makeup Bypass
+		if ev.Key == termbox.KeyArrowRight || ev.Key == termbox.KeyArrowLeft || ev.Key == termbox.KeyEnter {
+			s.comp.SetMakeupBypass(!s.comp.GetMakeupBypass())
+		}
+	case 10: // Bypass
 		if ev.Key == termbox.KeyArrowRight || ev.Key == termbox.KeyArrowLeft || ev.Key == termbox.KeyEnter {
 			s.comp.SetBypass(!s.comp.GetBypass())
 		}
+	case 11: // Output Gain
+		change := 0.0
+		if ev.Key == termbox.KeyArrowRight {
+			change = 0.5
+		}
+
+		if ev.Key == termbox.KeyArrowLeft {
+			change = -0.5
+		}
+
+		if change != 0 {
+			s.comp.SetOutputGain(s.comp.GetOutputGain() + change)
+		}
 	}
 }
 
@@ -200,20 +413,28 @@ func draw(state *TUIState) {
 	// Header
 	printTB(0, 0, colCyan, colDef, "PipeWire Audio Compressor (pw-comp) - Interactive Mode")
 	printTB(0, 1, colWhite, colDef,
-		fmt.Sprintf("Sample Rate: %.0f Hz | Processed Blocks: %d", meters.SampleRate, meters.Blocks))
-	printTB(0, 2, colDef, colDef, "Use Arrows to navigate/adjust. 'q' or Esc to quit.")
+		fmt.Sprintf("Sample Rate: %.0f Hz | Processed Blocks: %d | %s", meters.SampleRate, meters.Blocks, nodeLabel()))
+	printTB(0, 2, colDef, colDef, "Use Arrows to navigate/adjust. 'a' auto-tunes, 's' solos, 'm' dims, 'x' delta, 'l' locks makeup, 'g' log GR, 1-4 recall (Shift stores), 'd' toggles coefficients. 'q' or Esc to quit.")
 	printTB(0, 3, colDef, colDef, "----------------------------------------------------")
 
-	// Parameters
+	// Parameters, with the makeup read once so the row and the auto makeup
+	// readout show the same value in a frame
+	autoMakeup, makeupDB := state.comp.GetAutoMakeup(), state.comp.GetMakeupGain()
+	appliedMakeupDB := state.comp.GetAppliedMakeupGain()
+
 	vals := []string{
 		fmt.Sprintf("%.1f", state.comp.GetThreshold()),
 		fmt.Sprintf("%.1f", state.comp.GetRatio()),
 		fmt.Sprintf("%.1f", state.comp.GetKnee()),
 		fmt.Sprintf("%.1f", state.comp.GetAttack()),
 		fmt.Sprintf("%.1f", state.comp.GetRelease()),
-		fmt.Sprintf("%.1f", state.comp.GetMakeupGain()),
-		strconv.FormatBool(state.comp.GetAutoMakeup()),
+		fmt.Sprintf("%.1f", state.comp.GetSpeed()),
+		rangeReadout(state.comp.GetRange()),
+		fmt.Sprintf("%.1f", makeupDB),
+		strconv.FormatBool(autoMakeup),
+		strconv.FormatBool(state.comp.GetMakeupBypass()),
 		strconv.FormatBool(state.comp.GetBypass()),
+		fmt.Sprintf("%.1f", state.comp.GetOutputGain()),
 	}
 
 	for i, name := range paramNames {
@@ -230,31 +451,46 @@ func draw(state *TUIState) {
 		printTB(0, 5+i, col, bgColor, fmt.Sprintf("% -20s %s", prefix+name, vals[i]))
 	}
 
-	// Metering
-	meterY := 15
-	printTB(0, meterY, colYellow, colDef, "Meters:")
+	printTB(2, 5+len(paramNames), colCyan, colDef, autoMakeupReadout(autoMakeup, appliedMakeupDB, makeupDB))
+
+	if solo := state.comp.GetSolo(); solo >= 0 {
+		printTB(30, 5+len(paramNames), colYellow, colDef, fmt.Sprintf("SOLO ch %d", solo))
+	}
+
+	if dim := state.comp.GetDim(); dim != 0.0 {
+		printTB(42, 5+len(paramNames), colYellow, colDef, fmt.Sprintf("DIM %.0f dB", dim))
+	}
 
-	// Convert linear to dB for display
-	linToDB := func(l float64) float64 {
-		if l <= 1e-9 {
-			return -96.0
-		} // Lower noise floor
+	if state.comp.GetDeltaMonitor() {
+		printTB(54, 5+len(paramNames), colYellow, colDef, "DELTA")
+	}
 
-		return 20 * math.Log10(l)
+	if state.comp.GetLinkMakeupToThreshold() {
+		printTB(61, 5+len(paramNames), colYellow, colDef, "MAKEUP LOCK")
 	}
+	printTB(2, 6+len(paramNames), colYellow, colDef, state.status)
 
-	inL := linToDB(meters.InputL)
-	inR := linToDB(meters.InputR)
-	outL := linToDB(meters.OutputL)
-	outR := linToDB(meters.OutputR)
-	grL := linToDB(meters.GainReductionL)
-	grR := linToDB(meters.GainReductionR)
+	// Metering
+	meterY := 7 + len(paramNames)
+	printTB(0, meterY, colYellow, colDef, "Meters:")
+	drawActivityIndicators(9, meterY, state.comp)
 
-	drawMeter(meterY+2, "In L ", inL, colGreen)
-	drawMeter(meterY+3, "In R ", inR, colGreen)
+	// Levels use their own meter ballistics, like the gain reduction below
+	inL := linToDB(meters.InputMeterL)
+	inR := linToDB(meters.InputMeterR)
+	outL := linToDB(meters.OutputMeterL)
+	outR := linToDB(meters.OutputMeterR)
 
-	grLeftDisp := -grL
-	grRightDisp := -grR
+	drawMeter(meterY+2, "In L ", inL, levelFill(inL), colGreen)
+	drawMeter(meterY+3, "In R ", inR, levelFill(inR), colGreen)
+	drawClipIndicator(meterY+2, meters.InputClipL)
+	drawClipIndicator(meterY+3, meters.InputClipR)
+	printTB(84, meterY+2, colDef, colDef, clipCounts(state.comp, 0))
+	printTB(84, meterY+3, colDef, colDef, clipCounts(state.comp, 1))
+
+	// Gain reduction uses the meter ballistics so the bars don't flicker per block
+	grLeftDisp := meters.GainReductionMeterL
+	grRightDisp := meters.GainReductionMeterR
 
 	if grLeftDisp < 0 {
 		grLeftDisp = 0
@@ -264,49 +500,58 @@ func draw(state *TUIState) {
 		grRightDisp = 0
 	}
 
-	drawMeter(meterY+5, "GR L ", grLeftDisp, colRed)
-	drawMeter(meterY+6, "GR R ", grRightDisp, colRed)
-
-	drawMeter(meterY+8, "Out L", outL, colBlue)
-	drawMeter(meterY+9, "Out R", outR, colBlue)
+	drawMeter(meterY+5, "GR L ", grLeftDisp, grFill(grLeftDisp, state.grScale), colRed)
+	drawMeter(meterY+6, "GR R ", grRightDisp, grFill(grRightDisp, state.grScale), colRed)
+	printTB(84, meterY+5, colDef, colDef, blockGainReduction(meters.GainReductionL, meters.MeanGainL))
+	printTB(84, meterY+6, colDef, colDef, blockGainReduction(meters.GainReductionR, meters.MeanGainR))
+
+	drawMeter(meterY+8, "Out L", outL, levelFill(outL), colBlue)
+	drawMeter(meterY+9, "Out R", outR, levelFill(outR), colBlue)
+
+	printTB(2, meterY+11, colDef, colDef,
+		fmt.Sprintf("Avg GR L [%4.1f ±%3.1f dB]  Avg GR R [%4.1f ±%3.1f dB]",
+			meters.AverageGainReductionL, meters.GainReductionDevL,
+			meters.AverageGainReductionR, meters.GainReductionDevR))
+	printTB(2, meterY+12, colDef, colDef,
+		fmt.Sprintf("DC L     [%+.4f]    DC R     [%+.4f]", meters.DCOffsetL, meters.DCOffsetR))
+	printTB(50, meterY+11, colDef, colDef, formatPeakHold(meters, state.comp.GetPeakHoldInfinite()))
+	printTB(50, meterY+12, colDef, colDef,
+		fmt.Sprintf("Crest L [%4.1f dB]  Crest R [%4.1f dB]", meters.CrestFactorL, meters.CrestFactorR))
+	printTB(2, meterY+13, colDef, colDef, balanceIndicator(meters.ChannelBalance()))
+	printTB(50, meterY+13, colYellow, colDef, meters.GainStagingHint.String())
+	printTB(2, meterY+14, colRed, colDef, "GR hist L ["+gainHistoryLine(state.comp.GainHistory(0), gainHistoryWidth)+"]")
+	printTB(2, meterY+15, colRed, colDef, "GR hist R ["+gainHistoryLine(state.comp.GainHistory(1), gainHistoryWidth)+"]")
+
+	if state.showDebug {
+		printTB(0, meterY+17, colYellow, colDef, "Coefficients:")
+
+		for i, line := range formatCoefficients(state.comp.GetCoefficients()) {
+			printTB(2, meterY+18+i, colDef, colDef, line)
+		}
+	}
 
 	termbox.Flush()
 }
 
-func drawMeter(yPos int, label string, db float64, color termbox.Attribute) {
-	// Range -96 to +6 for levels, 0 to 30 for GR.
+// formatCoefficients renders the internal coefficients for the debug panel.
+func formatCoefficients(coeffs dsp.Coefficients) []string {
+	return []string{
+		fmt.Sprintf("Attack factor   %.6f", coeffs.AttackFactor),
+		fmt.Sprintf("Release factor  %.6f", coeffs.ReleaseFactor),
+		fmt.Sprintf("Threshold (lin) %.6f", coeffs.Threshold),
+		fmt.Sprintf("Knee (lin)      %.6f .. %.6f (width %.6f)", coeffs.KneeLower, coeffs.KneeUpper, coeffs.KneeWidth),
+		fmt.Sprintf("Makeup (lin)    %.6f", coeffs.MakeupGainLin),
+	}
+}
+
+// drawMeter draws a labeled bar filled to the given fraction (0..1).
+func drawMeter(yPos int, label string, db, fill float64, color termbox.Attribute) {
 	const (
 		barWidth = 60
 		xPos     = 2
 	)
 
-	var filled int
-
-	if color == colRed {
-		// GR logic: 0 to 24 dB range
-		// 0 dB = empty, 24 dB = full
-		ratio := db / 24.0
-		if ratio > 1 {
-			ratio = 1
-		}
-
-		filled = int(ratio * float64(barWidth))
-	} else {
-		// Level logic: -96 to 6 dB range
-		minDB := -96.0
-		maxDB := 6.0
-
-		if db < minDB {
-			db = minDB
-		}
-
-		if db > maxDB {
-			db = maxDB
-		}
-
-		ratio := (db - minDB) / (maxDB - minDB)
-		filled = int(ratio * float64(barWidth))
-	}
+	filled := int(fill * float64(barWidth))
 
 	printTB(xPos, yPos, colDef, colDef, fmt.Sprintf("%s [%-6.1f dB] ", label, db))
 
@@ -327,6 +572,224 @@ func drawMeter(yPos int, label string, db float64, color termbox.Attribute) {
 	}
 }
 
+// levelFill maps a level in dBFS to the filled fraction of a level bar.
+func levelFill(db float64) float64 {
+	db = math.Max(levelMeterMinDB, math.Min(levelMeterMaxDB, db))
+
+	return (db - levelMeterMinDB) / (levelMeterMaxDB - levelMeterMinDB)
+}
+
+// grFill maps a gain reduction in dB to the filled fraction of a gain
+// reduction bar. The log scale uses log(1+dB), so 1 dB fills about a fifth of
+// the bar and 12 dB about four fifths instead of a twenty-fourth and a half.
+func grFill(grDB float64, scale grScale) float64 {
+	grDB = math.Max(0.0, math.Min(grMeterRangeDB, grDB))
+
+	if scale == grScaleLog {
+		return math.Log1p(grDB) / math.Log1p(grMeterRangeDB)
+	}
+
+	return grDB / grMeterRangeDB
+}
+
+// nextGRScale toggles the gain reduction bars between linear and log fill.
+func nextGRScale(scale grScale) grScale {
+	if scale == grScaleLog {
+		return grScaleLinear
+	}
+
+	return grScaleLog
+}
+
+// gainHistoryLine renders the most recent gains as a scrolling line of width
+// characters, newest on the right, with taller bars for deeper reduction.
+func gainHistoryLine(gains []float64, width int) string {
+	levels := []rune(" ▁▂▃▄▅▆▇█")
+
+	line := []rune(strings.Repeat(" ", width))
+	start := max(0, len(gains)-width)
+
+	for i, gain := range gains[start:] {
+		grDB := math.Max(-dsp.LinearToDB(gain), 0.0)
+		level := int(math.Round(math.Min(grDB/gainHistoryRangeDB, 1.0) * float64(len(levels)-1)))
+		line[width-len(gains[start:])+i] = levels[level]
+	}
+
+	return string(line)
+}
+
+// balanceIndicator renders the L/R balance as a marker on a centered scale
+// spanning balanceRangeDB to either side; positive values lean left.
+func balanceIndicator(balanceDB float64) string {
+	scale := []rune(strings.Repeat("-", 2*balanceHalfWidth+1))
+	scale[balanceHalfWidth] = '|'
+
+	offset := int(math.Round(-balanceDB / balanceRangeDB * balanceHalfWidth))
+	offset = max(-balanceHalfWidth, min(balanceHalfWidth, offset))
+	scale[balanceHalfWidth+offset] = '●'
+
+	return fmt.Sprintf("Balance  L [%s] R  %+.1f dB", string(scale), balanceDB)
+}
+
+// autoTune analyzes the recently captured input, applies the suggested
+// attack, release and ratio, and returns a status line describing the result.
+func autoTune(comp *dsp.SoftKneeCompressor) string {
+	samples := comp.RecentInput()
+	if len(samples) == 0 {
+		return "Auto-tune: no input captured yet"
+	}
+
+	params := comp.AnalyzeAndSuggest(samples)
+
+	comp.SetAttack(params.AttackMs)
+	comp.SetRelease(params.ReleaseMs)
+	comp.SetRatio(params.Ratio)
+
+	return fmt.Sprintf("Auto-tune: attack %.1f ms, release %.0f ms, ratio %.1f:1",
+		params.AttackMs, params.ReleaseMs, params.Ratio)
+}
+
+// linToDB converts a linear level to dB for display, floored at -96 dB.
+func linToDB(l float64) float64 {
+	if l <= 1e-9 {
+		return -96.0
+	} // Lower noise floor
+
+	return 20 * math.Log10(l)
+}
+
+// formatPeakHold renders the held input and output peaks in dBFS, marking
+// infinity hold.
+func formatPeakHold(meters dsp.MeterStats, infinite bool) string {
+	mode := "Hold"
+	if infinite {
+		mode = "Hold∞"
+	}
+
+	return fmt.Sprintf("%s In [%5.1f %5.1f] Out [%5.1f %5.1f] dB", mode,
+		linToDB(meters.PeakHoldInputL), linToDB(meters.PeakHoldInputR),
+		linToDB(meters.PeakHoldOutputL), linToDB(meters.PeakHoldOutputR))
+}
+
+// nextSolo cycles the solo through no solo (-1) and each channel in turn.
+func nextSolo(current, channels int) int {
+	if current+1 >= channels {
+		return -1
+	}
+
+	return current + 1
+}
+
+// storeSlot saves the current parameters in a quick-recall slot.
+func storeSlot(s *TUIState, slot int) string {
+	params := captureParams(s.comp)
+	s.slots[slot] = &params
+
+	return fmt.Sprintf("Stored slot %d", slot+1)
+}
+
+// recallSlot applies the parameters of a quick-recall slot. Threshold, makeup
+// and output gain ramp to their new values with the parameter smoothing.
+func recallSlot(s *TUIState, slot int) string {
+	params := s.slots[slot]
+	if params == nil {
+		return fmt.Sprintf("Slot %d is empty (Shift+%d stores)", slot+1, slot+1)
+	}
+
+	params.apply(s.comp)
+
+	return fmt.Sprintf("Recalled slot %d", slot+1)
+}
+
+// nextDim toggles the dim between off (0) and level.
+func nextDim(current, level float64) float64 {
+	if current != 0.0 {
+		return 0.0
+	}
+
+	return level
+}
+
+// autoMakeupReadout shows how much gain auto makeup currently applies, and the
+// nominal makeup while headroom back-off or smoothing holds it lower.
+func autoMakeupReadout(autoMakeup bool, appliedDB, nominalDB float64) string {
+	if !autoMakeup {
+		return "Auto Makeup: off"
+	}
+
+	readout := fmt.Sprintf("Auto Makeup: %+.1f dB", appliedDB)
+	if math.Abs(nominalDB-appliedDB) >= 0.05 {
+		readout += fmt.Sprintf(" (of %+.1f)", nominalDB)
+	}
+
+	return readout
+}
+
+// rangeReadout formats the range parameter, showing "off" while unlimited.
+func rangeReadout(rangeDB float64) string {
+	if rangeDB <= 0.0 {
+		return "off"
+	}
+
+	return fmt.Sprintf("%.1f", rangeDB)
+}
+
+// drawActivityIndicators draws one LED-style character per channel colored by
+// its current gain reduction.
+func drawActivityIndicators(xPos, yPos int, comp *dsp.SoftKneeCompressor) {
+	for ch := range comp.Channels() {
+		meters, err := comp.GetChannelMeters(ch)
+		if err != nil {
+			return
+		}
+
+		termbox.SetCell(xPos+2*ch, yPos, '●', activityColor(meters.GainReductionMeter), colDef)
+	}
+}
+
+// activityColor maps gain reduction in dB to the activity indicator color:
+// green for none or light, yellow for moderate and red for heavy reduction.
+func activityColor(grDB float64) termbox.Attribute {
+	switch {
+	case grDB >= activityHeavyDB:
+		return colRed
+	case grDB >= activityModerateDB:
+		return colYellow
+	default:
+		return colGreen
+	}
+}
+
+// clipCounts formats the input and output clip counts of a channel, or
+// returns an empty string for a channel that doesn't exist.
+func clipCounts(comp *dsp.SoftKneeCompressor, channel int) string {
+	meters, err := comp.GetChannelMeters(channel)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("Clips in %d / out %d", meters.InputClipCount, meters.OutputClipCount)
+}
+
+// blockGainReduction formats the peak and average gain reduction of the last
+// block, or returns an empty string before a block has been processed.
+func blockGainReduction(minGain, meanGain float64) string {
+	if meanGain <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("Block pk %4.1f avg %4.1f dB", 0-linToDB(minGain), 0-linToDB(meanGain))
+}
+
+// drawClipIndicator marks a meter row when the signal reached full scale.
+func drawClipIndicator(yPos int, clipped bool) {
+	const xPos = 78 // Right of the meter bar
+
+	if clipped {
+		printTB(xPos, yPos, colRed, colDef, "CLIP")
+	}
+}
+
 func printTB(x, y int, fg, bg termbox.Attribute, msg string) {
 	for _, c := range msg {
 		termbox.SetCell(x, y, c, fg, bg)
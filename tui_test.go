@@ -0,0 +1,461 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsf/termbox-go"
+	"pw-comp/dsp"
+)
+
+// TestFormatCoefficients verifies the debug panel renders the internal values.
+func TestFormatCoefficients(t *testing.T) {
+	t.Parallel()
+
+	lines := formatCoefficients(dsp.Coefficients{
+		AttackFactor:  0.0014,
+		ReleaseFactor: 0.999856,
+		Threshold:     0.1,
+		KneeLower:     0.070795,
+		KneeUpper:     0.141254,
+		KneeWidth:     0.070459,
+		MakeupGainLin: 5.623413,
+	})
+
+	expected := []string{
+		"Attack factor   0.001400",
+		"Release factor  0.999856",
+		"Threshold (lin) 0.100000",
+		"Knee (lin)      0.070795 .. 0.141254 (width 0.070459)",
+		"Makeup (lin)    5.623413",
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d: %q", len(expected), len(lines), lines)
+	}
+
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Line %d: got %q, want %q", i, lines[i], expected[i])
+		}
+	}
+}
+
+// TestActivityColor verifies the gain-reduction to indicator color mapping.
+func TestActivityColor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		grDB     float64
+		expected termbox.Attribute
+	}{
+		{0.0, colGreen},
+		{6.0, colYellow},
+		{20.0, colRed},
+	}
+
+	for _, tt := range tests {
+		if got := activityColor(tt.grDB); got != tt.expected {
+			t.Errorf("activityColor(%.1f) = %v, want %v", tt.grDB, got, tt.expected)
+		}
+	}
+}
+
+// TestAutoMakeupReadout verifies the readout matches the auto makeup formula
+// -threshold * (1 - 1/ratio) for the current settings.
+func TestAutoMakeupReadout(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+	comp.SetAutoMakeup(true)
+	comp.SetThreshold(-24.0)
+	comp.SetRatio(3.0)
+
+	readout := func() string {
+		return autoMakeupReadout(comp.GetAutoMakeup(), comp.GetAppliedMakeupGain(), comp.GetMakeupGain())
+	}
+
+	if got, want := readout(), "Auto Makeup: +16.0 dB"; got != want {
+		t.Errorf("Readout %q, want %q", got, want)
+	}
+
+	comp.SetRatio(1.0)
+
+	if got, want := readout(), "Auto Makeup: +0.0 dB"; got != want {
+		t.Errorf("Readout at 1:1 %q, want %q", got, want)
+	}
+
+	comp.SetAutoMakeup(false)
+
+	if got, want := readout(), "Auto Makeup: off"; got != want {
+		t.Errorf("Readout with auto makeup disabled %q, want %q", got, want)
+	}
+}
+
+// TestAutoMakeupReadoutBackedOff verifies the readout shows the makeup that
+// headroom-aware makeup actually applies on a hot signal, next to the nominal
+// value.
+func TestAutoMakeupReadoutBackedOff(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 1)
+	comp.SetAutoMakeup(true)
+	comp.SetThreshold(-40.0)
+	comp.SetRatio(4.0)
+	comp.SetHeadroomAwareMakeup(true)
+
+	in := make([]float32, 48000)
+	for i := range in {
+		in[i] = float32(0.9 * math.Sin(2.0*math.Pi*100.0*float64(i)/48000.0))
+	}
+
+	comp.ProcessBlock(in, make([]float32, len(in)), 0)
+
+	applied, nominal := comp.GetAppliedMakeupGain(), comp.GetMakeupGain()
+	if applied >= nominal-1.0 {
+		t.Fatalf("Expected the makeup to back off on a hot signal: applied %.1f dB, nominal %.1f dB", applied, nominal)
+	}
+
+	want := fmt.Sprintf("Auto Makeup: %+.1f dB (of +30.0)", applied)
+	if got := autoMakeupReadout(true, applied, nominal); got != want {
+		t.Errorf("Readout %q, want %q", got, want)
+	}
+}
+
+// TestRatioKeyUpdatesAutoMakeup verifies changing the ratio or threshold with
+// the arrow keys recomputes the auto makeup immediately.
+func TestRatioKeyUpdatesAutoMakeup(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+	comp.SetAutoMakeup(true)
+	comp.SetThreshold(-24.0)
+	comp.SetRatio(3.0)
+
+	state := &TUIState{comp: comp, selectedParam: 1}
+	handleKey(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyArrowRight}, state)
+
+	// Ratio 3.5: 24 * (1 - 1/3.5)
+	if got, want := comp.GetMakeupGain(), 24.0*(1.0-1.0/3.5); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Makeup after the ratio change %.4f dB, want %.4f dB", got, want)
+	}
+
+	state.selectedParam = 0
+	handleKey(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyArrowLeft}, state)
+
+	// Threshold -24.5 dB
+	if got, want := comp.GetMakeupGain(), 24.5*(1.0-1.0/3.5); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Makeup after the threshold change %.4f dB, want %.4f dB", got, want)
+	}
+
+	if got, want := autoMakeupReadout(comp.GetAutoMakeup(), comp.GetMakeupGain(), comp.GetMakeupGain()), "Auto Makeup: +17.5 dB"; got != want {
+		t.Errorf("Readout %q, want %q", got, want)
+	}
+}
+
+// TestRangeReadout verifies an unlimited range reads "off".
+func TestRangeReadout(t *testing.T) {
+	t.Parallel()
+
+	if got, want := rangeReadout(0.0), "off"; got != want {
+		t.Errorf("Readout %q, want %q", got, want)
+	}
+
+	if got, want := rangeReadout(12.0), "12.0"; got != want {
+		t.Errorf("Readout %q, want %q", got, want)
+	}
+}
+
+// TestNextDim verifies the dim key toggles between off and the dim level.
+func TestNextDim(t *testing.T) {
+	t.Parallel()
+
+	if got := nextDim(0.0, -20.0); got != -20.0 {
+		t.Errorf("nextDim(off) = %.1f, want -20", got)
+	}
+
+	if got := nextDim(-20.0, -20.0); got != 0.0 {
+		t.Errorf("nextDim(-20) = %.1f, want 0", got)
+	}
+}
+
+// TestClipCounts verifies the clip count readout of clipped input.
+func TestClipCounts(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 1)
+	comp.SetBypass(true)
+
+	hot := []float32{1.5, 0.5, -1.0, 0.2}
+	comp.ProcessBlock(hot, make([]float32, len(hot)), 0)
+
+	if got, want := clipCounts(comp, 0), "Clips in 2 / out 2"; got != want {
+		t.Errorf("Readout %q, want %q", got, want)
+	}
+
+	if got := clipCounts(comp, 1); got != "" {
+		t.Errorf("Readout for a missing channel %q, want empty", got)
+	}
+}
+
+// TestBlockGainReduction verifies the block readout shows the peak and average
+// reduction and stays empty before the first block.
+func TestBlockGainReduction(t *testing.T) {
+	t.Parallel()
+
+	if got, want := blockGainReduction(dsp.DBToLinear(-12.0), dsp.DBToLinear(-1.5)), "Block pk 12.0 avg  1.5 dB"; got != want {
+		t.Errorf("Readout %q, want %q", got, want)
+	}
+
+	if got, want := blockGainReduction(1.0, 1.0), "Block pk  0.0 avg  0.0 dB"; got != want {
+		t.Errorf("Readout at unity gain %q, want %q", got, want)
+	}
+
+	if got := blockGainReduction(0, 0); got != "" {
+		t.Errorf("Readout before the first block %q, want empty", got)
+	}
+}
+
+// TestGRFill verifies the log gain reduction fill gives the low end more of
+// the bar than the linear fill, while both span the same range.
+func TestGRFill(t *testing.T) {
+	t.Parallel()
+
+	for _, grDB := range []float64{1.0, 3.0, 12.0} {
+		linear, log := grFill(grDB, grScaleLinear), grFill(grDB, grScaleLog)
+
+		if want := grDB / grMeterRangeDB; linear != want {
+			t.Errorf("Linear fill at %.0f dB = %.3f, want %.3f", grDB, linear, want)
+		}
+
+		if log <= linear {
+			t.Errorf("Log fill at %.0f dB = %.3f, want more than the linear %.3f", grDB, log, linear)
+		}
+	}
+
+	// The first 3 dB take up at least a third of the log bar
+	if fill := grFill(3.0, grScaleLog); fill < 1.0/3.0 {
+		t.Errorf("Log fill at 3 dB = %.3f, want at least a third", fill)
+	}
+
+	for _, scale := range []grScale{grScaleLinear, grScaleLog} {
+		if empty, full := grFill(-1.0, scale), grFill(30.0, scale); empty != 0 || full != 1 {
+			t.Errorf("Fill of scale %d spans %.3f..%.3f, want 0..1", scale, empty, full)
+		}
+	}
+
+	if nextGRScale(nextGRScale(grScaleLinear)) != grScaleLinear || nextGRScale(grScaleLinear) != grScaleLog {
+		t.Error("GR scale toggle doesn't alternate linear and log")
+	}
+}
+
+// TestPresetSlots verifies storing a slot preserves all parameters and
+// recalling it restores them, and that empty slots leave the settings alone.
+func TestPresetSlots(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 2)
+	comp.SetThreshold(-30.0)
+	comp.SetRatio(6.0)
+	comp.SetKnee(3.0)
+	comp.SetAttack(5.0)
+	comp.SetRelease(250.0)
+	comp.SetSpeed(0.5)
+	comp.SetRange(12.0)
+	comp.SetMakeupGain(4.5)
+	comp.SetMakeupBypass(true)
+	comp.SetOutputGain(-2.0)
+
+	state := &TUIState{comp: comp}
+	stored := captureParams(comp)
+
+	handleKey(termbox.Event{Type: termbox.EventKey, Ch: '@'}, state)
+
+	if state.slots[1] == nil || *state.slots[1] != stored {
+		t.Fatalf("Slot 2 holds %+v, want %+v", state.slots[1], stored)
+	}
+
+	// Change everything, including switching to auto makeup
+	comp.SetThreshold(-10.0)
+	comp.SetRatio(2.0)
+	comp.SetKnee(9.0)
+	comp.SetAttack(20.0)
+	comp.SetRelease(80.0)
+	comp.SetSpeed(2.0)
+	comp.SetRange(0.0)
+	comp.SetAutoMakeup(true)
+	comp.SetMakeupBypass(false)
+	comp.SetOutputGain(1.0)
+
+	handleKey(termbox.Event{Type: termbox.EventKey, Ch: '2'}, state)
+
+	if got := captureParams(comp); got != stored {
+		t.Errorf("Recalled %+v, want %+v", got, stored)
+	}
+
+	if comp.GetAutoMakeup() {
+		t.Error("Recalling a manual makeup slot should turn auto makeup off")
+	}
+
+	if comp.GetSpeed() != 0.5 || !comp.GetMakeupBypass() {
+		t.Errorf("Recalled speed %.1f and makeup bypass %t, want 0.5 and true",
+			comp.GetSpeed(), comp.GetMakeupBypass())
+	}
+
+	before := captureParams(comp)
+	handleKey(termbox.Event{Type: termbox.EventKey, Ch: '3'}, state)
+
+	if got := captureParams(comp); got != before {
+		t.Errorf("Recalling an empty slot changed the settings to %+v", got)
+	}
+
+	if state.status != "Slot 3 is empty (Shift+3 stores)" {
+		t.Errorf("Unexpected status %q", state.status)
+	}
+}
+
+// TestBalanceIndicator verifies the marker leans towards the hotter channel
+// and is clamped at the ends of the scale.
+func TestBalanceIndicator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		balanceDB float64
+		want      string
+	}{
+		{0.0, "Balance  L [----------●----------] R  +0.0 dB"},
+		{6.0, "Balance  L [-----●----|----------] R  +6.0 dB"},
+		{-30.0, "Balance  L [----------|---------●] R  -30.0 dB"},
+	}
+
+	for _, tt := range tests {
+		if got := balanceIndicator(tt.balanceDB); got != tt.want {
+			t.Errorf("balanceIndicator(%.1f) = %q, want %q", tt.balanceDB, got, tt.want)
+		}
+	}
+}
+
+// TestGainHistoryLine verifies the line is right-aligned, keeps only the newest
+// entries and scales the bars with the gain reduction.
+func TestGainHistoryLine(t *testing.T) {
+	t.Parallel()
+
+	gains := []float64{dsp.DBToLinear(-24.0), 1.0, dsp.DBToLinear(-12.0), dsp.DBToLinear(-48.0)}
+
+	if got, want := gainHistoryLine(gains, 6), "  █ ▄█"; got != want {
+		t.Errorf("gainHistoryLine = %q, want %q", got, want)
+	}
+
+	if got, want := gainHistoryLine(gains, 2), "▄█"; got != want {
+		t.Errorf("gainHistoryLine truncated = %q, want %q", got, want)
+	}
+
+	if got, want := gainHistoryLine(nil, 3), "   "; got != want {
+		t.Errorf("gainHistoryLine without history = %q, want %q", got, want)
+	}
+}
+
+// TestAutoTune verifies the auto-tune key applies the suggestion for the
+// captured input and reports when nothing has been captured yet.
+func TestAutoTune(t *testing.T) {
+	t.Parallel()
+
+	comp := dsp.NewSoftKneeCompressor(48000.0, 1)
+
+	if got := autoTune(comp); got != "Auto-tune: no input captured yet" {
+		t.Errorf("Unexpected status without capture: %q", got)
+	}
+
+	comp.SetInputCapture(1.0)
+
+	in := GenerateCalibrationTone(-12.0, 48000.0, 48000)
+	comp.ProcessBlock(in, make([]float32, len(in)), 0)
+
+	want := comp.AnalyzeAndSuggest(comp.RecentInput())
+	status := autoTune(comp)
+
+	if comp.GetAttack() != want.AttackMs || comp.GetRelease() != want.ReleaseMs || comp.GetRatio() != want.Ratio {
+		t.Errorf("Auto-tune should apply %+v, got attack %f, release %f, ratio %f",
+			want, comp.GetAttack(), comp.GetRelease(), comp.GetRatio())
+	}
+
+	if !strings.HasPrefix(status, "Auto-tune: attack") {
+		t.Errorf("Unexpected status: %q", status)
+	}
+}
+
+// TestNextSolo verifies the solo key cycles through every channel and back to off.
+func TestNextSolo(t *testing.T) {
+	t.Parallel()
+
+	solo := -1
+	visited := []int{}
+
+	for range 4 {
+		solo = nextSolo(solo, 3)
+		visited = append(visited, solo)
+	}
+
+	want := []int{0, 1, 2, -1}
+	for i := range want {
+		if visited[i] != want[i] {
+			t.Fatalf("Solo cycle %v, want %v", visited, want)
+		}
+	}
+}
+
+// TestFormatPeakHold verifies the held peaks are shown in dB with the hold mode.
+func TestFormatPeakHold(t *testing.T) {
+	t.Parallel()
+
+	meters := dsp.MeterStats{PeakHoldInputL: 1.0, PeakHoldInputR: 0.5, PeakHoldOutputL: 0.25, PeakHoldOutputR: 0.1}
+
+	if got, want := formatPeakHold(meters, false), "Hold In [  0.0  -6.0] Out [-12.0 -20.0] dB"; got != want {
+		t.Errorf("formatPeakHold = %q, want %q", got, want)
+	}
+
+	if got := formatPeakHold(meters, true); !strings.HasPrefix(got, "Hold∞") {
+		t.Errorf("Infinity hold should be marked, got %q", got)
+	}
+}
+
+// TestEventPollerStops verifies stopping the poller ends its goroutine whether
+// it waits in the poll or on an event nobody receives anymore.
+func TestEventPollerStops(t *testing.T) {
+	t.Parallel()
+
+	for _, pending := range []bool{false, true} {
+		source := make(chan termbox.Event)
+		poller := startEventPoller(
+			func() termbox.Event { return <-source },
+			func() { source <- termbox.Event{Type: termbox.EventInterrupt} },
+		)
+
+		source <- termbox.Event{Type: termbox.EventKey, Ch: 'a'}
+
+		if got := <-poller.events; got.Ch != 'a' {
+			t.Fatalf("Forwarded event %+v, want key 'a'", got)
+		}
+
+		if pending {
+			// The goroutine now blocks sending an event that is never received
+			source <- termbox.Event{Type: termbox.EventKey, Ch: 'b'}
+		}
+
+		stopped := make(chan struct{})
+
+		go func() {
+			poller.stop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Poller did not stop (pending event: %v)", pending)
+		}
+	}
+}